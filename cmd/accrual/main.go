@@ -0,0 +1,85 @@
+// Система расчёта начислений баллов лояльности для локальной разработки и тестов.
+// Реализует протокол из спецификации: регистрация заказов (POST /api/orders), правил вознаграждения
+// (POST /api/goods) и получение расчёта по заказу (GET /api/orders/{number}). Данные хранятся в памяти.
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/k1nky/gophermart/internal/logger"
+	flag "github.com/spf13/pflag"
+)
+
+const (
+	DefaultCloseTimeout = 5 * time.Second
+)
+
+// Config конфигурация системы расчёта
+type Config struct {
+	// адрес и порт сервиса: переменная окружения ОС `RUN_ADDRESS` или флаг `-a`
+	RunAddress string `env:"RUN_ADDRESS"`
+	// максимальное количество запросов информации о расчёте в минуту, 0 - без ограничений: переменная окружения ОС `RATE_LIMIT` или флаг `--rate-limit`
+	RateLimit uint `env:"RATE_LIMIT"`
+	// время каждого перехода заказа между статусами: переменная окружения ОС `PROCESSING_DELAY` или флаг `--processing-delay`
+	ProcessingDelay time.Duration `env:"PROCESSING_DELAY"`
+	// адрес для уведомлений об изменении расчёта: переменная окружения ОС `CALLBACK_URL` или флаг `--callback-url`
+	CallbackURL string `env:"CALLBACK_URL"`
+	// ключ подписи уведомлений: переменная окружения ОС `CALLBACK_SECRET` или флаг `--callback-secret`
+	CallbackSecret string `env:"CALLBACK_SECRET"`
+	// уровень логирования: переменная окружения ОС `LOG_LEVEL` или флаг `-l`
+	LogLevel string `env:"LOG_LEVEL"`
+}
+
+// Разбирает настройки из аргументов командной строки и переменных окружения.
+// Переменные окружения имеют более высокий приоритет, чем аргументы.
+func parseConfig(c *Config) error {
+	cmd := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	cmd.StringVarP(&c.RunAddress, "run-address", "a", ":8080", "адрес и порт запуска сервиса")
+	cmd.UintVar(&c.RateLimit, "rate-limit", 0, "максимальное количество запросов информации о расчёте в минуту, 0 - без ограничений")
+	cmd.DurationVar(&c.ProcessingDelay, "processing-delay", time.Second, "время каждого перехода заказа между статусами")
+	cmd.StringVar(&c.CallbackURL, "callback-url", "", "адрес для уведомлений об изменении расчёта, пустой - уведомления не отправляются")
+	cmd.StringVar(&c.CallbackSecret, "callback-secret", "", "ключ подписи уведомлений")
+	cmd.StringVarP(&c.LogLevel, "log-level", "l", "info", "уровень логирования")
+	// совместимость с параметрами запуска исходной системы расчёта, данные хранятся в памяти
+	cmd.StringP("database-uri", "d", "", "не используется")
+	if err := cmd.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+	return env.Parse(c)
+}
+
+func main() {
+	cfg := Config{}
+	if err := parseConfig(&cfg); err != nil {
+		panic(err)
+	}
+	log := logger.New()
+	log.SetLevel(cfg.LogLevel)
+	log.Debugf("config: %+v", cfg)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	srv := &http.Server{
+		Addr:    cfg.RunAddress,
+		Handler: NewServer(cfg, log).Router(),
+	}
+	go func() {
+		<-ctx.Done()
+		c, cancel := context.WithTimeout(context.Background(), DefaultCloseTimeout)
+		defer cancel()
+		srv.Shutdown(c)
+	}()
+	log.Infof("accrual: listening on %s", cfg.RunAddress)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Errorf("accrual: %v", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/logger"
+)
+
+const (
+	// таймаут отправки уведомления об изменении расчёта
+	DefaultCallbackTimeout = 5 * time.Second
+)
+
+type registerOrderRequest struct {
+	Order order.OrderNumber `json:"order"`
+	Goods []Good            `json:"goods"`
+}
+
+// Система расчёта начислений баллов лояльности
+type Server struct {
+	store    *Store
+	throttle *Throttle
+	// время каждого перехода заказа между статусами REGISTERED -> PROCESSING -> PROCESSED/INVALID
+	processingDelay time.Duration
+	// адрес, на который отправляются уведомления об изменении расчёта, пустой - уведомления не отправляются
+	callbackURL    string
+	callbackSecret []byte
+	cli            *http.Client
+	log            *logger.Logger
+}
+
+func NewServer(cfg Config, log *logger.Logger) *Server {
+	return &Server{
+		store:           NewStore(),
+		throttle:        NewThrottle(cfg.RateLimit),
+		processingDelay: cfg.ProcessingDelay,
+		callbackURL:     cfg.CallbackURL,
+		callbackSecret:  []byte(cfg.CallbackSecret),
+		cli:             &http.Client{Timeout: DefaultCallbackTimeout},
+		log:             log,
+	}
+}
+
+func (s *Server) Router() http.Handler {
+	r := chi.NewRouter()
+	r.Post("/api/orders", s.RegisterOrder)
+	r.Post("/api/goods", s.RegisterRule)
+	r.With(s.throttle.Middleware).Get("/api/orders/{number}", s.GetOrder)
+	return r
+}
+
+// Регистрация нового заказа. Расчёт начисления выполняется асинхронно.
+// Коды ответа: `202` — заказ принят в обработку, `400` — неверный формат запроса, `409` — заказ уже зарегистрирован.
+func (s *Server) RegisterOrder(w http.ResponseWriter, r *http.Request) {
+	request := registerOrderRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Order) == 0 {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	if err := s.store.AddOrder(request.Order, request.Goods); err != nil {
+		if errors.Is(err, ErrOrderExists) {
+			http.Error(w, "", http.StatusConflict)
+		} else {
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	s.schedule(request.Order)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Регистрация правила вознаграждения за товары.
+// Коды ответа: `200` — правило зарегистрировано, `400` — неверный формат запроса, `409` — правило с таким ключом уже зарегистрировано.
+func (s *Server) RegisterRule(w http.ResponseWriter, r *http.Request) {
+	rule := Rule{}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	if len(rule.Match) == 0 || rule.Reward <= 0 || (rule.RewardType != RewardPercent && rule.RewardType != RewardPoints) {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	if err := s.store.AddRule(rule); err != nil {
+		if errors.Is(err, ErrRuleExists) {
+			http.Error(w, "", http.StatusConflict)
+		} else {
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Получение информации о расчёте начислений баллов лояльности в соответствии со спецификацией.
+func (s *Server) GetOrder(w http.ResponseWriter, r *http.Request) {
+	o := s.store.GetOrder(order.OrderNumber(chi.URLParam(r, "number")))
+	if o == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(o); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+	}
+}
+
+// Планирует переходы заказа REGISTERED -> PROCESSING -> PROCESSED/INVALID
+func (s *Server) schedule(number order.OrderNumber) {
+	time.AfterFunc(s.processingDelay, func() {
+		s.setStatus(number, order.StatusProcessing, nil)
+		time.AfterFunc(s.processingDelay, func() {
+			s.process(number)
+		})
+	})
+}
+
+// Рассчитывает начисление по заказу. Заказ с некорректным номером не принимается к расчёту.
+// Если ни одно правило не подошло, то расчёт оканчивается без начисления.
+func (s *Server) process(number order.OrderNumber) {
+	o := s.store.GetOrder(number)
+	if o == nil {
+		return
+	}
+	if !number.IsValid() {
+		s.setStatus(number, order.StatusInvalid, nil)
+		return
+	}
+	var accrual *float64
+	if total, ok := s.store.Calculate(o.Goods); ok && total > 0 {
+		accrual = &total
+	}
+	s.setStatus(number, order.StatusProcessed, accrual)
+}
+
+func (s *Server) setStatus(number order.OrderNumber, status order.OrderStatus, accrual *float64) {
+	o := s.store.SetStatus(number, status, accrual)
+	if o == nil {
+		return
+	}
+	s.log.Debugf("order #%s: %s", number, status)
+	if len(s.callbackURL) != 0 {
+		go s.notify(*o)
+	}
+}
+
+// Отправляет уведомление об изменении расчёта, подписанное общим ключом
+func (s *Server) notify(o Order) {
+	body, err := json.Marshal(o)
+	if err != nil {
+		s.log.Errorf("notify order #%s: %v", o.Number, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCallbackTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.callbackURL, bytes.NewReader(body))
+	if err != nil {
+		s.log.Errorf("notify order #%s: %v", o.Number, err)
+		return
+	}
+	mac := hmac.New(sha256.New, s.callbackSecret)
+	mac.Write(body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Accrual-Signature", hex.EncodeToString(mac.Sum(nil)))
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		s.log.Errorf("notify order #%s: %v", o.Number, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		s.log.Errorf("notify order #%s: unexpected status %d", o.Number, resp.StatusCode)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(cfg Config) *httptest.Server {
+	cfg.ProcessingDelay = 10 * time.Millisecond
+	return httptest.NewServer(NewServer(cfg, logger.New()).Router())
+}
+
+func post(t *testing.T, url string, body string) int {
+	resp, err := http.Post(url, "application/json", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func getOrder(t *testing.T, url string) (int, Order) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	o := Order{}
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&o))
+	}
+	return resp.StatusCode, o
+}
+
+func TestOrderProcessing(t *testing.T) {
+	ts := newTestServer(Config{})
+	defer ts.Close()
+
+	assert.Equal(t, http.StatusOK, post(t, ts.URL+"/api/goods", `{"match":"Bork","reward":10,"reward_type":"%"}`))
+	assert.Equal(t, http.StatusOK, post(t, ts.URL+"/api/goods", `{"match":"Чайник","reward":15,"reward_type":"pt"}`))
+	assert.Equal(t, http.StatusConflict, post(t, ts.URL+"/api/goods", `{"match":"Bork","reward":5,"reward_type":"pt"}`))
+	assert.Equal(t, http.StatusBadRequest, post(t, ts.URL+"/api/goods", `{"match":"LG","reward":5,"reward_type":"x"}`))
+
+	assert.Equal(t, http.StatusAccepted, post(t, ts.URL+"/api/orders", `{"order":"12345678903","goods":[{"description":"Утюг Bork","price":7000},{"description":"Чайник LG","price":3000}]}`))
+	assert.Equal(t, http.StatusAccepted, post(t, ts.URL+"/api/orders", `{"order":"12345678901","goods":[{"description":"Утюг Bork","price":7000}]}`))
+	assert.Equal(t, http.StatusAccepted, post(t, ts.URL+"/api/orders", `{"order":"9278923470","goods":[{"description":"Стол","price":7000}]}`))
+	assert.Equal(t, http.StatusConflict, post(t, ts.URL+"/api/orders", `{"order":"12345678903","goods":[]}`))
+
+	code, o := getOrder(t, ts.URL+"/api/orders/12345678903")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, order.StatusRegistered, o.Status)
+	code, _ = getOrder(t, ts.URL+"/api/orders/100")
+	assert.Equal(t, http.StatusNoContent, code)
+
+	assert.Eventually(t, func() bool {
+		_, o := getOrder(t, ts.URL+"/api/orders/12345678903")
+		return o.Status == order.StatusProcessed
+	}, time.Second, 10*time.Millisecond)
+	_, o = getOrder(t, ts.URL+"/api/orders/12345678903")
+	require.NotNil(t, o.Accrual)
+	assert.Equal(t, 715.0, *o.Accrual)
+	// номер заказа не проходит проверку по алгоритму Луна
+	_, o = getOrder(t, ts.URL+"/api/orders/12345678901")
+	assert.Equal(t, order.StatusInvalid, o.Status)
+	// ни одно правило не подошло
+	_, o = getOrder(t, ts.URL+"/api/orders/9278923470")
+	assert.Equal(t, order.StatusProcessed, o.Status)
+	assert.Nil(t, o.Accrual)
+}
+
+func TestThrottle(t *testing.T) {
+	ts := newTestServer(Config{RateLimit: 2})
+	defer ts.Close()
+
+	for i := 0; i < 2; i++ {
+		code, _ := getOrder(t, ts.URL+"/api/orders/1")
+		assert.Equal(t, http.StatusNoContent, code)
+	}
+	resp, err := http.Get(ts.URL + "/api/orders/1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "60", resp.Header.Get("Retry-After"))
+	assert.Equal(t, "No more than 2 requests per minute allowed", string(body))
+	// регистрация заказов не ограничивается
+	assert.Equal(t, http.StatusAccepted, post(t, ts.URL+"/api/orders", `{"order":"12345678903"}`))
+}
+
+func TestCallback(t *testing.T) {
+	const secret = "secret"
+	notifications := make(chan Order, 3)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), r.Header.Get("X-Accrual-Signature"))
+		o := Order{}
+		assert.NoError(t, json.Unmarshal(body, &o))
+		notifications <- o
+	}))
+	defer callback.Close()
+	ts := newTestServer(Config{CallbackURL: callback.URL, CallbackSecret: secret})
+	defer ts.Close()
+
+	assert.Equal(t, http.StatusAccepted, post(t, ts.URL+"/api/orders", `{"order":"12345678903"}`))
+	for _, status := range []order.OrderStatus{order.StatusProcessing, order.StatusProcessed} {
+		select {
+		case o := <-notifications:
+			assert.Equal(t, order.OrderNumber("12345678903"), o.Number)
+			assert.Equal(t, status, o.Status)
+		case <-time.After(time.Second):
+			assert.Fail(t, "notification not received")
+		}
+	}
+}
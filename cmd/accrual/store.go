@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/k1nky/gophermart/internal/entity/order"
+)
+
+// Тип вознаграждения
+const (
+	// процент от стоимости товара
+	RewardPercent = "%"
+	// фиксированное количество баллов
+	RewardPoints = "pt"
+)
+
+var (
+	ErrOrderExists = errors.New("order already registered")
+	ErrRuleExists  = errors.New("reward rule already registered")
+)
+
+// Товар в составе заказа
+type Good struct {
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+}
+
+// Правило вознаграждения за товары, в описании которых встречается Match
+type Rule struct {
+	Match      string  `json:"match"`
+	Reward     float64 `json:"reward"`
+	RewardType string  `json:"reward_type"`
+}
+
+// Заказ, зарегистрированный в системе расчёта
+type Order struct {
+	Number  order.OrderNumber `json:"order"`
+	Status  order.OrderStatus `json:"status"`
+	Accrual *float64          `json:"accrual,omitempty"`
+	Goods   []Good            `json:"-"`
+}
+
+// Хранилище заказов и правил вознаграждения в памяти
+type Store struct {
+	mu     sync.RWMutex
+	orders map[order.OrderNumber]*Order
+	rules  []Rule
+}
+
+func NewStore() *Store {
+	return &Store{
+		orders: make(map[order.OrderNumber]*Order),
+	}
+}
+
+// Регистрирует новое правило вознаграждения
+func (s *Store) AddRule(r Rule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range s.rules {
+		if v.Match == r.Match {
+			return ErrRuleExists
+		}
+	}
+	s.rules = append(s.rules, r)
+	return nil
+}
+
+// Регистрирует новый заказ со статусом REGISTERED
+func (s *Store) AddOrder(number order.OrderNumber, goods []Good) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orders[number]; ok {
+		return ErrOrderExists
+	}
+	s.orders[number] = &Order{
+		Number: number,
+		Status: order.StatusRegistered,
+		Goods:  goods,
+	}
+	return nil
+}
+
+// Возвращает копию заказа или nil, если заказ не зарегистрирован
+func (s *Store) GetOrder(number order.OrderNumber) *Order {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	o, ok := s.orders[number]
+	if !ok {
+		return nil
+	}
+	c := *o
+	return &c
+}
+
+// Переводит заказ в статус status и возвращает копию заказа
+func (s *Store) SetStatus(number order.OrderNumber, status order.OrderStatus, accrual *float64) *Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orders[number]
+	if !ok {
+		return nil
+	}
+	o.Status = status
+	o.Accrual = accrual
+	c := *o
+	return &c
+}
+
+// Рассчитывает вознаграждение за товары. Для каждого товара применяется первое подходящее правило.
+// Возвращает ложь, если ни одно правило не подошло.
+func (s *Store) Calculate(goods []Good) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var (
+		total   float64
+		matched bool
+	)
+	for _, g := range goods {
+		for _, r := range s.rules {
+			if !strings.Contains(g.Description, r.Match) {
+				continue
+			}
+			matched = true
+			switch r.RewardType {
+			case RewardPercent:
+				total += g.Price * r.Reward / 100
+			case RewardPoints:
+				total += r.Reward
+			}
+			break
+		}
+	}
+	return total, matched
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Ограничивает количество запросов в минуту по фиксированному окну.
+// При превышении лимита отвечает кодом 429 в формате спецификации системы расчёта.
+type Throttle struct {
+	mu          sync.Mutex
+	limit       uint
+	count       uint
+	windowStart time.Time
+}
+
+// Создает ограничитель на limit запросов в минуту, 0 - без ограничений
+func NewThrottle(limit uint) *Throttle {
+	return &Throttle{
+		limit: limit,
+	}
+}
+
+// Учитывает запрос и возвращает время до начала следующего окна, если лимит превышен
+func (t *Throttle) take(now time.Time) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Sub(t.windowStart) >= time.Minute {
+		t.windowStart = now
+		t.count = 0
+	}
+	if t.count >= t.limit {
+		return t.windowStart.Add(time.Minute).Sub(now), false
+	}
+	t.count++
+	return 0, true
+}
+
+func (t *Throttle) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if t.limit == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		retryAfter, ok := t.take(time.Now())
+		if !ok {
+			// округляем вверх до целых секунд
+			seconds := int((retryAfter + time.Second - 1) / time.Second)
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprintf(w, "No more than %d requests per minute allowed", t.limit)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
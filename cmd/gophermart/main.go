@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -11,15 +12,29 @@ import (
 	"github.com/k1nky/gophermart/internal/adapter/database"
 	"github.com/k1nky/gophermart/internal/adapter/http"
 	"github.com/k1nky/gophermart/internal/config"
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
 	"github.com/k1nky/gophermart/internal/logger"
 	"github.com/k1nky/gophermart/internal/service/account"
 	accural "github.com/k1nky/gophermart/internal/service/accrual"
+	"github.com/k1nky/gophermart/internal/service/admin"
 	"github.com/k1nky/gophermart/internal/service/auth"
+	"github.com/k1nky/gophermart/internal/service/ledger"
+	"github.com/k1nky/gophermart/internal/service/rules"
 )
 
 const (
 	DefaultSecret          = "secret"
 	DefaultTokenExpiration = 3 * time.Hour
+	// имя блокировки для выбора лидера среди экземпляров сервиса
+	LeaderLockName = "gophermart"
+	// время на завершение обработки взятых заказов при остановке сервиса
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
+const (
+	// проверка журнала транзакций пользователей
+	CommandVerifyLedger = "verify-ledger"
 )
 
 func main() {
@@ -34,23 +49,155 @@ func main() {
 
 	ctx, _ := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 
-	run(ctx, cfg, log)
-
-	<-ctx.Done()
-	time.Sleep(1 * time.Second)
+	switch cfg.Command {
+	case "":
+		run(ctx, cfg, log)
+	case CommandVerifyLedger:
+		if !verifyLedger(ctx, cfg, log) {
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", cfg.Command)
+		os.Exit(2)
+	}
 }
 
+// Запускает сервис и ожидает отмены контекста ctx, после чего останавливает сервис
 func run(ctx context.Context, cfg config.Config, log *logger.Logger) {
+	validator, err := newOrderValidator(cfg)
+	if err != nil {
+		log.Errorf("%v", err)
+		return
+	}
+	tenants, err := newTenantDirectory(cfg)
+	if err != nil {
+		log.Errorf("%v", err)
+		return
+	}
 	store := database.New()
 	if err := store.Open(ctx, cfg.DarabaseURI); err != nil {
 		log.Errorf("failed opening db: %v", err)
 		return
 	}
+	store.SetPoolSize(cfg.AccrualWorkers)
 	authService := auth.New(DefaultSecret, DefaultTokenExpiration, store, log)
-	account := account.New(store, log)
-	accrualClient := accrual.New(cfg.AccrualSystemAddress)
-	accrual := accural.New(store, accrualClient, log)
-	accrual.Process(ctx)
-	httpServer := http.New(authService, account, log)
-	httpServer.ListenAndServe(ctx, cfg.RunAddress.String())
+	account := account.New(store, log).WithValidator(validator).WithTenants(tenants)
+	// собственная система расчёта начислений по правилам расчёта вознаграждений
+	rulesEngine := rules.New(store, log)
+	// заказы, не подходящие ни под одну дополнительную систему начислений, направляются в основную
+	var accrualRouter *accural.Router
+	if cfg.AccrualRules {
+		accrualRouter = accural.NewRouter(rulesEngine)
+	} else {
+		defaultAccrual := accrual.New(cfg.AccrualSystemAddress, cfg.AccrualRateLimit)
+		if cfg.AccrualRegisterOrders {
+			defaultAccrual.WithOrderRegistration()
+		}
+		accrualRouter = accural.NewRouter(defaultAccrual)
+	}
+	for _, p := range cfg.AccrualProviders {
+		if p.Rules {
+			accrualRouter.Add(p.Name, p.Prefixes, p.Merchants, rulesEngine)
+			continue
+		}
+		providerAccrual := accrual.New(p.URL, p.RateLimit).WithAuthorization(p.Authorization)
+		if p.RegisterOrders {
+			providerAccrual.WithOrderRegistration()
+		}
+		accrualRouter.Add(p.Name, p.Prefixes, p.Merchants, providerAccrual)
+	}
+	accrualService := accural.New(store, accrualRouter, accural.Options{
+		Workers:     cfg.AccrualWorkers,
+		GiveUpAfter: cfg.AccrualGiveUpAfter,
+		MaxFailures: cfg.AccrualMaxFailures,
+	}, log)
+	// заказы программ лояльности без собственной системы начислений направляются в общие системы
+	for _, t := range cfg.Tenants {
+		switch {
+		case t.Rules:
+			accrualService.WithTenant(tenant.ID(t.ID), rulesEngine, time.Duration(t.GiveUpAfter))
+		case len(t.AccrualAddress) != 0:
+			tenantAccrual := accrual.New(t.AccrualAddress, t.RateLimit).WithAuthorization(t.Authorization)
+			if t.RegisterOrders {
+				tenantAccrual.WithOrderRegistration()
+			}
+			accrualService.WithTenant(tenant.ID(t.ID), tenantAccrual, time.Duration(t.GiveUpAfter))
+		default:
+			accrualService.WithTenant(tenant.ID(t.ID), nil, time.Duration(t.GiveUpAfter))
+		}
+	}
+	// фоновые задачи выполняются только на экземпляре-лидере
+	elector := database.NewLeaderElector(store, LeaderLockName, 0, log)
+	elector.Register("accrual", accrualService.Run)
+	electorDone := make(chan struct{})
+	go func() {
+		defer close(electorDone)
+		elector.Run(ctx)
+	}()
+	httpServer := http.New(authService, account, accrualService, accrualService, log)
+	if len(cfg.Tenants) != 0 {
+		httpServer.EnableTenants(tenants)
+	}
+	if len(cfg.AccrualCallbackSecret) != 0 {
+		httpServer.EnableAccrualCallback(cfg.AccrualCallbackSecret)
+	}
+	if len(cfg.AdminToken) != 0 {
+		httpServer.EnableAdmin(cfg.AdminToken, admin.New(store, log))
+	}
+	httpDone := httpServer.ListenAndServe(ctx, cfg.RunAddress.String())
+
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+	if err := accrualService.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("accrual: in-flight orders were abandoned on shutdown: %v", err)
+	}
+	// лидерство освобождается, а обработка HTTP-запросов завершается до закрытия подключения к базе
+	<-electorDone
+	<-httpDone
+	store.Close()
+}
+
+// Создает валидатор номеров заказов по схемам проверки из конфигурации
+func newOrderValidator(cfg config.Config) (*order.Validator, error) {
+	rules := make([]order.SchemeRule, 0, len(cfg.OrderNumberSchemes))
+	for _, s := range cfg.OrderNumberSchemes {
+		rules = append(rules, order.SchemeRule{Prefix: s.Prefix, Scheme: order.Scheme(s.Scheme)})
+	}
+	return order.NewValidator(order.Scheme(cfg.OrderNumberScheme), rules...)
+}
+
+// Создает справочник программ лояльности из конфигурации
+func newTenantDirectory(cfg config.Config) (*tenant.Directory, error) {
+	tenants := make([]tenant.Tenant, 0, len(cfg.Tenants))
+	for _, t := range cfg.Tenants {
+		tenants = append(tenants, tenant.Tenant{
+			ID:           tenant.ID(t.ID),
+			Hosts:        t.Hosts,
+			GiveUpAfter:  time.Duration(t.GiveUpAfter),
+			MaxBatchSize: t.MaxBatchSize,
+		})
+	}
+	return tenant.NewDirectory(tenants...)
+}
+
+// Проверяет журналы транзакций пользователей и возвращает ложь, если остались неисправленные расхождения
+func verifyLedger(ctx context.Context, cfg config.Config, log *logger.Logger) bool {
+	store := database.New()
+	if err := store.Open(ctx, cfg.DarabaseURI); err != nil {
+		log.Errorf("failed opening db: %v", err)
+		return false
+	}
+	defer store.Close()
+	report, err := ledger.New(store, log).Verify(ctx, cfg.LedgerRepair)
+	for _, d := range report.Discrepancies {
+		log.Warnf("ledger: %s", d)
+	}
+	if err != nil {
+		log.Errorf("%v", err)
+		return false
+	}
+	log.Infof("ledger: checked %d users, found %d discrepancies, repaired %d users, %d users remain inconsistent",
+		report.Users, len(report.Discrepancies), report.RepairedUsers, report.UnresolvedUsers)
+	return report.UnresolvedUsers == 0
 }
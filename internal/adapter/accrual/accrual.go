@@ -7,10 +7,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/k1nky/gophermart/internal/entity/accrual"
 	"github.com/k1nky/gophermart/internal/entity/order"
 )
 
@@ -19,6 +22,8 @@ const (
 	DefaultRequestTimeout = 5 * time.Second
 	// Количество попыток повторной отправки запроса к сервису начислений
 	DefaultRetryCount = 1
+	// Время ожидания до отправки запроса в случае превышения лимита запросов, если сервис не указал Retry-After
+	DefaultRetryWaitTime = 1 * time.Second
 	// Максимальное время ожидания до отправки запроса в случае превышения лимита запросов к сервису начислений
 	DefaultRetryMaxWaitTime = 90 * time.Second
 )
@@ -27,37 +32,109 @@ var (
 	ErrUnexpectedResponse = errors.New("unexpected response")
 )
 
+// Текст ответа сервиса начислений при превышении лимита запросов
+var tooManyRequestsRe = regexp.MustCompile(`No more than (\d+) requests per minute allowed`)
+
 type orderResponse struct {
 	Order   string   `json:"order"`
 	Status  string   `json:"status"`
 	Accrual *float32 `json:"accrual,omitempty"`
 }
 
+type registerOrderRequest struct {
+	Order string       `json:"order"`
+	Goods []order.Item `json:"goods"`
+}
+
 type Adapter struct {
-	cli *resty.Client
-	url string
+	cli     *resty.Client
+	url     string
+	limiter *Limiter
+	breaker *Breaker
+	// регистрировать заказы со сведениями о покупке перед запросом начисления
+	registerOrders bool
+	// счетчики результатов запросов к сервису
+	succeeded atomic.Uint64
+	failed    atomic.Uint64
+	throttled atomic.Uint64
+	rejected  atomic.Uint64
 }
 
-func New(url string) *Adapter {
+// Создает адаптер к сервису начислений, который выполняет не более requestsPerMinute запросов в минуту, 0 - без ограничений.
+// Если сервис ответит, что лимит запросов превышен, частота запросов будет снижена до разрешенной сервисом.
+func New(url string, requestsPerMinute uint) *Adapter {
 	return &Adapter{
-		url: url,
+		url:     url,
+		limiter: NewLimiter(requestsPerMinute),
+		breaker: NewBreaker(0, 0),
 		cli: resty.New().
-			SetTimeout(DefaultRequestTimeout).
-			SetRetryCount(DefaultRetryCount).
-			SetRetryMaxWaitTime(DefaultRetryMaxWaitTime).
-			SetRetryAfter(func(c *resty.Client, r *resty.Response) (time.Duration, error) {
-				// если в ответе был заголовок Retry-After в формате <seconds>
-				// то постараемся придерживаться его, но не дольше MaxWaitTime
-				s := r.Header().Get("Retry-After")
-				if s == "" {
-					return 0, nil
-				}
-				sec, err := strconv.Atoi(s)
-				if err != nil {
-					return 0, nil
-				}
-				return time.Duration(sec * int(time.Second)), nil
-			}),
+			SetTimeout(DefaultRequestTimeout),
+	}
+}
+
+// Устанавливает значение заголовка Authorization для запросов к сервису начислений
+func (a *Adapter) WithAuthorization(token string) *Adapter {
+	if len(token) != 0 {
+		a.cli.SetHeader("Authorization", token)
+	}
+	return a
+}
+
+// Включает регистрацию заказов со сведениями о покупке в сервисе начислений, см. RegisterOrder
+func (a *Adapter) WithOrderRegistration() *Adapter {
+	a.registerOrders = true
+	return a
+}
+
+// Регистрирует заказ o с позициями покупки в сервисе начислений, чтобы сервис рассчитал по нему начисление.
+// Заказ без сведений о покупке, а также любой заказ, если регистрация не включена WithOrderRegistration,
+// не регистрируется. Уже зарегистрированный заказ не считается ошибкой.
+func (a *Adapter) RegisterOrder(ctx context.Context, o order.Order) error {
+	if !a.registerOrders || o.Purchase == nil {
+		return nil
+	}
+	url, err := url.JoinPath(a.url, "/api/orders")
+	if err != nil {
+		return fmt.Errorf("RegisterOrder: failed: %w", err)
+	}
+	if err := a.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("RegisterOrder: failed: %w", err)
+	}
+	if err := a.breaker.Allow(); err != nil {
+		a.rejected.Add(1)
+		return fmt.Errorf("RegisterOrder: failed: %w", err)
+	}
+	body := registerOrderRequest{
+		Order: string(o.Number),
+		Goods: o.Purchase.Items,
+	}
+	if body.Goods == nil {
+		body.Goods = []order.Item{}
+	}
+	resp, err := a.newRequest().SetContext(ctx).SetHeader("Content-Type", "application/json").SetBody(body).Post(url)
+	if err != nil {
+		if ctx.Err() == nil {
+			a.report(true)
+		}
+		return fmt.Errorf("RegisterOrder: failed: %w", err)
+	}
+	switch resp.StatusCode() {
+	case http.StatusOK, http.StatusAccepted, http.StatusConflict:
+		// заказ принят в обработку или уже был зарегистрирован
+		a.report(false)
+		return nil
+	case http.StatusBadRequest:
+		// сервис доступен, но отказался принять заказ
+		a.report(false)
+		return fmt.Errorf("RegisterOrder: failed with code %d and body %s: %w: %w", resp.StatusCode(), resp.Body(), ErrUnexpectedResponse, order.ErrInvalidAccrualResponse)
+	case http.StatusTooManyRequests:
+		a.breaker.Report(false)
+		a.throttled.Add(1)
+		a.throttle(resp)
+		return fmt.Errorf("RegisterOrder: failed with code %d and body %s: %w", resp.StatusCode(), resp.Body(), ErrUnexpectedResponse)
+	default:
+		a.report(true)
+		return fmt.Errorf("RegisterOrder: failed with code %d and body %s: %w: %w", resp.StatusCode(), resp.Body(), ErrUnexpectedResponse, order.ErrInvalidAccrualResponse)
 	}
 }
 
@@ -68,38 +145,132 @@ func (a *Adapter) newRequest() *resty.Request {
 // Заказ может быть взят в расчёт в любой момент после его совершения.
 // Время выполнения расчёта системой не регламентировано. Статусы `INVALID` и `PROCESSED` являются окончательными.
 // Общее количество запросов информации о начислении не ограничено.
-func (a *Adapter) FetchOrder(ctx context.Context, number order.OrderNumber) (*order.Order, error) {
-	req := a.newRequest()
-	req.AddRetryCondition(func(response *resty.Response, err error) bool {
-		// если в ответ придет TooManyRequests, то сделаем retry в соответствии с настройками клиента,
-		// описанными в конструкторе
-		return response.StatusCode() == http.StatusTooManyRequests
-	})
+// Статус расчёта, не предусмотренный спецификацией, возвращается ошибкой *UnknownStatusError.
+// Метод безопасен для конкурентного использования, частота запросов всех вызовов ограничивается общим ограничителем.
+// Информация запрашивается по номеру заказа o.Number.
+func (a *Adapter) FetchOrder(ctx context.Context, o order.Order) (*order.Order, error) {
+	number := o.Number
 	// Получение информации о расчёте начислений баллов лояльности.
 	url, err := url.JoinPath(a.url, "/api/orders", string(number))
 	if err != nil {
 		return nil, fmt.Errorf("FetchOrder: failed: %w", err)
 	}
-	resp, err := req.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("FetchOrder: failed: %w", err)
+	for attempt := 0; ; attempt++ {
+		if err := a.limiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("FetchOrder: failed: %w", err)
+		}
+		// не обращаемся к сервису, пока он считается недоступным
+		if err := a.breaker.Allow(); err != nil {
+			a.rejected.Add(1)
+			return nil, fmt.Errorf("FetchOrder: failed: %w", err)
+		}
+		resp, err := a.newRequest().SetContext(ctx).Get(url)
+		if err != nil {
+			// отмена запроса не говорит о недоступности сервиса
+			if ctx.Err() == nil {
+				a.report(true)
+			}
+			return nil, fmt.Errorf("FetchOrder: failed: %w", err)
+		}
+
+		switch resp.StatusCode() {
+		case http.StatusOK:
+			responseData := orderResponse{}
+			if err := json.Unmarshal(resp.Body(), &responseData); err != nil {
+				a.report(true)
+				return nil, fmt.Errorf("FetchOrder: malformed body %s: %w: %w", resp.Body(), order.ErrInvalidAccrualResponse, err)
+			}
+			a.report(false)
+			status, err := parseStatus(responseData.Status)
+			if err != nil {
+				return nil, fmt.Errorf("FetchOrder: %w", err)
+			}
+			o := order.Order{
+				Number:  order.OrderNumber(responseData.Order),
+				Accrual: responseData.Accrual,
+				Status:  status,
+				Payload: resp.Body(),
+			}
+			return &o, nil
+		case http.StatusNoContent:
+			a.report(false)
+			// заказ не зарегистрирован в системе расчета.
+			return nil, nil
+		case http.StatusTooManyRequests:
+			// сервис доступен, но ограничивает частоту запросов
+			a.breaker.Report(false)
+			a.throttled.Add(1)
+			// приостанавливаем запросы всех обработчиков и повторяем запрос через ограничитель
+			a.throttle(resp)
+			if attempt < DefaultRetryCount {
+				continue
+			}
+			// исчерпание повторов не говорит о некорректном ответе по заказу
+			return nil, fmt.Errorf("FetchOrder: failed with code %d and body %s: %w", resp.StatusCode(), resp.Body(), ErrUnexpectedResponse)
+		default:
+			a.report(true)
+		}
+		return nil, fmt.Errorf("FetchOrder: failed with code %d and body %s: %w: %w", resp.StatusCode(), resp.Body(), ErrUnexpectedResponse, order.ErrInvalidAccrualResponse)
 	}
+}
 
-	switch resp.StatusCode() {
-	case http.StatusOK:
-		responseData := orderResponse{}
-		if err := json.Unmarshal(resp.Body(), &responseData); err != nil {
-			return nil, err
+// Учитывает результат запроса в автомате защиты и счетчиках
+func (a *Adapter) report(failed bool) {
+	a.breaker.Report(failed)
+	if failed {
+		a.failed.Add(1)
+	} else {
+		a.succeeded.Add(1)
+	}
+}
+
+// Возвращает ложь, если сервис начислений считается недоступным и запросы к нему не выполняются
+func (a *Adapter) Available() bool {
+	return a.breaker.State() != accrual.CircuitOpen
+}
+
+// Возвращает состояние автомата защиты: accrual.CircuitClosed, accrual.CircuitOpen или accrual.CircuitHalfOpen
+func (a *Adapter) CircuitState() accrual.CircuitState {
+	return a.breaker.State()
+}
+
+// Возвращает счетчики результатов запросов к сервису начислений:
+// succeeded - успешные, failed - неудачные, throttled - отклоненные сервисом из-за превышения лимита,
+// rejected - не выполненные из-за разомкнутого автомата защиты
+func (a *Adapter) FetchCounters() map[string]uint64 {
+	return map[string]uint64{
+		"succeeded": a.succeeded.Load(),
+		"failed":    a.failed.Load(),
+		"throttled": a.throttled.Load(),
+		"rejected":  a.rejected.Load(),
+	}
+}
+
+// Применяет к ограничителю запросов ограничения из ответа сервиса о превышении лимита запросов:
+// разрешенную частоту запросов из тела ответа и время ожидания из заголовка Retry-After.
+func (a *Adapter) throttle(resp *resty.Response) {
+	if m := tooManyRequestsRe.FindSubmatch(resp.Body()); m != nil {
+		if n, err := strconv.ParseUint(string(m[1]), 10, 32); err == nil {
+			a.limiter.Adapt(uint(n))
 		}
-		o := order.Order{
-			Number:  order.OrderNumber(responseData.Order),
-			Accrual: responseData.Accrual,
-			Status:  order.OrderStatus(responseData.Status),
+	}
+	a.limiter.Pause(parseRetryAfter(resp.Header().Get("Retry-After")))
+}
+
+// Возвращает время ожидания из заголовка Retry-After в формате <seconds> или HTTP-даты,
+// но не дольше DefaultRetryMaxWaitTime
+func parseRetryAfter(s string) time.Duration {
+	d := DefaultRetryWaitTime
+	if sec, err := strconv.Atoi(s); err == nil && sec >= 0 {
+		d = time.Duration(sec) * time.Second
+	} else if t, err := http.ParseTime(s); err == nil {
+		d = time.Until(t)
+		if d < 0 {
+			d = 0
 		}
-		return &o, nil
-	case http.StatusNoContent:
-		// заказ не зарегистрирован в системе расчета.
-		return nil, nil
 	}
-	return nil, fmt.Errorf("FetchOrder: failed with code %d and body %s: %w", resp.StatusCode(), resp.Body(), ErrUnexpectedResponse)
+	if d > DefaultRetryMaxWaitTime {
+		d = DefaultRetryMaxWaitTime
+	}
+	return d
 }
@@ -2,10 +2,14 @@ package accrual
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/k1nky/gophermart/internal/entity/accrual"
 	"github.com/k1nky/gophermart/internal/entity/order"
 	"github.com/stretchr/testify/assert"
 )
@@ -16,8 +20,8 @@ func TestTooManyRequestWithRetryAfter(t *testing.T) {
 		rw.WriteHeader(http.StatusTooManyRequests)
 	}))
 	defer ts.Close()
-	c := New(ts.URL)
-	_, err := c.FetchOrder(context.TODO(), "1")
+	c := New(ts.URL, 0)
+	_, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
 	assert.ErrorIs(t, err, ErrUnexpectedResponse)
 }
 
@@ -26,9 +30,10 @@ func TestTooManyRequestWithoutRetryAfter(t *testing.T) {
 		rw.WriteHeader(http.StatusTooManyRequests)
 	}))
 	defer ts.Close()
-	c := New(ts.URL)
-	_, err := c.FetchOrder(context.TODO(), "1")
+	c := New(ts.URL, 0)
+	_, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
 	assert.ErrorIs(t, err, ErrUnexpectedResponse)
+	assert.NotErrorIs(t, err, order.ErrInvalidAccrualResponse)
 }
 
 func TestTooManyRequestSuccess(t *testing.T) {
@@ -43,8 +48,8 @@ func TestTooManyRequestSuccess(t *testing.T) {
 		}
 	}))
 	defer ts.Close()
-	c := New(ts.URL)
-	_, err := c.FetchOrder(context.TODO(), "1")
+	c := New(ts.URL, 0)
+	_, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
 	assert.NoError(t, err)
 }
 
@@ -53,26 +58,49 @@ func TestNoContent(t *testing.T) {
 		rw.WriteHeader(http.StatusNoContent)
 	}))
 	defer ts.Close()
-	c := New(ts.URL)
-	o, err := c.FetchOrder(context.TODO(), "1")
+	c := New(ts.URL, 0)
+	o, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
 	assert.NoError(t, err)
 	assert.Nil(t, o)
 }
 
+func TestInternalServerError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	c := New(ts.URL, 0)
+	_, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
+	assert.ErrorIs(t, err, ErrUnexpectedResponse)
+	assert.ErrorIs(t, err, order.ErrInvalidAccrualResponse)
+}
+
+func TestMalformedBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(`{"order":"1", "status":`))
+	}))
+	defer ts.Close()
+	c := New(ts.URL, 0)
+	o, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
+	assert.ErrorIs(t, err, order.ErrInvalidAccrualResponse)
+	assert.Nil(t, o)
+}
+
 func TestFetchOrderWithAccrual(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		rw.Write([]byte(`{"order":"1", "status":"PROCESSED", "accrual": 123.0}`))
 		rw.WriteHeader(http.StatusOK)
 	}))
 	defer ts.Close()
-	c := New(ts.URL)
-	o, err := c.FetchOrder(context.TODO(), "1")
+	c := New(ts.URL, 0)
+	o, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
 	assert.NoError(t, err)
 	v := float32(123.0)
 	assert.Equal(t, &order.Order{
 		Number:  "1",
 		Status:  order.StatusProcessed,
 		Accrual: &v,
+		Payload: []byte(`{"order":"1", "status":"PROCESSED", "accrual": 123.0}`),
 	}, o)
 }
 
@@ -82,12 +110,266 @@ func TestFetchOrderWithoutAccrual(t *testing.T) {
 		rw.WriteHeader(http.StatusOK)
 	}))
 	defer ts.Close()
-	c := New(ts.URL)
-	o, err := c.FetchOrder(context.TODO(), "1")
+	c := New(ts.URL, 0)
+	o, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
 	assert.NoError(t, err)
 	assert.Equal(t, &order.Order{
 		Number:  "1",
 		Status:  order.StatusProcessed,
 		Accrual: nil,
+		Payload: []byte(`{"order":"1", "status":"PROCESSED"}`),
 	}, o)
 }
+
+func TestRateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	c := New(ts.URL, 600)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
+		assert.NoError(t, err)
+	}
+	// первый запрос выполняется сразу, следующие не чаще одного раза в 100ms
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestTooManyRequestAdaptRate(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if requests.Add(1) == 1 {
+			rw.Header().Add("Retry-After", "0")
+			rw.WriteHeader(http.StatusTooManyRequests)
+			rw.Write([]byte("No more than 300 requests per minute allowed"))
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	c := New(ts.URL, 0)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
+		assert.NoError(t, err)
+	}
+	// после ответа 429 запросы выполняются не чаще одного раза в 200ms
+	assert.GreaterOrEqual(t, time.Since(start), 600*time.Millisecond)
+	assert.Equal(t, int32(4), requests.Load())
+}
+
+func TestTooManyRequestPauseAll(t *testing.T) {
+	throttled := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/api/orders/1" {
+			rw.Header().Add("Retry-After", "1")
+			rw.WriteHeader(http.StatusTooManyRequests)
+			select {
+			case throttled <- struct{}{}:
+			default:
+			}
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	c := New(ts.URL, 0)
+	go c.FetchOrder(context.TODO(), order.Order{Number: "1"})
+	<-throttled
+	// даем клиенту обработать ответ 429
+	time.Sleep(100 * time.Millisecond)
+	// запросы других обработчиков также приостановлены до истечения Retry-After
+	start := time.Now()
+	_, err := c.FetchOrder(context.TODO(), order.Order{Number: "2"})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 800*time.Millisecond)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want time.Duration
+	}{
+		{name: "Seconds", s: "60", want: 60 * time.Second},
+		{name: "Empty", s: "", want: DefaultRetryWaitTime},
+		{name: "Invalid", s: "soon", want: DefaultRetryWaitTime},
+		{name: "Too long", s: "3600", want: DefaultRetryMaxWaitTime},
+		{name: "Date in the past", s: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseRetryAfter(tt.s))
+		})
+	}
+}
+
+func TestLimiterPauseWhileWaiting(t *testing.T) {
+	l := NewLimiter(600)
+	// первый запрос выполняется сразу, второй - через 100 мс
+	assert.NoError(t, l.Wait(context.TODO()))
+	start := time.Now()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		// сервис приостановил запросы, пока второй запрос ожидает своей очереди
+		l.Pause(300 * time.Millisecond)
+	}()
+	assert.NoError(t, l.Wait(context.TODO()))
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		requests atomic.Int32
+		down     atomic.Bool
+	)
+	down.Store(true)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	c := New(ts.URL, 0)
+	c.breaker = NewBreaker(2, 200*time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
+		assert.ErrorIs(t, err, ErrUnexpectedResponse)
+	}
+	assert.Equal(t, accrual.CircuitOpen, c.CircuitState())
+	assert.False(t, c.Available())
+	// автомат разомкнут, запрос не отправляется
+	_, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), requests.Load())
+
+	// пробный запрос неудачен, автомат снова размыкается
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, accrual.CircuitHalfOpen, c.CircuitState())
+	_, err = c.FetchOrder(context.TODO(), order.Order{Number: "1"})
+	assert.ErrorIs(t, err, ErrUnexpectedResponse)
+	assert.Equal(t, accrual.CircuitOpen, c.CircuitState())
+
+	// сервис восстановился, пробный запрос успешен
+	down.Store(false)
+	time.Sleep(200 * time.Millisecond)
+	_, err = c.FetchOrder(context.TODO(), order.Order{Number: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, accrual.CircuitClosed, c.CircuitState())
+	assert.Equal(t, map[string]uint64{"succeeded": 1, "failed": 3, "throttled": 0, "rejected": 1}, c.FetchCounters())
+}
+
+func TestBreakerHalfOpenSingleProbe(t *testing.T) {
+	b := NewBreaker(1, 50*time.Millisecond)
+	assert.NoError(t, b.Allow())
+	b.Report(true)
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+	time.Sleep(50 * time.Millisecond)
+	// пропускается только один пробный запрос
+	assert.NoError(t, b.Allow())
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+	b.Report(false)
+	assert.NoError(t, b.Allow())
+}
+
+func TestAuthorization(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer token" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	c := New(ts.URL, 0).WithAuthorization("Bearer token")
+	_, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
+	assert.NoError(t, err)
+}
+
+func TestFetchOrderStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		want    order.OrderStatus
+		wantErr bool
+	}{
+		{name: "Registered", status: "REGISTERED", want: order.StatusRegistered},
+		{name: "Processing", status: "PROCESSING", want: order.StatusProcessing},
+		{name: "Invalid", status: "INVALID", want: order.StatusInvalid},
+		{name: "Processed", status: "PROCESSED", want: order.StatusProcessed},
+		{name: "Internal status", status: "NEW", wantErr: true},
+		{name: "Unknown", status: "DONE", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Write([]byte(`{"order":"1", "status":"` + tt.status + `"}`))
+			}))
+			defer ts.Close()
+			c := New(ts.URL, 0)
+			o, err := c.FetchOrder(context.TODO(), order.Order{Number: "1"})
+			if tt.wantErr {
+				var statusErr *UnknownStatusError
+				if assert.ErrorAs(t, err, &statusErr) {
+					assert.Equal(t, tt.status, statusErr.Status)
+				}
+				assert.ErrorIs(t, err, order.ErrUnknownStatus)
+				assert.ErrorIs(t, err, order.ErrInvalidAccrualResponse)
+				assert.Nil(t, o)
+				// сервис доступен, некорректный статус не размыкает автомат защиты
+				assert.Equal(t, uint64(1), c.FetchCounters()["succeeded"])
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, o.Status)
+		})
+	}
+}
+
+func TestRegisterOrder(t *testing.T) {
+	var (
+		requests atomic.Int32
+		code     atomic.Int32
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		body := registerOrderRequest{}
+		if req.Method != http.MethodPost || req.URL.Path != "/api/orders" || json.NewDecoder(req.Body).Decode(&body) != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if body.Order != "12345678903" || len(body.Goods) != 1 || body.Goods[0].Description != "Чайник Bork" {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		rw.WriteHeader(int(code.Load()))
+	}))
+	defer ts.Close()
+	o := order.Order{
+		Number:   "12345678903",
+		Purchase: &order.Purchase{Total: 7000, Currency: "RUB", Items: []order.Item{{Description: "Чайник Bork", Price: 7000}}},
+	}
+
+	// регистрация не включена
+	c := New(ts.URL, 0)
+	assert.NoError(t, c.RegisterOrder(context.TODO(), o))
+	assert.Equal(t, int32(0), requests.Load())
+
+	c = New(ts.URL, 0).WithOrderRegistration()
+	// заказ без сведений о покупке не регистрируется
+	assert.NoError(t, c.RegisterOrder(context.TODO(), order.Order{Number: "12345678903"}))
+	assert.Equal(t, int32(0), requests.Load())
+
+	code.Store(http.StatusAccepted)
+	assert.NoError(t, c.RegisterOrder(context.TODO(), o))
+	code.Store(http.StatusConflict)
+	assert.NoError(t, c.RegisterOrder(context.TODO(), o))
+	code.Store(http.StatusBadRequest)
+	assert.ErrorIs(t, c.RegisterOrder(context.TODO(), o), order.ErrInvalidAccrualResponse)
+	assert.Equal(t, int32(3), requests.Load())
+}
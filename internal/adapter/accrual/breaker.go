@@ -0,0 +1,101 @@
+package accrual
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/accrual"
+)
+
+const (
+	// количество неудачных запросов подряд, после которого автомат размыкается
+	DefaultBreakerThreshold = 5
+	// время, на которое автомат размыкается, прежде чем пропустить пробный запрос
+	DefaultBreakerOpenTimeout = 30 * time.Second
+)
+
+var (
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+)
+
+// Автомат защиты (circuit breaker) от обращений к недоступному сервису начислений.
+// После threshold неудачных запросов подряд автомат размыкается и отклоняет запросы в течение openTimeout.
+// Затем пропускает один пробный запрос: при успехе автомат замыкается, при неудаче - снова размыкается.
+type Breaker struct {
+	mu          sync.Mutex
+	state       accrual.CircuitState
+	failures    uint
+	threshold   uint
+	openTimeout time.Duration
+	// момент размыкания автомата или начала пробного запроса
+	changedAt time.Time
+}
+
+// Создает автомат защиты, 0 - значения по умолчанию
+func NewBreaker(threshold uint, openTimeout time.Duration) *Breaker {
+	if threshold == 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if openTimeout == 0 {
+		openTimeout = DefaultBreakerOpenTimeout
+	}
+	return &Breaker{
+		state:       accrual.CircuitClosed,
+		threshold:   threshold,
+		openTimeout: openTimeout,
+	}
+}
+
+// Возвращает ErrCircuitOpen, если запрос выполнять нельзя
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case accrual.CircuitOpen:
+		if time.Since(b.changedAt) < b.openTimeout {
+			return ErrCircuitOpen
+		}
+	case accrual.CircuitHalfOpen:
+		// пробный запрос уже выполняется. Если результат пробного запроса так и не был получен
+		// (например, запрос отменен), то через openTimeout пропускаем новый пробный запрос.
+		if time.Since(b.changedAt) < b.openTimeout {
+			return ErrCircuitOpen
+		}
+	default:
+		return nil
+	}
+	b.state = accrual.CircuitHalfOpen
+	b.changedAt = time.Now()
+	return nil
+}
+
+// Учитывает результат запроса
+func (b *Breaker) Report(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.state = accrual.CircuitClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == accrual.CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = accrual.CircuitOpen
+		b.changedAt = time.Now()
+	}
+}
+
+// Возвращает состояние автомата
+func (b *Breaker) State() accrual.CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == accrual.CircuitOpen && time.Since(b.changedAt) >= b.openTimeout {
+		// следующий запрос будет пробным
+		return accrual.CircuitHalfOpen
+	}
+	return b.state
+}
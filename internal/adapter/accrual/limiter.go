@@ -0,0 +1,134 @@
+package accrual
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Ограничитель частоты запросов к сервису начислений по алгоритму token bucket.
+// Один ограничитель используется всеми обработчиками, которые обращаются к сервису через адаптер.
+// Частота запросов может быть уточнена по ответам сервиса (Adapt), а запросы - приостановлены
+// до указанного сервисом времени (Pause).
+type Limiter struct {
+	mu sync.Mutex
+	// количество запросов в секунду, 0 - без ограничений
+	rate float64
+	// максимальное количество запросов в секунду, заданное при создании, 0 - без ограничений
+	maxRate float64
+	// количество доступных запросов, отрицательное значение - количество запросов в очереди
+	tokens float64
+	last   time.Time
+	// момент, до которого запросы приостановлены
+	pausedUntil time.Time
+}
+
+// Создает ограничитель на requestsPerMinute запросов в минуту, 0 - без ограничений
+func NewLimiter(requestsPerMinute uint) *Limiter {
+	rate := float64(requestsPerMinute) / 60
+	return &Limiter{
+		rate:    rate,
+		maxRate: rate,
+		tokens:  1,
+		last:    time.Now(),
+	}
+}
+
+// Ожидает возможности выполнить запрос или отмены контекста. Если во время ожидания запросы были
+// приостановлены (Pause), ожидание продолжается до окончания паузы.
+func (l *Limiter) Wait(ctx context.Context) error {
+	delay := l.reserve()
+	for delay > 0 {
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+		delay = l.resume()
+	}
+	return nil
+}
+
+// Устанавливает частоту запросов, разрешенную сервисом, но не выше заданной при создании
+func (l *Limiter) Adapt(requestsPerMinute uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rate := float64(requestsPerMinute) / 60
+	if rate == 0 || (l.maxRate != 0 && rate > l.maxRate) {
+		rate = l.maxRate
+	}
+	if rate == l.rate {
+		return
+	}
+	if l.rate == 0 {
+		// без ограничений запас запросов не учитывался, начинаем отсчет заново
+		l.tokens = 0
+		l.last = time.Now()
+	}
+	l.rate = rate
+}
+
+// Приостанавливает все запросы на время d
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// Резервирует запрос и возвращает время, через которое его можно выполнить
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.reserveLocked(time.Now())
+}
+
+// Проверяет зарезервированный запрос после ожидания. Если запросы приостановлены, то резерв отменяется,
+// запрос резервируется заново после окончания паузы и возвращается время, через которое его можно выполнить.
+// Возвращает 0, если запрос можно выполнить.
+func (l *Limiter) resume() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if !l.pausedUntil.After(now) {
+		return 0
+	}
+	if l.rate != 0 {
+		l.tokens++
+	}
+	return l.reserveLocked(now)
+}
+
+// Резервирует запрос в момент now, вызывается под блокировкой l.mu
+func (l *Limiter) reserveLocked(now time.Time) time.Duration {
+	// во время паузы запросы не выполняются, поэтому отсчитываем от ее окончания
+	at := now
+	if l.pausedUntil.After(at) {
+		at = l.pausedUntil
+	}
+	if l.rate == 0 {
+		return at.Sub(now)
+	}
+	if at.After(l.last) {
+		// накопить можно не более одного запроса, чтобы запросы равномерно распределялись во времени
+		l.tokens += at.Sub(l.last).Seconds() * l.rate
+		if l.tokens > 1 {
+			l.tokens = 1
+		}
+		l.last = at
+	}
+	l.tokens--
+	delay := at.Sub(now)
+	if l.tokens < 0 {
+		delay += time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	return delay
+}
@@ -0,0 +1,42 @@
+package accrual
+
+import (
+	"fmt"
+
+	"github.com/k1nky/gophermart/internal/entity/order"
+)
+
+// Статусы расчёта начисления по спецификации системы расчёта начислений
+var accrualStatuses = map[string]order.OrderStatus{
+	// заказ зарегистрирован, но вознаграждение не рассчитано
+	"REGISTERED": order.StatusRegistered,
+	// заказ не принят к расчёту, и вознаграждение не будет начислено
+	"INVALID": order.StatusInvalid,
+	// расчёт начисления в процессе
+	"PROCESSING": order.StatusProcessing,
+	// расчёт начисления окончен
+	"PROCESSED": order.StatusProcessed,
+}
+
+// UnknownStatusError - система расчёта начислений вернула статус, не предусмотренный спецификацией.
+// Является одновременно order.ErrUnknownStatus и order.ErrInvalidAccrualResponse.
+type UnknownStatusError struct {
+	Status string
+}
+
+func (e *UnknownStatusError) Error() string {
+	return fmt.Sprintf("unknown accrual status %q", e.Status)
+}
+
+func (e *UnknownStatusError) Unwrap() []error {
+	return []error{order.ErrUnknownStatus, order.ErrInvalidAccrualResponse}
+}
+
+// Возвращает статус заказа, соответствующий статусу расчёта начисления status
+func parseStatus(status string) (order.OrderStatus, error) {
+	s, ok := accrualStatuses[status]
+	if !ok {
+		return "", &UnknownStatusError{Status: status}
+	}
+	return s, nil
+}
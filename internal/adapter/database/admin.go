@@ -0,0 +1,286 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/k1nky/gophermart/internal/entity/admin"
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+	"github.com/k1nky/gophermart/internal/entity/transaction"
+	"github.com/k1nky/gophermart/internal/entity/user"
+	"github.com/k1nky/gophermart/internal/entity/withdraw"
+)
+
+// Возвращает заказ программы лояльности tenantID с номером number в очередь на проверку начислений: статус заказа сбрасывается в NEW,
+// уже проведенное начисление по заказу списывается корректировкой, расписание проверки заказа и
+// счетчик некорректных ответов сбрасываются.
+// Действие записывается в журнал действий администраторов от имени r.Admin с причиной r.Reason.
+func (a *Adapter) RequeueOrder(ctx context.Context, tenantID tenant.ID, number order.OrderNumber, r admin.AuditRecord) (*order.Order, error) {
+	tx, err := a.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	defer tx.Rollback()
+
+	prev, err := a.lockOrder(ctx, tx, "number = $1 AND tenant_id = $2", number, tenantID)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	if prev == nil {
+		return nil, fmt.Errorf("%s %w", number, order.ErrNotFound)
+	}
+	o := *prev
+	o.Status = order.StatusNew
+	o.Accrual = nil
+	if err := a.changeAdminOrder(ctx, tx, *prev, o, r); err != nil {
+		return nil, err
+	}
+	if err := a.resetOrderSchedule(ctx, tx, o.ID); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	r.Action = admin.ActionRequeue
+	if err := a.commitAdminOrder(ctx, tx, *prev, o, r); err != nil {
+		return nil, err
+	}
+	o.Attempts = 0
+	o.Failures = 0
+	o.LastError = ""
+	o.DeadLetteredAt = nil
+	return &o, nil
+}
+
+// Возобновляет проверку заказов программы лояльности tenantID с номерами numbers, проверка которых
+// была прекращена, пустой numbers - всех таких заказов программы. Статус и начисление заказов не изменяются.
+// Для каждого заказа действие записывается в журнал действий администраторов. Возвращает возобновленные заказы.
+func (a *Adapter) RetryDeadLetterOrders(ctx context.Context, tenantID tenant.ID, numbers []order.OrderNumber, r admin.AuditRecord) ([]*order.Order, error) {
+	tx, err := a.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	defer tx.Rollback()
+
+	args := make([]string, 0, len(numbers))
+	for _, v := range numbers {
+		args = append(args, string(v))
+	}
+	query := fmt.Sprintf(`
+		SELECT %s FROM orders
+		WHERE dead_lettered_at IS NOT NULL AND tenant_id = $2
+			AND (cardinality($1::text[]) = 0 OR number = any($1::text[]))
+		ORDER BY dead_lettered_at
+		FOR UPDATE
+	`, orderColumns)
+	rows, err := tx.QueryContext(ctx, query, args, tenantID)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	orders := make([]*order.Order, 0)
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			rows.Close()
+			return nil, NewExecutingQueryError(err)
+		}
+		orders = append(orders, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+
+	r.Action = admin.ActionRetry
+	for _, o := range orders {
+		if err := a.resetOrderSchedule(ctx, tx, o.ID); err != nil {
+			return nil, NewExecutingQueryError(err)
+		}
+		details, err := json.Marshal(map[string]interface{}{
+			"failures":   o.Failures,
+			"last_error": o.LastError,
+		})
+		if err != nil {
+			return nil, err
+		}
+		r.OrderID = o.ID
+		r.UserID = o.UserID
+		r.Details = details
+		if err := a.newAuditRecord(ctx, tx, r); err != nil {
+			return nil, NewExecutingQueryError(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	return orders, nil
+}
+
+// Сбрасывает расписание проверки заказа и счетчик некорректных ответов, возобновляет проверку заказа
+// и уведомляет подписчиков канала NewOrdersChannel о заказе
+func (a *Adapter) resetOrderSchedule(ctx context.Context, tx *sql.Tx, id order.ID) error {
+	const query = `
+		UPDATE orders
+		SET attempts = 0, next_check_at = NOW(), failures = 0, last_error = NULL, dead_lettered_at = NULL
+		WHERE order_id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", NewOrdersChannel, strconv.FormatUint(uint64(id), 10))
+	return err
+}
+
+// Принудительно устанавливает заказу программы лояльности tenantID с номером number статус status и начисление accrual.
+// Изменение уже проведенного начисления по заказу проводится корректировкой.
+// Действие записывается в журнал действий администраторов от имени r.Admin с причиной r.Reason.
+func (a *Adapter) ForceOrderStatus(ctx context.Context, tenantID tenant.ID, number order.OrderNumber, status order.OrderStatus, accrual *float32, r admin.AuditRecord) (*order.Order, error) {
+	tx, err := a.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	defer tx.Rollback()
+
+	prev, err := a.lockOrder(ctx, tx, "number = $1 AND tenant_id = $2", number, tenantID)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	if prev == nil {
+		return nil, fmt.Errorf("%s %w", number, order.ErrNotFound)
+	}
+	o := *prev
+	o.Status = status
+	o.Accrual = accrual
+	if err := a.changeAdminOrder(ctx, tx, *prev, o, r); err != nil {
+		return nil, err
+	}
+	r.Action = admin.ActionForceStatus
+	if err := a.commitAdminOrder(ctx, tx, *prev, o, r); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Изменяет заказ prev на o от имени администратора и снимает аренду заказа, поэтому результат проверки,
+// начатой до изменения, не будет записан. Изменение, в результате которого баланс пользователя
+// становится отрицательным, не допускается.
+func (a *Adapter) changeAdminOrder(ctx context.Context, tx *sql.Tx, prev order.Order, o order.Order, r admin.AuditRecord) error {
+	balance, err := a.changeOrder(ctx, tx, prev, o, order.SourceAdmin, r.Reason, r.Admin)
+	if err != nil {
+		return NewExecutingQueryError(err)
+	}
+	if err := a.unlockOrder(ctx, tx, o.ID); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	if balance != nil && *balance < 0 {
+		return withdraw.ErrInsufficientBalance
+	}
+	return nil
+}
+
+// Записывает изменение заказа prev на o в журнал действий администраторов и фиксирует транзакцию tx
+func (a *Adapter) commitAdminOrder(ctx context.Context, tx *sql.Tx, prev order.Order, o order.Order, r admin.AuditRecord) error {
+	details, err := json.Marshal(map[string]interface{}{
+		"previous_status":  prev.Status,
+		"previous_accrual": prev.Accrual,
+		"status":           o.Status,
+		"accrual":          o.Accrual,
+	})
+	if err != nil {
+		return err
+	}
+	r.OrderID = o.ID
+	r.UserID = o.UserID
+	r.Details = details
+	if err := a.newAuditRecord(ctx, tx, r); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	return nil
+}
+
+// Проводит корректировку баланса пользователя adj.UserID на adj.Amount и возвращает ее вместе с балансом
+// пользователя после корректировки. Корректировка, в результате которой баланс пользователя
+// становится отрицательным, не допускается. Действие записывается в журнал действий администраторов.
+func (a *Adapter) NewAdjustment(ctx context.Context, adj admin.Adjustment) (*admin.Adjustment, error) {
+	tx, err := a.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	defer tx.Rollback()
+
+	created, err := a.newAdjustment(ctx, tx, adj.UserID, 0, adj.Amount, adj.Reason, adj.Admin)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	if created.Balance < 0 {
+		return nil, withdraw.ErrInsufficientBalance
+	}
+	details, err := json.Marshal(map[string]interface{}{
+		"adjustment_id": created.ID,
+		"amount":        created.Amount,
+		"balance":       created.Balance,
+	})
+	if err != nil {
+		return nil, err
+	}
+	r := admin.AuditRecord{
+		Admin:   adj.Admin,
+		Action:  admin.ActionAdjustment,
+		UserID:  adj.UserID,
+		Reason:  adj.Reason,
+		Details: details,
+	}
+	if err := a.newAuditRecord(ctx, tx, r); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	return created, nil
+}
+
+// Добавляет корректировку баланса пользователя и соответствующую ей транзакцию в рамках транзакции tx.
+// orderID - заказ, начисление по которому изменилось, 0 - корректировка не связана с заказом.
+// Пустой by - корректировка выполнена системой.
+func (a *Adapter) newAdjustment(ctx context.Context, tx *sql.Tx, userID user.ID, orderID order.ID, amount float32, reason string, by string) (*admin.Adjustment, error) {
+	const query = `
+		INSERT INTO adjustments (user_id, order_id, amount, reason, admin)
+		VALUES ($1, NULLIF($2, 0), $3, $4, NULLIF($5, ''))
+		RETURNING adjustment_id, created_at
+	`
+	adj := &admin.Adjustment{
+		UserID:  userID,
+		OrderID: orderID,
+		Amount:  amount,
+		Reason:  reason,
+		Admin:   by,
+	}
+	row := tx.QueryRowContext(ctx, query, userID, orderID, amount, reason, by)
+	if err := row.Scan(&adj.ID, &adj.CreatedAt); err != nil {
+		return nil, err
+	}
+	balance, err := a.newTransaction(ctx, tx, userID, uint64(adj.ID), transaction.SourceAdjustment, amount)
+	if err != nil {
+		return nil, err
+	}
+	adj.Balance = balance
+	return adj, nil
+}
+
+// Добавляет запись в журнал действий администраторов в рамках транзакции tx
+func (a *Adapter) newAuditRecord(ctx context.Context, tx *sql.Tx, r admin.AuditRecord) error {
+	const query = `
+		INSERT INTO admin_audit (admin, action, order_id, user_id, reason, details)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, 0), $5, $6::jsonb)
+	`
+	var details interface{}
+	if len(r.Details) != 0 {
+		details = string(r.Details)
+	}
+	_, err := tx.ExecContext(ctx, query, r.Admin, r.Action, r.OrderID, r.UserID, r.Reason, details)
+	return err
+}
@@ -0,0 +1,219 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/admin"
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/reward"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+	"github.com/k1nky/gophermart/internal/entity/user"
+	"github.com/k1nky/gophermart/internal/entity/withdraw"
+	"github.com/stretchr/testify/suite"
+)
+
+type adminTestSuite struct {
+	suite.Suite
+	a *Adapter
+}
+
+func (suite *adminTestSuite) SetupTest() {
+	if shouldSkipDBTest(suite.T()) {
+		return
+	}
+	var err error
+	if suite.a, err = openTestDB(); err != nil {
+		suite.FailNow(err.Error())
+		return
+	}
+	if _, err := suite.a.Exec(`
+		DELETE FROM admin_audit;
+		DELETE FROM reward_rules;
+		DELETE FROM adjustments;
+		DELETE FROM transactions CASCADE;
+		DELETE FROM balances;
+		DELETE FROM orders CASCADE;
+		DELETE FROM users CASCADE;
+
+		INSERT INTO users(user_id, login, password) VALUES (1, 'u1', 'p1');
+		INSERT INTO orders(order_id, user_id, number, status) VALUES (1, 1, '100', 'NEW');
+	`); err != nil {
+		suite.FailNow(err.Error())
+	}
+}
+
+// Проверяет, что изменение по каждой транзакции пользователя совпадает с изменением по ее источнику
+func (suite *adminTestSuite) assertLedger(want float32) {
+	transactions, err := suite.a.GetTransactionsByUserID(context.TODO(), 1)
+	suite.NoError(err)
+	var balance float32
+	for _, t := range transactions {
+		if suite.NotNil(t.Amount) {
+			balance += *t.Amount
+		}
+		suite.Equal(balance, t.Balance)
+	}
+	suite.Equal(want, balance)
+	b, err := suite.a.GetBalanceByUser(context.TODO(), 1)
+	suite.NoError(err)
+	suite.Equal(want, b.Current)
+}
+
+func (suite *adminTestSuite) TestForceOrderStatus() {
+	r := admin.AuditRecord{Admin: "support", Reason: "confirmed by partner"}
+	v := float32(100)
+	suite.NoError(suite.a.UpdateOrder(context.TODO(), "", order.Order{ID: 1, Number: "100", Status: order.StatusProcessed, Accrual: &v}, order.SourcePoller))
+	suite.assertLedger(100)
+
+	v2 := float32(150)
+	o, err := suite.a.ForceOrderStatus(context.TODO(), tenant.Default, "100", order.StatusProcessed, &v2, r)
+	suite.NoError(err)
+	suite.Equal(&v2, o.Accrual)
+	suite.assertLedger(150)
+
+	_, err = suite.a.ForceOrderStatus(context.TODO(), tenant.Default, "100", order.StatusInvalid, nil, r)
+	suite.NoError(err)
+	suite.assertLedger(0)
+
+	_, err = suite.a.ForceOrderStatus(context.TODO(), tenant.Default, "200", order.StatusInvalid, nil, r)
+	suite.ErrorIs(err, order.ErrNotFound)
+	// заказ другой программы лояльности не изменяется
+	_, err = suite.a.ForceOrderStatus(context.TODO(), "bork", "100", order.StatusInvalid, nil, r)
+	suite.ErrorIs(err, order.ErrNotFound)
+
+	var audit int
+	suite.NoError(suite.a.QueryRow(`SELECT COUNT(*) FROM admin_audit WHERE action = 'FORCE_STATUS' AND order_id = 1`).Scan(&audit))
+	suite.Equal(2, audit)
+}
+
+func (suite *adminTestSuite) TestRequeueOrder() {
+	r := admin.AuditRecord{Admin: "support", Reason: "accrual system fixed"}
+	v := float32(100)
+	suite.NoError(suite.a.UpdateOrder(context.TODO(), "", order.Order{ID: 1, Number: "100", Status: order.StatusProcessed, Accrual: &v}, order.SourcePoller))
+
+	o, err := suite.a.RequeueOrder(context.TODO(), tenant.Default, "100", r)
+	suite.NoError(err)
+	suite.Equal(order.StatusNew, o.Status)
+	suite.assertLedger(0)
+
+	// повторная проверка снова начисляет баллы по заказу
+	v2 := float32(80)
+	suite.NoError(suite.a.UpdateOrder(context.TODO(), "", order.Order{ID: 1, Number: "100", Status: order.StatusProcessed, Accrual: &v2}, order.SourcePoller))
+	suite.assertLedger(80)
+
+	events, err := suite.a.GetOrderEvents(context.TODO(), 1)
+	suite.NoError(err)
+	suite.Len(events, 3)
+	suite.Equal(order.SourceAdmin, events[1].Source)
+
+	// корректировка при повторной проверке выполнена системой, а не администратором
+	var system int
+	suite.NoError(suite.a.QueryRow(`SELECT COUNT(*) FROM adjustments WHERE order_id = 1 AND admin IS NULL`).Scan(&system))
+	suite.Equal(1, system)
+}
+
+func (suite *adminTestSuite) TestNewAdjustment() {
+	adj, err := suite.a.NewAdjustment(context.TODO(), admin.Adjustment{UserID: user.ID(1), Amount: 50, Reason: "compensation", Admin: "support"})
+	suite.NoError(err)
+	suite.Equal(float32(50), adj.Balance)
+	suite.assertLedger(50)
+
+	_, err = suite.a.NewAdjustment(context.TODO(), admin.Adjustment{UserID: user.ID(1), Amount: -60, Reason: "compensation", Admin: "support"})
+	suite.ErrorIs(err, withdraw.ErrInsufficientBalance)
+	suite.assertLedger(50)
+}
+
+func (suite *adminTestSuite) TestDeadLetterOrders() {
+	statuses := []order.OrderStatus{order.StatusNew, order.StatusProcessing}
+	for i := 0; i < 2; i++ {
+		_, err := suite.a.ClaimOrder(context.TODO(), "i1", 1, statuses, tenant.Filter{}, time.Minute)
+		suite.NoError(err)
+		deadLettered, err := suite.a.FailOrder(context.TODO(), "i1", 1, "invalid accrual system response", 0, 2)
+		suite.NoError(err)
+		suite.Equal(i == 1, deadLettered)
+	}
+	orders, err := suite.a.GetDeadLetterOrders(context.TODO(), tenant.Default, 10)
+	suite.NoError(err)
+	if suite.Len(orders, 1) {
+		suite.Equal(uint(2), orders[0].Failures)
+		suite.Equal("invalid accrual system response", orders[0].LastError)
+		suite.NotNil(orders[0].DeadLetteredAt)
+	}
+	// проверка заказа прекращена
+	claimed, err := suite.a.ClaimOrders(context.TODO(), "i1", statuses, tenant.Filter{}, time.Minute, 10)
+	suite.NoError(err)
+	suite.Empty(claimed)
+
+	orders, err = suite.a.GetDeadLetterOrders(context.TODO(), "bork", 10)
+	suite.NoError(err)
+	suite.Empty(orders)
+
+	r := admin.AuditRecord{Admin: "support", Reason: "accrual system fixed"}
+	orders, err = suite.a.RetryDeadLetterOrders(context.TODO(), tenant.Default, []order.OrderNumber{"200"}, r)
+	suite.NoError(err)
+	suite.Empty(orders)
+	orders, err = suite.a.RetryDeadLetterOrders(context.TODO(), "bork", nil, r)
+	suite.NoError(err)
+	suite.Empty(orders)
+	orders, err = suite.a.RetryDeadLetterOrders(context.TODO(), tenant.Default, nil, r)
+	suite.NoError(err)
+	suite.Len(orders, 1)
+
+	orders, err = suite.a.GetDeadLetterOrders(context.TODO(), tenant.Default, 10)
+	suite.NoError(err)
+	suite.Empty(orders)
+	claimed, err = suite.a.ClaimOrders(context.TODO(), "i1", statuses, tenant.Filter{}, time.Minute, 10)
+	suite.NoError(err)
+	if suite.Len(claimed, 1) {
+		suite.Equal(uint(0), claimed[0].Failures)
+		suite.Empty(claimed[0].LastError)
+	}
+
+	var audit int
+	suite.NoError(suite.a.QueryRow(`SELECT COUNT(*) FROM admin_audit WHERE action = 'RETRY' AND order_id = 1`).Scan(&audit))
+	suite.Equal(1, audit)
+}
+
+func (suite *adminTestSuite) TestRewardRules() {
+	r := admin.AuditRecord{Admin: "support", Reason: "new partner"}
+	until := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	created, err := suite.a.NewRewardRule(context.TODO(), tenant.Default, reward.Rule{
+		Name:       "Чайники Bork",
+		Match:      "чайник",
+		Reward:     10,
+		RewardType: reward.TypePercent,
+		ValidUntil: &until,
+	}, r)
+	suite.NoError(err)
+	suite.NotZero(created.ID)
+	suite.Empty(created.Merchant)
+	suite.Zero(created.MaxReward)
+
+	created.Merchant = "Bork"
+	created.MaxReward = 500
+	updated, err := suite.a.UpdateRewardRule(context.TODO(), tenant.Default, *created, r)
+	suite.NoError(err)
+	suite.Equal("Bork", updated.Merchant)
+	suite.Equal(float32(500), updated.MaxReward)
+
+	rules, err := suite.a.GetRewardRules(context.TODO(), tenant.Default)
+	suite.NoError(err)
+	suite.Len(rules, 1)
+	// правила других программ лояльности недоступны
+	rules, err = suite.a.GetRewardRules(context.TODO(), "bork")
+	suite.NoError(err)
+	suite.Empty(rules)
+	_, err = suite.a.UpdateRewardRule(context.TODO(), "bork", *created, r)
+	suite.ErrorIs(err, reward.ErrNotFound)
+	suite.ErrorIs(suite.a.DeleteRewardRule(context.TODO(), "bork", created.ID, r), reward.ErrNotFound)
+
+	_, err = suite.a.UpdateRewardRule(context.TODO(), tenant.Default, reward.Rule{ID: created.ID + 1, Name: "r", Reward: 1, RewardType: reward.TypePoints}, r)
+	suite.ErrorIs(err, reward.ErrNotFound)
+	suite.NoError(suite.a.DeleteRewardRule(context.TODO(), tenant.Default, created.ID, r))
+	suite.ErrorIs(suite.a.DeleteRewardRule(context.TODO(), tenant.Default, created.ID, r), reward.ErrNotFound)
+
+	var audit int
+	suite.NoError(suite.a.QueryRow(`SELECT COUNT(*) FROM admin_audit WHERE action LIKE '%_REWARD_RULE'`).Scan(&audit))
+	suite.Equal(3, audit)
+}
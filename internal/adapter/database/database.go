@@ -17,6 +17,11 @@ import (
 
 const (
 	DefaultMaxKeepaliveConnections = 10
+	// подключения, которые удерживаются все время работы экземпляра: подключение с блокировкой лидера
+	// и подключение, ожидающее уведомлений о новых заказах
+	DedicatedConnections = 2
+	// канал уведомлений о новых заказах, полезная нагрузка - идентификатор заказа
+	NewOrdersChannel = "new_orders"
 )
 
 //go:embed migrations/*.sql
@@ -30,22 +35,32 @@ func New() *Adapter {
 	return &Adapter{}
 }
 
-// Открывает новое подключение к базе
+// Открывает новое подключение к базе и применяет миграции. Подключение закрывается вызовом Close
+// после остановки всех его пользователей.
 func (a *Adapter) Open(ctx context.Context, dsn string) (err error) {
 	if a.DB, err = sql.Open("pgx", dsn); err != nil {
 		return
 	}
 	a.DB.SetMaxIdleConns(DefaultMaxKeepaliveConnections)
 	a.DB.SetMaxOpenConns(DefaultMaxKeepaliveConnections)
-	err = a.Initialize(dsn)
-	if err == nil {
-		// закрывает соединение при отмене контекста
-		go func() {
-			<-ctx.Done()
-			a.Close()
-		}()
+	if err = a.PingContext(ctx); err != nil {
+		a.Close()
+		return
 	}
-	return err
+	if err = a.Initialize(dsn); err != nil {
+		a.Close()
+	}
+	return
+}
+
+// Устанавливает размер пула подключений так, чтобы workers обработчиков заказов не ожидали освобождения
+// подключений, занятых выделенными подключениями, см. DedicatedConnections.
+func (a *Adapter) SetPoolSize(workers uint) {
+	n := int(workers) + DedicatedConnections
+	if n < DefaultMaxKeepaliveConnections {
+		n = DefaultMaxKeepaliveConnections
+	}
+	a.DB.SetMaxOpenConns(n)
 }
 
 // Применяет миграции
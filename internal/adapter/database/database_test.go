@@ -29,4 +29,8 @@ func shouldSkipDBTest(t *testing.T) bool {
 func TestAdapter(t *testing.T) {
 	suite.Run(t, new(usersTestSuite))
 	suite.Run(t, new(ordersTestSuite))
+	suite.Run(t, new(promoTestSuite))
+	suite.Run(t, new(withdrawalsTestSuite))
+	suite.Run(t, new(leaderTestSuite))
+	suite.Run(t, new(adminTestSuite))
 }
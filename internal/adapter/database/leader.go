@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// интервал продления лидерства и попыток его получения
+	DefaultLeaderRenewInterval = 5 * time.Second
+	// время на освобождение блокировки при потере лидерства
+	leaderReleaseTimeout = 5 * time.Second
+)
+
+// Фоновая задача, которая выполняется только на экземпляре-лидере.
+// Контекст задачи отменяется при потере лидерства. Задача должна вернуть управление только после завершения
+// всей начатой ей работы: блокировка освобождается сразу после этого, и лидером может стать другой экземпляр.
+type Job func(ctx context.Context)
+
+type job struct {
+	name string
+	run  Job
+}
+
+// LeaderElector выбирает лидера среди экземпляров сервиса с помощью сессионной
+// рекомендательной блокировки (pg_try_advisory_lock). Блокировка удерживается выделенным
+// подключением к базе, пока оно живо. Если лидер завершился или потерял подключение,
+// блокировку получает другой экземпляр при очередной попытке.
+type LeaderElector struct {
+	a             *Adapter
+	name          string
+	key           int64
+	renewInterval time.Duration
+	log           logger
+	jobs          []job
+	jobsMu        sync.Mutex
+	isLeader      atomic.Bool
+	// подключение, которое удерживает блокировку, пока экземпляр является лидером
+	conn *sql.Conn
+	// отменяет контекст задач лидера
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Создает выборщика лидера с блокировкой name. Экземпляры с одинаковым name конкурируют за лидерство.
+func NewLeaderElector(a *Adapter, name string, renewInterval time.Duration, l logger) *LeaderElector {
+	if renewInterval == 0 {
+		renewInterval = DefaultLeaderRenewInterval
+	}
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return &LeaderElector{
+		a:             a,
+		name:          name,
+		key:           int64(h.Sum64()),
+		renewInterval: renewInterval,
+		log:           l,
+	}
+}
+
+// Регистрирует фоновую задачу. Задача запускается каждый раз, когда экземпляр становится лидером.
+// Задачи должны быть зарегистрированы до вызова Run.
+func (le *LeaderElector) Register(name string, run Job) {
+	le.jobsMu.Lock()
+	defer le.jobsMu.Unlock()
+	le.jobs = append(le.jobs, job{name: name, run: run})
+}
+
+// Возвращает истину, если экземпляр является лидером
+func (le *LeaderElector) IsLeader() bool {
+	return le.isLeader.Load()
+}
+
+// Участвует в выборах лидера до отмены контекста. При отмене контекста останавливает задачи
+// и освобождает блокировку.
+func (le *LeaderElector) Run(ctx context.Context) {
+	t := time.NewTicker(le.renewInterval)
+	defer t.Stop()
+	for {
+		if le.conn == nil {
+			le.acquire(ctx)
+		} else if err := le.conn.PingContext(ctx); err != nil {
+			le.log.Errorf("leader: %s: lost leadership: %v", le.name, err)
+			le.release()
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			le.release()
+			return
+		}
+	}
+}
+
+// Пытается получить блокировку и, в случае успеха, запускает задачи
+func (le *LeaderElector) acquire(ctx context.Context) {
+	conn, err := le.a.Conn(ctx)
+	if err != nil {
+		le.log.Errorf("leader: %s: %v", le.name, NewExecutingQueryError(err))
+		return
+	}
+	locked := false
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", le.key).Scan(&locked); err != nil {
+		le.log.Errorf("leader: %s: %v", le.name, NewExecutingQueryError(err))
+		conn.Close()
+		return
+	}
+	if !locked {
+		// блокировку удерживает другой экземпляр
+		conn.Close()
+		return
+	}
+	le.log.Infof("leader: %s: acquired leadership", le.name)
+	le.conn = conn
+	le.isLeader.Store(true)
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	le.cancel = cancel
+	le.jobsMu.Lock()
+	defer le.jobsMu.Unlock()
+	for _, j := range le.jobs {
+		j := j
+		le.wg.Add(1)
+		go func() {
+			defer le.wg.Done()
+			le.log.Debugf("leader: %s: start job %s", le.name, j.name)
+			j.run(jobCtx)
+		}()
+	}
+}
+
+// Останавливает задачи, дожидается их завершения и освобождает блокировку
+func (le *LeaderElector) release() {
+	if le.conn == nil {
+		return
+	}
+	le.isLeader.Store(false)
+	le.cancel()
+	le.wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), leaderReleaseTimeout)
+	defer cancel()
+	if _, err := le.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", le.key); err != nil {
+		// блокировка сессионная, поэтому подключение не должно вернуться в пул вместе с блокировкой:
+		// ErrBadConn заставляет database/sql закрыть подключение вместо возврата в пул
+		le.conn.Raw(func(driverConn interface{}) error {
+			return driver.ErrBadConn
+		})
+	}
+	le.conn.Close()
+	le.conn = nil
+	le.log.Infof("leader: %s: released leadership", le.name)
+}
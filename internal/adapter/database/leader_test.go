@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	log "github.com/k1nky/gophermart/internal/logger"
+	"github.com/stretchr/testify/suite"
+)
+
+type leaderTestSuite struct {
+	suite.Suite
+	a *Adapter
+}
+
+func (suite *leaderTestSuite) SetupTest() {
+	if shouldSkipDBTest(suite.T()) {
+		return
+	}
+	var err error
+	if suite.a, err = openTestDB(); err != nil {
+		suite.FailNow(err.Error())
+		return
+	}
+}
+
+func (suite *leaderTestSuite) TestFailover() {
+	const interval = 50 * time.Millisecond
+	var running [2]atomic.Int32
+	electors := make([]*LeaderElector, 2)
+	cancels := make([]context.CancelFunc, 2)
+	for i := range electors {
+		i := i
+		electors[i] = NewLeaderElector(suite.a, "test", interval, &log.Blackhole{})
+		electors[i].Register("job", func(ctx context.Context) {
+			running[i].Add(1)
+			<-ctx.Done()
+			running[i].Add(-1)
+		})
+		var ctx context.Context
+		ctx, cancels[i] = context.WithCancel(context.Background())
+		defer cancels[i]()
+		go electors[i].Run(ctx)
+	}
+	suite.Eventually(func() bool {
+		return electors[0].IsLeader() != electors[1].IsLeader()
+	}, time.Second, interval)
+	leader, follower := 0, 1
+	if electors[1].IsLeader() {
+		leader, follower = 1, 0
+	}
+	suite.Equal(int32(1), running[leader].Load())
+	suite.Equal(int32(0), running[follower].Load())
+
+	// лидер завершается, лидерство переходит ко второму экземпляру
+	cancels[leader]()
+	suite.Eventually(func() bool {
+		return electors[follower].IsLeader() && running[follower].Load() == 1
+	}, time.Second, interval)
+	suite.False(electors[leader].IsLeader())
+	suite.Equal(int32(0), running[leader].Load())
+}
@@ -0,0 +1,3 @@
+-- удаление значения 'PROMO' из перечисления transaction_type не поддерживается
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_codes;
@@ -0,0 +1,38 @@
+-- промокоды
+CREATE TABLE IF NOT EXISTS promo_codes (
+   promo_id SERIAL PRIMARY KEY,
+   code VARCHAR(100) UNIQUE NOT NULL,
+   -- количество баллов, начисляемых при погашении
+   amount REAL NOT NULL CHECK (amount > 0),
+   -- максимальное количество погашений, 1 - одноразовый промокод
+   max_redemptions INT NOT NULL DEFAULT 1 CHECK (max_redemptions > 0),
+   -- количество выполненных погашений
+   redemptions INT NOT NULL DEFAULT 0,
+   -- период действия промокода, valid_until NULL - бессрочный промокод
+   valid_from TIMESTAMP NOT NULL DEFAULT NOW(),
+   valid_until TIMESTAMP NULL,
+   -- не допускаем превышения лимита погашений
+   CHECK (redemptions <= max_redemptions)
+);
+
+-- погашения промокодов
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+   redemption_id SERIAL PRIMARY KEY,
+   promo_id INT NOT NULL,
+   user_id INT NOT NULL,
+   amount REAL NOT NULL,
+   redeemed_at TIMESTAMP DEFAULT NOW(),
+   CONSTRAINT fk_promo
+      FOREIGN KEY (promo_id)
+      REFERENCES promo_codes(promo_id)
+      ON DELETE CASCADE,
+   CONSTRAINT fk_user
+      FOREIGN KEY (user_id)
+      REFERENCES users(user_id)
+      ON DELETE CASCADE,
+   -- пользователь может погасить промокод только один раз
+   UNIQUE(promo_id, user_id)
+);
+
+-- погашение промокода является источником транзакции
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'PROMO';
@@ -0,0 +1,24 @@
+-- текущие балансы пользователей
+-- Обновляются в той же транзакции, что и журнал транзакций пользователя.
+CREATE TABLE IF NOT EXISTS balances (
+   user_id INT PRIMARY KEY,
+   -- баланс в результате проведения последней транзакции
+   current REAL NOT NULL DEFAULT 0,
+   -- сумма всех списаний
+   withdrawn REAL NOT NULL DEFAULT 0,
+   -- последовательный номер последней транзакции пользователя
+   last_seq INT NOT NULL DEFAULT 0,
+   CONSTRAINT fk_user
+      FOREIGN KEY (user_id)
+      REFERENCES users(user_id)
+      ON DELETE CASCADE
+);
+
+INSERT INTO balances (user_id, current, withdrawn, last_seq)
+SELECT
+   u.user_id,
+   COALESCE((SELECT t.balance FROM transactions t WHERE t.user_id = u.user_id ORDER BY t.user_transaction_seq DESC LIMIT 1), 0),
+   COALESCE((SELECT SUM(w.amount) FROM withdrawals w WHERE w.user_id = u.user_id AND w.processed_at IS NOT NULL), 0),
+   COALESCE((SELECT MAX(t.user_transaction_seq) FROM transactions t WHERE t.user_id = u.user_id), 0)
+FROM users u
+ON CONFLICT DO NOTHING;
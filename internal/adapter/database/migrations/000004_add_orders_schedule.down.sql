@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS orders_next_check_at_idx;
+ALTER TABLE orders
+   DROP COLUMN IF EXISTS next_check_at,
+   DROP COLUMN IF EXISTS attempts;
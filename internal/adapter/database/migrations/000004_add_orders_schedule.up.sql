@@ -0,0 +1,10 @@
+-- расписание проверки начислений по заказу
+-- next_check_at - момент, не раньше которого заказ будет проверен в системе расчёта начислений,
+-- attempts - количество выполненных проверок.
+ALTER TABLE orders
+   ADD COLUMN IF NOT EXISTS next_check_at TIMESTAMP NOT NULL DEFAULT NOW(),
+   ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+
+-- выборка заказов к проверке упорядочена по next_check_at
+CREATE INDEX IF NOT EXISTS orders_next_check_at_idx ON orders (next_check_at)
+   WHERE status IN ('NEW', 'PROCESSING');
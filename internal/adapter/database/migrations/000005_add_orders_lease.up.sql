@@ -0,0 +1,6 @@
+-- аренда заказа экземпляром сервиса на время проверки начислений
+-- locked_by - идентификатор экземпляра, взявшего заказ в обработку,
+-- locked_until - момент окончания аренды, после которого заказ может взять другой экземпляр.
+ALTER TABLE orders
+   ADD COLUMN IF NOT EXISTS locked_by VARCHAR(100) NULL,
+   ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP NULL;
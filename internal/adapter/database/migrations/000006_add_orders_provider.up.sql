@@ -0,0 +1,2 @@
+-- система расчёта начислений, которая рассчитала начисление по заказу
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS provider VARCHAR(100) NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS order_events;
+DROP TYPE IF EXISTS order_event_source;
@@ -0,0 +1,31 @@
+-- перечисление возможных источников изменения статуса заказа
+CREATE TYPE order_event_source AS ENUM (
+   'UPLOAD',
+   'POLLER',
+   'CALLBACK',
+   'ADMIN'
+);
+
+-- история изменения статусов заказов
+CREATE TABLE IF NOT EXISTS order_events (
+   event_id SERIAL PRIMARY KEY,
+   order_id INT NOT NULL,
+   status order_status NOT NULL,
+   accrual REAL NULL,
+   source order_event_source NOT NULL,
+   -- система расчёта начислений, которая рассчитала начисление
+   provider VARCHAR(100) NULL,
+   -- ответ системы расчёта начислений, на основании которого изменен статус
+   payload JSONB NULL,
+   created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+   CONSTRAINT fk_order
+      FOREIGN KEY (order_id)
+      REFERENCES orders(order_id)
+      ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS order_events_order_id_idx ON order_events (order_id, event_id);
+
+-- история уже загруженных заказов начинается с их загрузки
+INSERT INTO order_events (order_id, status, source, created_at)
+SELECT order_id, 'NEW', 'UPLOAD', uploaded_at FROM orders;
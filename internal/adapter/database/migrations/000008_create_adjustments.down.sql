@@ -0,0 +1,3 @@
+-- удаление значения 'ADJUSTMENT' из перечисления transaction_type не поддерживается
+DROP TABLE IF EXISTS admin_audit;
+DROP TABLE IF EXISTS adjustments;
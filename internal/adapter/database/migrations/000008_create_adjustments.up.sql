@@ -0,0 +1,41 @@
+-- корректировка баланса является источником транзакции
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'ADJUSTMENT';
+
+-- корректировки баланса пользователей
+-- Корректировка создается администратором вручную или при изменении начисления по уже
+-- начисленному заказу, тогда order_id - заказ, начисление по которому изменилось.
+CREATE TABLE IF NOT EXISTS adjustments (
+   adjustment_id SERIAL PRIMARY KEY,
+   user_id INT NOT NULL,
+   order_id INT NULL,
+   -- изменение баланса, может быть отрицательным
+   amount REAL NOT NULL,
+   reason TEXT NOT NULL CHECK (reason <> ''),
+   -- администратор, выполнивший корректировку, NULL - корректировка выполнена системой
+   -- при изменении начисления по заказу
+   admin VARCHAR(100) NULL,
+   created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+   CONSTRAINT fk_user
+      FOREIGN KEY (user_id)
+      REFERENCES users(user_id)
+      ON DELETE CASCADE,
+   CONSTRAINT fk_order
+      FOREIGN KEY (order_id)
+      REFERENCES orders(order_id)
+      ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS adjustments_order_id_idx ON adjustments (order_id) WHERE order_id IS NOT NULL;
+
+-- журнал действий администраторов
+CREATE TABLE IF NOT EXISTS admin_audit (
+   audit_id SERIAL PRIMARY KEY,
+   admin VARCHAR(100) NOT NULL,
+   action VARCHAR(50) NOT NULL,
+   order_id INT NULL,
+   user_id INT NULL,
+   reason TEXT NOT NULL,
+   -- параметры действия и его результат
+   details JSONB NULL,
+   created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS orders_dead_lettered_at_idx;
+ALTER TABLE orders
+   DROP COLUMN IF EXISTS failures,
+   DROP COLUMN IF EXISTS last_error,
+   DROP COLUMN IF EXISTS dead_lettered_at;
@@ -0,0 +1,10 @@
+-- обработка заказов, по которым система расчёта начислений возвращает некорректные ответы
+-- failures - количество некорректных ответов, last_error - последняя ошибка,
+-- dead_lettered_at - момент, когда проверка заказа была прекращена, NULL - заказ проверяется.
+ALTER TABLE orders
+   ADD COLUMN IF NOT EXISTS failures INT NOT NULL DEFAULT 0,
+   ADD COLUMN IF NOT EXISTS last_error TEXT NULL,
+   ADD COLUMN IF NOT EXISTS dead_lettered_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS orders_dead_lettered_at_idx ON orders (dead_lettered_at)
+   WHERE dead_lettered_at IS NOT NULL;
@@ -0,0 +1,4 @@
+-- удаление значения 'REGISTERED' из перечисления order_status не поддерживается,
+-- заказы и события с этим статусом переводятся в PROCESSING
+UPDATE orders SET status = 'PROCESSING' WHERE status = 'REGISTERED';
+UPDATE order_events SET status = 'PROCESSING' WHERE status = 'REGISTERED';
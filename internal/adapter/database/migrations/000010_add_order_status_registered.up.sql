@@ -0,0 +1,3 @@
+-- заказ зарегистрирован в системе расчёта начислений, но расчёт еще не начат.
+-- Для пользователя статус не отличается от PROCESSING.
+ALTER TYPE order_status ADD VALUE IF NOT EXISTS 'REGISTERED' AFTER 'NEW';
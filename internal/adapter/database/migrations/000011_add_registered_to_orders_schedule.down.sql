@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS orders_next_check_at_idx;
+CREATE INDEX IF NOT EXISTS orders_next_check_at_idx ON orders (next_check_at)
+   WHERE status IN ('NEW', 'PROCESSING');
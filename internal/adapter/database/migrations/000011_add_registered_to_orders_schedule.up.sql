@@ -0,0 +1,5 @@
+-- новое значение перечисления нельзя использовать в той же транзакции, в которой оно добавлено,
+-- поэтому индекс пересоздается отдельной миграцией
+DROP INDEX IF EXISTS orders_next_check_at_idx;
+CREATE INDEX IF NOT EXISTS orders_next_check_at_idx ON orders (next_check_at)
+   WHERE status IN ('NEW', 'REGISTERED', 'PROCESSING');
@@ -0,0 +1,3 @@
+-- сведения о покупке, по которой загружен заказ: сумма, валюта, продавец и позиции
+-- NULL - заказ загружен только номером
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS purchase JSONB NULL;
@@ -0,0 +1,18 @@
+-- правила расчёта вознаграждений за покупки собственной системой расчёта начислений
+CREATE TABLE IF NOT EXISTS reward_rules (
+   rule_id SERIAL PRIMARY KEY,
+   name VARCHAR(100) NOT NULL CHECK (name <> ''),
+   -- продавец, NULL - правило применяется к покупкам у любого продавца
+   merchant VARCHAR(255) NULL,
+   -- подстрока описания позиции покупки, NULL - правило применяется к покупке целиком
+   match VARCHAR(255) NULL,
+   reward REAL NOT NULL CHECK (reward > 0),
+   -- '%' - процент от стоимости, 'pt' - фиксированное количество баллов
+   reward_type VARCHAR(2) NOT NULL CHECK (reward_type IN ('%', 'pt')),
+   -- максимальное вознаграждение по правилу за одну покупку, NULL - без ограничений
+   max_reward REAL NULL CHECK (max_reward > 0),
+   -- период действия правила по времени загрузки заказа, NULL - без ограничения
+   valid_from TIMESTAMP NULL,
+   valid_until TIMESTAMP NULL,
+   created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS transactions_tenant_id_idx;
+DROP INDEX IF EXISTS withdrawals_tenant_id_idx;
+DROP INDEX IF EXISTS orders_tenant_id_idx;
+
+-- завершится ошибкой, если в разных программах есть пользователи с одинаковым логином
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_tenant_login_key;
+ALTER TABLE users ADD CONSTRAINT users_login_key UNIQUE (login);
+-- завершится ошибкой, если в разных программах есть промокоды с одинаковым кодом
+ALTER TABLE promo_codes DROP CONSTRAINT IF EXISTS promo_codes_tenant_code_key;
+ALTER TABLE promo_codes ADD CONSTRAINT promo_codes_code_key UNIQUE (code);
+
+ALTER TABLE promo_codes DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
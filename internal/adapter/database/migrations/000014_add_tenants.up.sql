@@ -0,0 +1,21 @@
+-- программы лояльности
+-- Пользователь относится к одной программе, его заказы, списания и транзакции - к программе пользователя.
+-- Номера заказов и списаний остаются уникальными во всем развертывании: системы расчета начислений
+-- идентифицируют заказ только по номеру.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(50) NOT NULL DEFAULT 'default';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(50) NOT NULL DEFAULT 'default';
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(50) NOT NULL DEFAULT 'default';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(50) NOT NULL DEFAULT 'default';
+ALTER TABLE promo_codes ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(50) NOT NULL DEFAULT 'default';
+
+-- логин уникален в рамках программы
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_login_key;
+ALTER TABLE users ADD CONSTRAINT users_tenant_login_key UNIQUE (tenant_id, login);
+
+-- промокод уникален в рамках программы и погашается только пользователями программы
+ALTER TABLE promo_codes DROP CONSTRAINT IF EXISTS promo_codes_code_key;
+ALTER TABLE promo_codes ADD CONSTRAINT promo_codes_tenant_code_key UNIQUE (tenant_id, code);
+
+CREATE INDEX IF NOT EXISTS orders_tenant_id_idx ON orders (tenant_id);
+CREATE INDEX IF NOT EXISTS withdrawals_tenant_id_idx ON withdrawals (tenant_id);
+CREATE INDEX IF NOT EXISTS transactions_tenant_id_idx ON transactions (tenant_id);
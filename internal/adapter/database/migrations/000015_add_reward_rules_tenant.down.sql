@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS reward_rules_tenant_id_idx;
+
+ALTER TABLE reward_rules DROP COLUMN IF EXISTS tenant_id;
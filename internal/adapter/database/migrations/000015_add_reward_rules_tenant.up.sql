@@ -0,0 +1,4 @@
+-- правила расчёта вознаграждений относятся к программе лояльности и применяются только к ее заказам
+ALTER TABLE reward_rules ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(50) NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS reward_rules_tenant_id_idx ON reward_rules (tenant_id);
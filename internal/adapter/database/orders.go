@@ -2,14 +2,43 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+	"github.com/k1nky/gophermart/internal/entity/transaction"
 	"github.com/k1nky/gophermart/internal/entity/user"
 )
 
+// Столбцы заказа в порядке сканирования scanOrder
+const orderColumns = `order_id, number, status, accrual, uploaded_at, user_id, attempts, COALESCE(provider, ''),
+	failures, COALESCE(last_error, ''), dead_lettered_at, purchase, tenant_id`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Читает заказ из строки со столбцами orderColumns
+func scanOrder(row scanner) (*order.Order, error) {
+	o := &order.Order{}
+	var purchase []byte
+	err := row.Scan(&o.ID, &o.Number, &o.Status, &o.Accrual, &o.UploadedAt, &o.UserID, &o.Attempts, &o.Provider,
+		&o.Failures, &o.LastError, &o.DeadLetteredAt, &purchase, &o.TenantID)
+	if err != nil || purchase == nil {
+		return o, err
+	}
+	o.Purchase = &order.Purchase{}
+	return o, json.Unmarshal(purchase, o.Purchase)
+}
+
 func (a *Adapter) selectOrders(ctx context.Context, where string, limit uint, args ...interface{}) ([]*order.Order, error) {
-	query := fmt.Sprintf(`SELECT order_id, number, status, accrual, uploaded_at, user_id FROM orders WHERE %s`, where)
+	query := fmt.Sprintf(`SELECT %s FROM orders WHERE %s`, orderColumns, where)
 	if limit > 0 {
 		query = query + fmt.Sprintf(" LIMIT %d", limit)
 	}
@@ -20,8 +49,8 @@ func (a *Adapter) selectOrders(ctx context.Context, where string, limit uint, ar
 	}
 	defer rows.Close()
 	for rows.Next() {
-		o := &order.Order{}
-		if err := rows.Scan(&o.ID, &o.Number, &o.Status, &o.Accrual, &o.UploadedAt, &o.UserID); err != nil {
+		o, err := scanOrder(rows)
+		if err != nil {
 			return orders, err
 		}
 		orders = append(orders, o)
@@ -59,6 +88,153 @@ func (a *Adapter) GetOrdersByStatus(ctx context.Context, statuses []order.OrderS
 	return orders, err
 }
 
+// Берет в аренду на время lease не более maxRows заказов с заданными статусами, время проверки которых наступило.
+// Заказы выбираются в порядке времени следующей проверки, поэтому давно ожидающие заказы выбираются первыми.
+// Заказы, арендованные другими экземплярами сервиса, пропускаются до окончания их аренды.
+// Арендуются только заказы программ лояльности, подходящих под отбор tenants.
+func (a *Adapter) ClaimOrders(ctx context.Context, owner string, statuses []order.OrderStatus, tenants tenant.Filter, lease time.Duration, maxRows uint) ([]*order.Order, error) {
+	args := make([]string, 0, len(statuses))
+	// преобразуем в совместимый с postgres тип
+	for _, v := range statuses {
+		args = append(args, string(v))
+	}
+	where := fmt.Sprintf("status = any($3::order_status[]) AND %s ORDER BY next_check_at ASC LIMIT $4", tenantCondition(5))
+	orders, err := a.claimOrders(ctx, owner, lease, where, args, maxRows, tenantIDs(tenants.Include), tenantIDs(tenants.Exclude))
+	if err != nil {
+		err = NewExecutingQueryError(err)
+	}
+	return orders, err
+}
+
+// Берет в аренду на время lease заказ с заданными статусами, время проверки которого наступило.
+// Возвращает nil, если заказ не найден, арендован другим экземпляром сервиса или относится к программе
+// лояльности, не подходящей под отбор tenants.
+func (a *Adapter) ClaimOrder(ctx context.Context, owner string, id order.ID, statuses []order.OrderStatus, tenants tenant.Filter, lease time.Duration) (*order.Order, error) {
+	args := make([]string, 0, len(statuses))
+	// преобразуем в совместимый с postgres тип
+	for _, v := range statuses {
+		args = append(args, string(v))
+	}
+	where := fmt.Sprintf("order_id = $3 AND status = any($4::order_status[]) AND %s", tenantCondition(5))
+	orders, err := a.claimOrders(ctx, owner, lease, where, id, args, tenantIDs(tenants.Include), tenantIDs(tenants.Exclude))
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	if len(orders) == 0 {
+		return nil, nil
+	}
+	return orders[0], nil
+}
+
+// Возвращает условие отбора заказов по программам лояльности tenant.Filter: параметр $n - отбираемые программы,
+// $n+1 - исключаемые программы
+func tenantCondition(n int) string {
+	return fmt.Sprintf("(cardinality($%d::text[]) = 0 OR tenant_id = any($%d::text[])) AND tenant_id <> all($%d::text[])", n, n, n+1)
+}
+
+// Преобразует идентификаторы программ лояльности в совместимый с postgres тип
+func tenantIDs(ids []tenant.ID) []string {
+	args := make([]string, 0, len(ids))
+	for _, v := range ids {
+		args = append(args, string(v))
+	}
+	return args
+}
+
+// Берет в аренду заказы, удовлетворяющие условию where, время проверки которых наступило и
+// которые не арендованы другими экземплярами сервиса. Заказы, проверка которых прекращена, не арендуются.
+// Параметры условия нумеруются начиная с $3.
+func (a *Adapter) claimOrders(ctx context.Context, owner string, lease time.Duration, where string, args ...interface{}) ([]*order.Order, error) {
+	query := fmt.Sprintf(`
+		UPDATE orders
+		SET locked_by = $1, locked_until = NOW() + make_interval(secs => $2)
+		WHERE order_id IN (
+			SELECT order_id FROM orders
+			WHERE next_check_at <= NOW()
+				AND (locked_until IS NULL OR locked_until < NOW())
+				AND dead_lettered_at IS NULL
+				AND %s
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %s
+	`, where, orderColumns)
+	orders := make([]*order.Order, 0)
+	rows, err := a.QueryContext(ctx, query, append([]interface{}{owner, lease.Seconds()}, args...)...)
+	if err != nil {
+		return orders, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return orders, err
+		}
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return orders, err
+	}
+	return orders, nil
+}
+
+// Откладывает следующую проверку заказа o, арендованного экземпляром owner, на delay, увеличивает счетчик
+// проверок и снимает аренду заказа. Счетчик некорректных ответов системы расчёта начислений заменяется
+// на o.Failures: после корректного ответа сервис сбрасывает его, чтобы проверка прекращалась только после
+// некорректных ответов подряд. Если заказ больше не арендован owner, возвращается ErrLeaseLost.
+func (a *Adapter) DeferOrder(ctx context.Context, owner string, o order.Order, delay time.Duration) error {
+	const query = `
+		UPDATE orders
+		SET attempts = attempts + 1, next_check_at = NOW() + make_interval(secs => $2),
+			failures = $3, locked_by = NULL, locked_until = NULL
+		WHERE order_id = $1 AND locked_by = $4
+	`
+	result, err := a.ExecContext(ctx, query, o.ID, delay.Seconds(), o.Failures, owner)
+	if err != nil {
+		return NewExecutingQueryError(err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return NewExecutingQueryError(err)
+	} else if n == 0 {
+		return fmt.Errorf("%s %w", o.Number, order.ErrLeaseLost)
+	}
+	return nil
+}
+
+// Учитывает некорректный ответ системы расчёта начислений по заказу, арендованному экземпляром owner:
+// сохраняет ошибку reason, откладывает следующую проверку заказа на delay и снимает аренду заказа.
+// После maxFailures некорректных ответов проверка заказа прекращается, 0 - без ограничений.
+// Возвращает истину, если проверка заказа прекращена. Если заказ больше не арендован owner,
+// возвращается ErrLeaseLost.
+func (a *Adapter) FailOrder(ctx context.Context, owner string, id order.ID, reason string, delay time.Duration, maxFailures uint) (bool, error) {
+	const query = `
+		UPDATE orders
+		SET attempts = attempts + 1, next_check_at = NOW() + make_interval(secs => $3),
+			failures = failures + 1, last_error = $2,
+			dead_lettered_at = CASE WHEN $4 > 0 AND failures + 1 >= $4 THEN NOW() END,
+			locked_by = NULL, locked_until = NULL
+		WHERE order_id = $1 AND locked_by = $5
+		RETURNING dead_lettered_at IS NOT NULL
+	`
+	var deadLettered bool
+	if err := a.QueryRowContext(ctx, query, id, reason, delay.Seconds(), maxFailures, owner).Scan(&deadLettered); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, fmt.Errorf("order %d %w", id, order.ErrLeaseLost)
+		}
+		return false, NewExecutingQueryError(err)
+	}
+	return deadLettered, nil
+}
+
+// Возвращает не более maxRows заказов программы лояльности tenantID, проверка которых прекращена,
+// в порядке прекращения проверки
+func (a *Adapter) GetDeadLetterOrders(ctx context.Context, tenantID tenant.ID, maxRows uint) ([]*order.Order, error) {
+	orders, err := a.selectOrders(ctx, "dead_lettered_at IS NOT NULL AND tenant_id = $1 ORDER BY dead_lettered_at ASC", maxRows, tenantID)
+	if err != nil {
+		err = NewExecutingQueryError(err)
+	}
+	return orders, err
+}
+
 // Возвращает не более maxRows заказов для указанного пользователя в порядке возрастания даты загрузки
 func (a *Adapter) GetOrdersByUserID(ctx context.Context, userID user.ID, maxRows uint) ([]*order.Order, error) {
 	orders, err := a.selectOrders(ctx, "user_id = $1 ORDER BY uploaded_at ASC", maxRows, userID)
@@ -68,71 +244,182 @@ func (a *Adapter) GetOrdersByUserID(ctx context.Context, userID user.ID, maxRows
 	return orders, err
 }
 
-// Создает новый заказ и возвращает его
+// Создает новый заказ и возвращает его. Записывает загрузку заказа в историю изменения его статуса и
+// уведомляет подписчиков канала NewOrdersChannel о новом заказе.
 func (a *Adapter) NewOrder(ctx context.Context, o order.Order) (*order.Order, error) {
 	const query = `
-		INSERT INTO orders AS o (user_id, number, status)
-		VALUES ($1, $2, 'NEW')
-		RETURNING o.order_id, o.uploaded_at
+		INSERT INTO orders AS o (user_id, tenant_id, number, status, purchase)
+		VALUES ($1, (SELECT tenant_id FROM users WHERE user_id = $1), $2, 'NEW', $3::jsonb)
+		RETURNING o.order_id, o.uploaded_at, o.tenant_id
 	`
-	row := a.QueryRowContext(ctx, query, o.UserID, o.Number)
+	tx, err := a.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	defer tx.Rollback()
+
+	var purchase interface{}
+	if o.Purchase != nil {
+		b, err := json.Marshal(o.Purchase)
+		if err != nil {
+			return nil, err
+		}
+		purchase = string(b)
+	}
+	row := tx.QueryRowContext(ctx, query, o.UserID, o.Number, purchase)
 	if err := row.Err(); err != nil {
 		if a.hasUniqueViolationError(err) {
 			return nil, fmt.Errorf("%s %w", o.Number, order.ErrDuplicated)
 		}
 		return nil, NewExecutingQueryError(err)
 	}
-	if err := row.Scan(&o.ID, &o.UploadedAt); err != nil {
+	if err := row.Scan(&o.ID, &o.UploadedAt, &o.TenantID); err != nil {
 		return nil, NewExecutingQueryError(err)
 	}
 	o.Status = order.StatusNew
+	if err := a.newOrderEvent(ctx, tx, o, order.SourceUpload); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	// уведомление будет доставлено подписчикам только после фиксации транзакции
+	if _, err := tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", NewOrdersChannel, strconv.FormatUint(uint64(o.ID), 10)); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
 	return &o, nil
-
 }
 
-// Обновляет заказ
-func (a *Adapter) UpdateOrder(ctx context.Context, o order.Order) error {
-	// не допускаем обновление уже обработанного заказ
-	const updateOrderQuery = `
-		UPDATE orders 
-		SET status = $1, accrual = $2
-		WHERE order_id = $3 AND status <> 'PROCESSED'
+// Добавляет заказы пользователя userID с номерами numbers в рамках одной транзакции.
+// Номера, которые уже были загружены, пропускаются. Возвращает добавленные заказы.
+func (a *Adapter) NewOrders(ctx context.Context, userID user.ID, numbers []order.OrderNumber) ([]*order.Order, error) {
+	const query = `
+		INSERT INTO orders AS o (user_id, tenant_id, number, status)
+		SELECT $1, (SELECT tenant_id FROM users WHERE user_id = $1), number, 'NEW' FROM unnest($2::text[]) AS number
+		ON CONFLICT (number) DO NOTHING
+		RETURNING o.order_id, o.number, o.uploaded_at, o.tenant_id
 	`
 	tx, err := a.BeginTx(ctx, nil)
 	if err != nil {
-		return NewExecutingQueryError(err)
+		return nil, NewExecutingQueryError(err)
 	}
 	defer tx.Rollback()
 
-	if r, err := tx.ExecContext(ctx, updateOrderQuery, o.Status, o.Accrual, o.ID); err != nil {
+	args := make([]string, 0, len(numbers))
+	for _, v := range numbers {
+		args = append(args, string(v))
+	}
+	rows, err := tx.QueryContext(ctx, query, userID, args)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	orders := make([]*order.Order, 0, len(numbers))
+	for rows.Next() {
+		o := &order.Order{UserID: userID, Status: order.StatusNew}
+		if err := rows.Scan(&o.ID, &o.Number, &o.UploadedAt, &o.TenantID); err != nil {
+			rows.Close()
+			return nil, NewExecutingQueryError(err)
+		}
+		orders = append(orders, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	for _, o := range orders {
+		if err := a.newOrderEvent(ctx, tx, *o, order.SourceUpload); err != nil {
+			return nil, NewExecutingQueryError(err)
+		}
+		if _, err := tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", NewOrdersChannel, strconv.FormatUint(uint64(o.ID), 10)); err != nil {
+			return nil, NewExecutingQueryError(err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	return orders, nil
+}
+
+// Возвращает заказы с номерами numbers
+func (a *Adapter) GetOrdersByNumbers(ctx context.Context, numbers []order.OrderNumber) ([]*order.Order, error) {
+	args := make([]string, 0, len(numbers))
+	for _, v := range numbers {
+		args = append(args, string(v))
+	}
+	orders, err := a.selectOrders(ctx, "number = any($1::text[])", 0, args)
+	if err != nil {
+		err = NewExecutingQueryError(err)
+	}
+	return orders, err
+}
+
+// Подписывается на уведомления о новых заказах и вызывает fn для каждого нового заказа.
+// Блокируется до отмены контекста или потери подключения к базе.
+func (a *Adapter) ListenNewOrders(ctx context.Context, fn func(id order.ID)) error {
+	conn, err := a.Conn(ctx)
+	if err != nil {
+		return NewExecutingQueryError(err)
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", driverConn)
+		}
+		pgxConn := c.Conn()
+		if _, err := pgxConn.Exec(ctx, "LISTEN "+NewOrdersChannel); err != nil {
+			return NewExecutingQueryError(err)
+		}
+		// подключение возвращается в пул, поэтому отписываемся от канала
+		defer pgxConn.Exec(context.Background(), "UNLISTEN "+NewOrdersChannel)
+		for {
+			n, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				return NewExecutingQueryError(err)
+			}
+			id, err := strconv.ParseUint(n.Payload, 10, 64)
+			if err != nil {
+				continue
+			}
+			fn(order.ID(id))
+		}
+	})
+}
+
+// Обновляет заказ и записывает изменение в историю изменения статуса заказа с источником изменения source
+// и ответом системы расчёта начислений o.Payload.
+// Заказ, арендованный экземпляром owner, обновляется, только пока аренда принадлежит owner, иначе возвращается
+// ErrLeaseLost. Аренда сохраняется, пока статус заказа не окончательный, и снимается при откладывании
+// следующей проверки, см. DeferOrder. Пустой owner - заказ обновляется без аренды.
+func (a *Adapter) UpdateOrder(ctx context.Context, owner string, o order.Order, source order.EventSource) error {
+	tx, err := a.BeginTx(ctx, nil)
+	if err != nil {
 		return NewExecutingQueryError(err)
+	}
+	defer tx.Rollback()
+
+	var prev *order.Order
+	if len(owner) == 0 {
+		prev, err = a.lockOrder(ctx, tx, "order_id = $1", o.ID)
 	} else {
-		if rows, err := r.RowsAffected(); rows == 0 || err != nil {
-			return fmt.Errorf("%s %w", o.Number, order.ErrAlreadyProcessed)
-		}
-	}
-	// добавляем соответствующую транзакцию
-	if o.Accrual != nil && o.Status == order.StatusProcessed {
-		// получаем последнюю транзакцию пользователя
-		// для новой транзакции увеличиваем последовательный номер транзакции пользователя на 1 и баланс на размер начисления
-		// последовательный номер уникальный для каждого пользователя
-		const transactionQuery = `
-			WITH user_balance AS (
-				SELECT coalesce(sum(user_transaction_seq), 0) seq, coalesce(sum(balance), 0) balance FROM transactions WHERE user_id = $1 ORDER BY seq DESC LIMIT 1
-			)
-			INSERT INTO transactions(
-				user_id,
-				user_transaction_seq,
-				source_id, source_type,
-				balance
-			) VALUES (
-				$1,
-				(SELECT seq FROM user_balance) + 1,
-				$2, 'ACCRUAL',
-				(SELECT balance FROM user_balance) + $3
-			)
-		`
-		if _, err := tx.ExecContext(ctx, transactionQuery, o.UserID, o.ID, o.Accrual); err != nil {
+		prev, err = a.lockOrder(ctx, tx, "order_id = $1 AND locked_by = $2", o.ID, owner)
+	}
+	if err != nil {
+		return NewExecutingQueryError(err)
+	}
+	if prev == nil && len(owner) != 0 {
+		return fmt.Errorf("%s %w", o.Number, order.ErrLeaseLost)
+	}
+	// не допускаем обновление уже обработанного заказ
+	if prev == nil || prev.Status == order.StatusProcessed {
+		return fmt.Errorf("%s %w", o.Number, order.ErrAlreadyProcessed)
+	}
+	reason := fmt.Sprintf("order %s has been recalculated", o.Number)
+	if _, err := a.changeOrder(ctx, tx, *prev, o, source, reason, ""); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	if len(owner) == 0 || o.Status.IsFinal() {
+		if err := a.unlockOrder(ctx, tx, o.ID); err != nil {
 			return NewExecutingQueryError(err)
 		}
 	}
@@ -141,3 +428,122 @@ func (a *Adapter) UpdateOrder(ctx context.Context, o order.Order) error {
 	}
 	return nil
 }
+
+// Снимает аренду заказа id в рамках транзакции tx
+func (a *Adapter) unlockOrder(ctx context.Context, tx *sql.Tx, id order.ID) error {
+	_, err := tx.ExecContext(ctx, `UPDATE orders SET locked_by = NULL, locked_until = NULL WHERE order_id = $1`, id)
+	return err
+}
+
+// Блокирует до конца транзакции tx и возвращает заказ, удовлетворяющий условию where. Nil - заказ не найден.
+func (a *Adapter) lockOrder(ctx context.Context, tx *sql.Tx, where string, args ...interface{}) (*order.Order, error) {
+	query := fmt.Sprintf(`SELECT %s FROM orders WHERE %s FOR UPDATE`, orderColumns, where)
+	o, err := scanOrder(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return o, nil
+}
+
+// Изменяет заказ prev на o в рамках транзакции tx, не изменяя аренду заказа: обновляет статус и начисление,
+// записывает изменение в историю статусов заказа и проводит изменение начисления по журналу транзакций.
+// Первое начисление по заказу проводится транзакцией ACCRUAL, а последующие изменения уже проведенного
+// начисления - корректировками с причиной reason от имени admin. Пустой admin - изменение выполнено
+// системой, например, при пересчёте начисления системой расчёта начислений. Возвращает баланс пользователя
+// после проведения, nil - баланс не изменился.
+func (a *Adapter) changeOrder(ctx context.Context, tx *sql.Tx, prev order.Order, o order.Order, source order.EventSource, reason string, admin string) (*float32, error) {
+	const updateOrderQuery = `
+		UPDATE orders
+		SET status = $1, accrual = $2, provider = COALESCE(NULLIF($4, ''), provider)
+		WHERE order_id = $3
+	`
+	if _, err := tx.ExecContext(ctx, updateOrderQuery, o.Status, o.Accrual, o.ID, o.Provider); err != nil {
+		return nil, err
+	}
+	if err := a.newOrderEvent(ctx, tx, o, source); err != nil {
+		return nil, err
+	}
+
+	var credited bool
+	row := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM transactions WHERE source_id = $1 AND source_type = $2)`, o.ID, transaction.SourceAccrual)
+	if err := row.Scan(&credited); err != nil {
+		return nil, err
+	}
+	if !credited {
+		// добавляем соответствующую транзакцию
+		if o.Accrual == nil || o.Status != order.StatusProcessed {
+			return nil, nil
+		}
+		balance, err := a.newTransaction(ctx, tx, prev.UserID, uint64(o.ID), transaction.SourceAccrual, *o.Accrual)
+		return &balance, err
+	}
+	delta := creditedAccrual(o) - creditedAccrual(prev)
+	if delta == 0 {
+		return nil, nil
+	}
+	adj, err := a.newAdjustment(ctx, tx, prev.UserID, o.ID, delta, reason, admin)
+	if err != nil {
+		return nil, err
+	}
+	return &adj.Balance, nil
+}
+
+// Возвращает начисление по заказу, которое должно быть проведено по журналу транзакций
+func creditedAccrual(o order.Order) float32 {
+	if o.Status != order.StatusProcessed || o.Accrual == nil {
+		return 0
+	}
+	return *o.Accrual
+}
+
+// Записывает текущий статус заказа o в историю изменения статуса заказа
+func (a *Adapter) newOrderEvent(ctx context.Context, tx *sql.Tx, o order.Order, source order.EventSource) error {
+	const query = `
+		INSERT INTO order_events (order_id, status, accrual, source, provider, payload)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6::jsonb)
+	`
+	var payload interface{}
+	if len(o.Payload) != 0 {
+		payload = string(o.Payload)
+	}
+	var accrual *float32
+	if o.Status == order.StatusProcessed {
+		accrual = o.Accrual
+	}
+	_, err := tx.ExecContext(ctx, query, o.ID, o.Status, accrual, source, o.Provider, payload)
+	return err
+}
+
+// Возвращает историю изменения статуса заказа в порядке возрастания времени изменения
+func (a *Adapter) GetOrderEvents(ctx context.Context, id order.ID) ([]order.Event, error) {
+	const query = `
+		SELECT order_id, status, accrual, source, COALESCE(provider, ''), payload, created_at
+		FROM order_events
+		WHERE order_id = $1
+		ORDER BY event_id ASC
+	`
+	events := make([]order.Event, 0)
+	rows, err := a.QueryContext(ctx, query, id)
+	if err != nil {
+		return events, NewExecutingQueryError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		e := order.Event{}
+		var payload []byte
+		if err := rows.Scan(&e.OrderID, &e.Status, &e.Accrual, &e.Source, &e.Provider, &payload, &e.CreatedAt); err != nil {
+			return events, NewExecutingQueryError(err)
+		}
+		if len(payload) != 0 {
+			e.Payload = payload
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return events, NewExecutingQueryError(err)
+	}
+	return events, nil
+}
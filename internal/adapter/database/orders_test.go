@@ -2,8 +2,10 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
 	"github.com/k1nky/gophermart/internal/entity/user"
 	"github.com/stretchr/testify/suite"
 )
@@ -49,6 +51,39 @@ func (suite *ordersTestSuite) TestNewOrder() {
 	suite.NotEqual(0, newOrder.ID)
 }
 
+func (suite *ordersTestSuite) TestNewOrderWithPurchase() {
+	o := order.Order{
+		Number: "999",
+		UserID: user.ID(2),
+		Purchase: &order.Purchase{
+			Total:    7000,
+			Currency: "RUB",
+			Merchant: "Bork",
+			Items:    []order.Item{{Description: "Чайник Bork", Price: 7000}},
+		},
+	}
+	newOrder, err := suite.a.NewOrder(context.TODO(), o)
+	suite.NoError(err)
+	suite.Equal(o.Purchase, newOrder.Purchase)
+	got, err := suite.a.GetOrderByNumber(context.TODO(), "999")
+	suite.NoError(err)
+	suite.Equal(o.Purchase, got.Purchase)
+}
+
+func (suite *ordersTestSuite) TestNewOrderTenant() {
+	_, err := suite.a.Exec(`UPDATE users SET tenant_id = 'bork' WHERE user_id = 2`)
+	suite.Require().NoError(err)
+	newOrder, err := suite.a.NewOrder(context.TODO(), order.Order{Number: "999", UserID: user.ID(2)})
+	suite.NoError(err)
+	suite.Equal(tenant.ID("bork"), newOrder.TenantID)
+	created, err := suite.a.NewOrders(context.TODO(), user.ID(2), []order.OrderNumber{"998"})
+	suite.NoError(err)
+	suite.Equal(tenant.ID("bork"), created[0].TenantID)
+	got, err := suite.a.GetOrderByNumber(context.TODO(), "100")
+	suite.NoError(err)
+	suite.Equal(tenant.Default, got.TenantID)
+}
+
 func (suite *ordersTestSuite) TestNewOrderDuplicate() {
 	o := order.Order{
 		Number: "100",
@@ -59,6 +94,21 @@ func (suite *ordersTestSuite) TestNewOrderDuplicate() {
 	suite.Nil(got)
 }
 
+func (suite *ordersTestSuite) TestNewOrders() {
+	created, err := suite.a.NewOrders(context.TODO(), user.ID(2), []order.OrderNumber{"100", "998", "999"})
+	suite.NoError(err)
+	suite.Len(created, 2)
+	for _, o := range created {
+		suite.Equal(order.StatusNew, o.Status)
+		suite.Equal(user.ID(2), o.UserID)
+		suite.NotEqual(0, o.ID)
+	}
+
+	orders, err := suite.a.GetOrdersByNumbers(context.TODO(), []order.OrderNumber{"100", "999", "1000"})
+	suite.NoError(err)
+	suite.Len(orders, 2)
+}
+
 func (suite *ordersTestSuite) TestUpdateOrder() {
 	var v float32 = 120.0
 
@@ -69,7 +119,7 @@ func (suite *ordersTestSuite) TestUpdateOrder() {
 		Accrual: &v,
 		UserID:  user.ID(1),
 	}
-	err := suite.a.UpdateOrder(context.TODO(), o)
+	err := suite.a.UpdateOrder(context.TODO(), "", o, order.SourcePoller)
 	suite.NoError(err)
 }
 
@@ -83,6 +133,210 @@ func (suite *ordersTestSuite) TestUpdateOrderProcessed() {
 		Accrual: &v,
 		UserID:  user.ID(1),
 	}
-	err := suite.a.UpdateOrder(context.TODO(), o)
+	err := suite.a.UpdateOrder(context.TODO(), "", o, order.SourcePoller)
 	suite.ErrorIs(err, order.ErrAlreadyProcessed)
 }
+
+func (suite *ordersTestSuite) TestClaimOrders() {
+	statuses := []order.OrderStatus{order.StatusNew, order.StatusProcessing}
+	if _, err := suite.a.Exec(`UPDATE orders SET next_check_at = NOW() - INTERVAL '1 minute' WHERE order_id = 2`); err != nil {
+		suite.FailNow(err.Error())
+	}
+	orders, err := suite.a.ClaimOrders(context.TODO(), "a", statuses, tenant.Filter{}, time.Minute, 1)
+	suite.NoError(err)
+	suite.Len(orders, 1)
+	suite.Equal(order.ID(2), orders[0].ID)
+	// заказ 2 арендован экземпляром a
+	orders, err = suite.a.ClaimOrders(context.TODO(), "b", statuses, tenant.Filter{}, time.Minute, 10)
+	suite.NoError(err)
+	suite.Len(orders, 1)
+	suite.Equal(order.ID(1), orders[0].ID)
+	orders, err = suite.a.ClaimOrders(context.TODO(), "b", statuses, tenant.Filter{}, time.Minute, 10)
+	suite.NoError(err)
+	suite.Len(orders, 0)
+}
+
+func (suite *ordersTestSuite) TestClaimOrdersByTenant() {
+	statuses := []order.OrderStatus{order.StatusNew, order.StatusProcessing}
+	if _, err := suite.a.Exec(`UPDATE orders SET tenant_id = 'bork' WHERE order_id = 2`); err != nil {
+		suite.FailNow(err.Error())
+	}
+	// система начислений программы bork недоступна
+	orders, err := suite.a.ClaimOrders(context.TODO(), "a", statuses, tenant.Filter{Exclude: []tenant.ID{"bork"}}, time.Minute, 10)
+	suite.NoError(err)
+	if suite.Len(orders, 1) {
+		suite.Equal(order.ID(1), orders[0].ID)
+	}
+	o, err := suite.a.ClaimOrder(context.TODO(), "a", 2, statuses, tenant.Filter{Exclude: []tenant.ID{"bork"}}, time.Minute)
+	suite.NoError(err)
+	suite.Nil(o)
+	// доступна только система начислений программы bork
+	orders, err = suite.a.ClaimOrders(context.TODO(), "b", statuses, tenant.Filter{Include: []tenant.ID{"bork"}}, time.Minute, 10)
+	suite.NoError(err)
+	if suite.Len(orders, 1) {
+		suite.Equal(order.ID(2), orders[0].ID)
+		suite.Equal(tenant.ID("bork"), orders[0].TenantID)
+	}
+}
+
+func (suite *ordersTestSuite) TestClaimOrdersExpiredLease() {
+	statuses := []order.OrderStatus{order.StatusNew, order.StatusProcessing}
+	if _, err := suite.a.Exec(`UPDATE orders SET locked_by = 'a', locked_until = NOW() - INTERVAL '1 second' WHERE order_id = 1`); err != nil {
+		suite.FailNow(err.Error())
+	}
+	if _, err := suite.a.Exec(`UPDATE orders SET locked_by = 'a', locked_until = NOW() + INTERVAL '1 minute' WHERE order_id = 2`); err != nil {
+		suite.FailNow(err.Error())
+	}
+	orders, err := suite.a.ClaimOrders(context.TODO(), "b", statuses, tenant.Filter{}, time.Minute, 10)
+	suite.NoError(err)
+	suite.Len(orders, 1)
+	suite.Equal(order.ID(1), orders[0].ID)
+}
+
+func (suite *ordersTestSuite) TestLeaseFencing() {
+	statuses := []order.OrderStatus{order.StatusNew, order.StatusProcessing}
+	// аренда экземпляра a истекла, и заказ 1 арендовал экземпляр b
+	if _, err := suite.a.Exec(`UPDATE orders SET locked_by = 'a', locked_until = NOW() - INTERVAL '1 second' WHERE order_id = 1`); err != nil {
+		suite.FailNow(err.Error())
+	}
+	if _, err := suite.a.Exec(`UPDATE orders SET next_check_at = NOW() + INTERVAL '1 minute' WHERE order_id = 2`); err != nil {
+		suite.FailNow(err.Error())
+	}
+	orders, err := suite.a.ClaimOrders(context.TODO(), "b", statuses, tenant.Filter{}, time.Minute, 10)
+	suite.NoError(err)
+	suite.Require().Len(orders, 1)
+	o := *orders[0]
+
+	stale := o
+	stale.Status = order.StatusInvalid
+	suite.ErrorIs(suite.a.UpdateOrder(context.TODO(), "a", stale, order.SourcePoller), order.ErrLeaseLost)
+	suite.ErrorIs(suite.a.DeferOrder(context.TODO(), "a", o, time.Minute), order.ErrLeaseLost)
+
+	// владелец аренды обновляет заказ, аренда сохраняется до откладывания следующей проверки
+	o.Status = order.StatusProcessing
+	suite.NoError(suite.a.UpdateOrder(context.TODO(), "b", o, order.SourcePoller))
+	orders, err = suite.a.ClaimOrders(context.TODO(), "c", statuses, tenant.Filter{}, time.Minute, 10)
+	suite.NoError(err)
+	suite.Len(orders, 0)
+	suite.NoError(suite.a.DeferOrder(context.TODO(), "b", o, 0))
+	orders, err = suite.a.ClaimOrders(context.TODO(), "c", statuses, tenant.Filter{}, time.Minute, 10)
+	suite.NoError(err)
+	suite.Require().Len(orders, 1)
+	suite.Equal(order.StatusProcessing, orders[0].Status)
+}
+
+func (suite *ordersTestSuite) TestClaimOrdersSkipsDeadLettered() {
+	statuses := []order.OrderStatus{order.StatusNew, order.StatusProcessing}
+	_, err := suite.a.ClaimOrder(context.TODO(), "a", 1, statuses, tenant.Filter{}, time.Minute)
+	suite.NoError(err)
+	deadLettered, err := suite.a.FailOrder(context.TODO(), "a", 1, "500", 0, 1)
+	suite.NoError(err)
+	suite.True(deadLettered)
+	orders, err := suite.a.ClaimOrders(context.TODO(), "a", statuses, tenant.Filter{}, time.Minute, 10)
+	suite.NoError(err)
+	suite.Len(orders, 1)
+	suite.Equal(order.ID(2), orders[0].ID)
+	o, err := suite.a.ClaimOrder(context.TODO(), "a", 1, statuses, tenant.Filter{}, time.Minute)
+	suite.NoError(err)
+	suite.Nil(o)
+}
+
+func (suite *ordersTestSuite) TestDeferOrderResetsFailures() {
+	statuses := []order.OrderStatus{order.StatusNew, order.StatusProcessing}
+	_, err := suite.a.ClaimOrder(context.TODO(), "a", 1, statuses, tenant.Filter{}, time.Minute)
+	suite.NoError(err)
+	_, err = suite.a.FailOrder(context.TODO(), "a", 1, "500", 0, 3)
+	suite.NoError(err)
+	o, err := suite.a.ClaimOrder(context.TODO(), "a", 1, statuses, tenant.Filter{}, time.Minute)
+	suite.NoError(err)
+	suite.Require().NotNil(o)
+	suite.Equal(uint(1), o.Failures)
+	o.Failures = 0
+	suite.NoError(suite.a.DeferOrder(context.TODO(), "a", *o, 0))
+	o, err = suite.a.ClaimOrder(context.TODO(), "a", 1, statuses, tenant.Filter{}, time.Minute)
+	suite.NoError(err)
+	suite.Require().NotNil(o)
+	suite.Zero(o.Failures)
+}
+
+func (suite *ordersTestSuite) TestDeferOrder() {
+	statuses := []order.OrderStatus{order.StatusNew, order.StatusProcessing}
+	_, err := suite.a.ClaimOrders(context.TODO(), "a", statuses, tenant.Filter{}, time.Minute, 10)
+	suite.NoError(err)
+	err = suite.a.DeferOrder(context.TODO(), "a", order.Order{ID: 1}, time.Minute)
+	suite.NoError(err)
+	err = suite.a.DeferOrder(context.TODO(), "a", order.Order{ID: 2}, 0)
+	suite.NoError(err)
+	// заказ 1 отложен, заказ 2 доступен для проверки, аренда обоих снята
+	orders, err := suite.a.ClaimOrders(context.TODO(), "b", statuses, tenant.Filter{}, time.Minute, 10)
+	suite.NoError(err)
+	suite.Len(orders, 1)
+	suite.Equal(order.ID(2), orders[0].ID)
+	suite.Equal(uint(1), orders[0].Attempts)
+}
+
+func (suite *ordersTestSuite) TestClaimOrder() {
+	statuses := []order.OrderStatus{order.StatusNew, order.StatusProcessing}
+	o, err := suite.a.ClaimOrder(context.TODO(), "a", 1, statuses, tenant.Filter{}, time.Minute)
+	suite.NoError(err)
+	suite.Equal(order.ID(1), o.ID)
+	// заказ уже арендован
+	o, err = suite.a.ClaimOrder(context.TODO(), "b", 1, statuses, tenant.Filter{}, time.Minute)
+	suite.NoError(err)
+	suite.Nil(o)
+	// заказ уже обработан
+	o, err = suite.a.ClaimOrder(context.TODO(), "b", 4, statuses, tenant.Filter{}, time.Minute)
+	suite.NoError(err)
+	suite.Nil(o)
+}
+
+func (suite *ordersTestSuite) TestListenNewOrders() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ids := make(chan order.ID, 1)
+	done := make(chan error)
+	go func() {
+		done <- suite.a.ListenNewOrders(ctx, func(id order.ID) {
+			ids <- id
+		})
+	}()
+	// ожидаем подписки на канал
+	time.Sleep(100 * time.Millisecond)
+	newOrder, err := suite.a.NewOrder(context.TODO(), order.Order{Number: "999", UserID: user.ID(2)})
+	suite.NoError(err)
+	select {
+	case id := <-ids:
+		suite.Equal(newOrder.ID, id)
+	case <-time.After(time.Second):
+		suite.Fail("notification not received")
+	}
+	cancel()
+	suite.Error(<-done)
+}
+
+func (suite *ordersTestSuite) TestOrderEvents() {
+	newOrder, err := suite.a.NewOrder(context.TODO(), order.Order{Number: "500", UserID: user.ID(1)})
+	suite.NoError(err)
+	newOrder.Status = order.StatusProcessing
+	newOrder.Provider = "default"
+	newOrder.Payload = []byte(`{"order":"500","status":"PROCESSING"}`)
+	suite.NoError(suite.a.UpdateOrder(context.TODO(), "", *newOrder, order.SourcePoller))
+	var v float32 = 120.0
+	newOrder.Status = order.StatusProcessed
+	newOrder.Accrual = &v
+	newOrder.Payload = nil
+	suite.NoError(suite.a.UpdateOrder(context.TODO(), "", *newOrder, order.SourceAdmin))
+
+	events, err := suite.a.GetOrderEvents(context.TODO(), newOrder.ID)
+	suite.NoError(err)
+	suite.Len(events, 3)
+	suite.Equal(order.SourceUpload, events[0].Source)
+	suite.Equal(order.StatusNew, events[0].Status)
+	suite.Equal(order.SourcePoller, events[1].Source)
+	suite.Equal(order.StatusProcessing, events[1].Status)
+	suite.Equal("default", events[1].Provider)
+	suite.JSONEq(`{"order":"500","status":"PROCESSING"}`, string(events[1].Payload))
+	suite.Equal(order.SourceAdmin, events[2].Source)
+	suite.Equal(&v, events[2].Accrual)
+	suite.Nil(events[2].Payload)
+}
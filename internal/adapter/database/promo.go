@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/k1nky/gophermart/internal/entity/admin"
+	"github.com/k1nky/gophermart/internal/entity/promo"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+	"github.com/k1nky/gophermart/internal/entity/transaction"
+	"github.com/k1nky/gophermart/internal/entity/user"
+)
+
+// Создает новый промокод программы лояльности tenantID и возвращает его. Код промокода уникален в рамках программы.
+// Действие записывается в журнал действий администраторов от имени r.Admin с причиной r.Reason.
+func (a *Adapter) NewPromo(ctx context.Context, tenantID tenant.ID, p promo.Promo, r admin.AuditRecord) (*promo.Promo, error) {
+	tx, err := a.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	defer tx.Rollback()
+
+	const query = `
+		INSERT INTO promo_codes AS p (code, amount, max_redemptions, valid_from, valid_until, tenant_id)
+		VALUES ($1, $2, $3, COALESCE($4, NOW()), $5, $6)
+		RETURNING p.promo_id, p.valid_from
+	`
+	// начало действия промокода по умолчанию - момент создания
+	var validFrom interface{}
+	if !p.ValidFrom.IsZero() {
+		validFrom = p.ValidFrom
+	}
+	row := tx.QueryRowContext(ctx, query, p.Code, p.Amount, p.MaxRedemptions, validFrom, p.ValidUntil, tenantID)
+	if err := row.Err(); err != nil {
+		if a.hasUniqueViolationError(err) {
+			return nil, fmt.Errorf("%s %w", p.Code, promo.ErrDuplicated)
+		}
+		return nil, NewExecutingQueryError(err)
+	}
+	if err := row.Scan(&p.ID, &p.ValidFrom); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+
+	details, err := json.Marshal(map[string]interface{}{
+		"tenant": tenantID,
+		"promo":  p,
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.Action = admin.ActionNewPromo
+	r.Details = details
+	if err := a.newAuditRecord(ctx, tx, r); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	return &p, nil
+}
+
+// Погашает промокод программы лояльности пользователя и начисляет баллы на счет пользователя.
+// Промокоды других программ считаются ненайденными.
+func (a *Adapter) RedeemPromo(ctx context.Context, userID user.ID, code promo.Code) (*promo.Redemption, error) {
+	tx, err := a.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	defer tx.Rollback()
+
+	// увеличиваем счетчик погашений, если промокод действует и лимит не исчерпан.
+	// Строка промокода блокируется до конца транзакции, поэтому конкурентные погашения
+	// выполняются последовательно и условие проверяется повторно для каждого из них.
+	const redeemQuery = `
+		UPDATE promo_codes
+		SET redemptions = redemptions + 1
+		WHERE code = $1 AND tenant_id = (SELECT tenant_id FROM users WHERE user_id = $2)
+			AND redemptions < max_redemptions
+			AND valid_from <= NOW() AND (valid_until IS NULL OR valid_until > NOW())
+		RETURNING promo_id, amount
+	`
+	var promoID promo.ID
+	r := &promo.Redemption{
+		Code:   code,
+		UserID: userID,
+	}
+	if err := tx.QueryRowContext(ctx, redeemQuery, code, userID).Scan(&promoID, &r.Amount); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, a.getPromoUnavailableReason(ctx, tx, userID, code)
+		}
+		return nil, NewExecutingQueryError(err)
+	}
+
+	const newRedemptionQuery = `
+		INSERT INTO promo_redemptions (promo_id, user_id, amount)
+		VALUES ($1, $2, $3)
+		RETURNING redemption_id, redeemed_at
+	`
+	row := tx.QueryRowContext(ctx, newRedemptionQuery, promoID, userID, r.Amount)
+	if err := row.Err(); err != nil {
+		if a.hasUniqueViolationError(err) {
+			return nil, fmt.Errorf("%s %w", code, promo.ErrAlreadyRedeemed)
+		}
+		return nil, NewExecutingQueryError(err)
+	}
+	if err := row.Scan(&r.ID, &r.RedeemedAt); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+
+	// добавляем транзакцию на начисление баллов по промокоду
+	if _, err := a.newTransaction(ctx, tx, userID, uint64(r.ID), transaction.SourcePromo, r.Amount); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	return r, nil
+}
+
+// Возвращает причину, по которой промокод не может быть погашен пользователем userID
+func (a *Adapter) getPromoUnavailableReason(ctx context.Context, tx *sql.Tx, userID user.ID, code promo.Code) error {
+	const query = `
+		SELECT valid_from <= NOW() AND (valid_until IS NULL OR valid_until > NOW())
+		FROM promo_codes WHERE code = $1 AND tenant_id = (SELECT tenant_id FROM users WHERE user_id = $2)
+	`
+	var isActive bool
+	if err := tx.QueryRowContext(ctx, query, code, userID).Scan(&isActive); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s %w", code, promo.ErrNotFound)
+		}
+		return NewExecutingQueryError(err)
+	}
+	if !isActive {
+		return fmt.Errorf("%s %w", code, promo.ErrNotActive)
+	}
+	return fmt.Errorf("%s %w", code, promo.ErrExhausted)
+}
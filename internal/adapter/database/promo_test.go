@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"github.com/k1nky/gophermart/internal/entity/admin"
+	"github.com/k1nky/gophermart/internal/entity/promo"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+	"github.com/k1nky/gophermart/internal/entity/user"
+	"github.com/stretchr/testify/suite"
+)
+
+type promoTestSuite struct {
+	suite.Suite
+	a *Adapter
+}
+
+func (suite *promoTestSuite) SetupTest() {
+	if shouldSkipDBTest(suite.T()) {
+		return
+	}
+	var err error
+	if suite.a, err = openTestDB(); err != nil {
+		suite.FailNow(err.Error())
+		return
+	}
+	if _, err := suite.a.Exec(`
+		DELETE FROM transactions CASCADE;
+		DELETE FROM admin_audit;
+		DELETE FROM promo_redemptions CASCADE;
+		DELETE FROM promo_codes CASCADE;
+		DELETE FROM users CASCADE;
+
+		INSERT INTO users(user_id, login, password) 
+			VALUES (1, 'u1', 'p1'), 
+					(2, 'u2', 'p2'),
+					(3, 'u3', 'p3');
+		INSERT INTO promo_codes(promo_id, code, amount, max_redemptions, valid_from, valid_until)
+			VALUES (1, 'SINGLE', 100, 1, NOW() - INTERVAL '1 day', NULL),
+					(2, 'MULTI', 50, 2, NOW() - INTERVAL '1 day', NOW() + INTERVAL '1 day'),
+					(3, 'EXPIRED', 50, 10, NOW() - INTERVAL '2 day', NOW() - INTERVAL '1 day'),
+					(4, 'FUTURE', 50, 10, NOW() + INTERVAL '1 day', NULL);
+	`); err != nil {
+		suite.FailNow(err.Error())
+	}
+}
+
+func (suite *promoTestSuite) TestNewPromo() {
+	p := promo.Promo{
+		Code:           "NEW",
+		Amount:         10,
+		MaxRedemptions: 5,
+	}
+	r := admin.AuditRecord{Admin: "support", Reason: "black friday"}
+	got, err := suite.a.NewPromo(context.TODO(), tenant.Default, p, r)
+	suite.NoError(err)
+	suite.NotEqual(0, got.ID)
+	suite.False(got.ValidFrom.IsZero())
+	var audit int
+	suite.NoError(suite.a.QueryRow(`SELECT COUNT(*) FROM admin_audit WHERE action = 'NEW_PROMO' AND admin = 'support'`).Scan(&audit))
+	suite.Equal(1, audit)
+}
+
+func (suite *promoTestSuite) TestNewPromoDuplicate() {
+	p := promo.Promo{
+		Code:           "SINGLE",
+		Amount:         10,
+		MaxRedemptions: 1,
+	}
+	got, err := suite.a.NewPromo(context.TODO(), tenant.Default, p, admin.AuditRecord{Admin: "support", Reason: "duplicate"})
+	suite.ErrorIs(err, promo.ErrDuplicated)
+	suite.Nil(got)
+	var audit int
+	suite.NoError(suite.a.QueryRow(`SELECT COUNT(*) FROM admin_audit`).Scan(&audit))
+	suite.Equal(0, audit)
+}
+
+func (suite *promoTestSuite) TestNewPromoTenant() {
+	p := promo.Promo{
+		Code:           "SINGLE",
+		Amount:         10,
+		MaxRedemptions: 1,
+	}
+	// код уникален в рамках программы лояльности
+	got, err := suite.a.NewPromo(context.TODO(), "bork", p, admin.AuditRecord{Admin: "support", Reason: "bork"})
+	suite.NoError(err)
+	suite.Require().NotNil(got)
+	_, err = suite.a.Exec(`UPDATE users SET tenant_id = 'bork' WHERE user_id = 2`)
+	suite.Require().NoError(err)
+	// пользователь погашает промокод только своей программы
+	redeemed, err := suite.a.RedeemPromo(context.TODO(), user.ID(2), "SINGLE")
+	suite.NoError(err)
+	suite.Equal(float32(10), redeemed.Amount)
+	_, err = suite.a.RedeemPromo(context.TODO(), user.ID(2), "MULTI")
+	suite.ErrorIs(err, promo.ErrNotFound)
+	redeemed, err = suite.a.RedeemPromo(context.TODO(), user.ID(1), "SINGLE")
+	suite.NoError(err)
+	suite.Equal(float32(100), redeemed.Amount)
+}
+
+func (suite *promoTestSuite) TestRedeemPromo() {
+	got, err := suite.a.RedeemPromo(context.TODO(), user.ID(1), "SINGLE")
+	suite.NoError(err)
+	suite.Equal(float32(100), got.Amount)
+	balance, err := suite.a.GetBalanceByUser(context.TODO(), user.ID(1))
+	suite.NoError(err)
+	suite.Equal(float32(100), balance.Current)
+}
+
+func (suite *promoTestSuite) TestRedeemPromoTwice() {
+	_, err := suite.a.RedeemPromo(context.TODO(), user.ID(1), "MULTI")
+	suite.NoError(err)
+	_, err = suite.a.RedeemPromo(context.TODO(), user.ID(1), "MULTI")
+	suite.ErrorIs(err, promo.ErrAlreadyRedeemed)
+}
+
+func (suite *promoTestSuite) TestRedeemPromoExhausted() {
+	_, err := suite.a.RedeemPromo(context.TODO(), user.ID(1), "SINGLE")
+	suite.NoError(err)
+	_, err = suite.a.RedeemPromo(context.TODO(), user.ID(2), "SINGLE")
+	suite.ErrorIs(err, promo.ErrExhausted)
+}
+
+func (suite *promoTestSuite) TestRedeemPromoNotActive() {
+	_, err := suite.a.RedeemPromo(context.TODO(), user.ID(1), "EXPIRED")
+	suite.ErrorIs(err, promo.ErrNotActive)
+	_, err = suite.a.RedeemPromo(context.TODO(), user.ID(1), "FUTURE")
+	suite.ErrorIs(err, promo.ErrNotActive)
+}
+
+func (suite *promoTestSuite) TestRedeemPromoNotFound() {
+	_, err := suite.a.RedeemPromo(context.TODO(), user.ID(1), "UNKNOWN")
+	suite.ErrorIs(err, promo.ErrNotFound)
+}
+
+func (suite *promoTestSuite) TestRedeemPromoConcurrent() {
+	wg := sync.WaitGroup{}
+	redeemed := make(chan struct{}, 3)
+	for _, id := range []user.ID{1, 2, 3} {
+		wg.Add(1)
+		go func(id user.ID) {
+			defer wg.Done()
+			if _, err := suite.a.RedeemPromo(context.TODO(), id, "MULTI"); err == nil {
+				redeemed <- struct{}{}
+			}
+		}(id)
+	}
+	wg.Wait()
+	close(redeemed)
+	suite.Equal(2, len(redeemed))
+}
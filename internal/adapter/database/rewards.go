@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/k1nky/gophermart/internal/entity/admin"
+	"github.com/k1nky/gophermart/internal/entity/reward"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+)
+
+// Столбцы правила в порядке сканирования scanRewardRule
+const rewardRuleColumns = `rule_id, name, COALESCE(merchant, ''), COALESCE(match, ''), reward, reward_type,
+	COALESCE(max_reward, 0), valid_from, valid_until, created_at`
+
+// Читает правило из строки со столбцами rewardRuleColumns
+func scanRewardRule(row scanner) (*reward.Rule, error) {
+	r := &reward.Rule{}
+	err := row.Scan(&r.ID, &r.Name, &r.Merchant, &r.Match, &r.Reward, &r.RewardType,
+		&r.MaxReward, &r.ValidFrom, &r.ValidUntil, &r.CreatedAt)
+	return r, err
+}
+
+// Возвращает все правила расчёта вознаграждений программы лояльности tenantID в порядке их создания
+func (a *Adapter) GetRewardRules(ctx context.Context, tenantID tenant.ID) ([]*reward.Rule, error) {
+	query := fmt.Sprintf(`SELECT %s FROM reward_rules WHERE tenant_id = $1 ORDER BY rule_id`, rewardRuleColumns)
+	rules := make([]*reward.Rule, 0)
+	rows, err := a.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		rule, err := scanRewardRule(rows)
+		if err != nil {
+			return nil, NewExecutingQueryError(err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	return rules, nil
+}
+
+// Создает правило расчёта вознаграждений программы лояльности tenantID и возвращает его.
+// Действие записывается в журнал действий администраторов от имени r.Admin с причиной r.Reason.
+func (a *Adapter) NewRewardRule(ctx context.Context, tenantID tenant.ID, rule reward.Rule, r admin.AuditRecord) (*reward.Rule, error) {
+	tx, err := a.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`
+		INSERT INTO reward_rules (name, merchant, match, reward, reward_type, max_reward, valid_from, valid_until, tenant_id)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5, NULLIF($6, 0), $7, $8, $9)
+		RETURNING %s
+	`, rewardRuleColumns)
+	row := tx.QueryRowContext(ctx, query, rule.Name, rule.Merchant, rule.Match, rule.Reward, rule.RewardType,
+		rule.MaxReward, rule.ValidFrom, rule.ValidUntil, tenantID)
+	created, err := scanRewardRule(row)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	r.Action = admin.ActionNewRewardRule
+	if err := a.commitRewardRule(ctx, tx, tenantID, nil, created, r); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Изменяет правило расчёта вознаграждений программы лояльности tenantID с идентификатором rule.ID и
+// возвращает его после изменения. Правило другой программы не изменяется и считается ненайденным.
+// Действие записывается в журнал действий администраторов от имени r.Admin с причиной r.Reason.
+func (a *Adapter) UpdateRewardRule(ctx context.Context, tenantID tenant.ID, rule reward.Rule, r admin.AuditRecord) (*reward.Rule, error) {
+	tx, err := a.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`SELECT %s FROM reward_rules WHERE rule_id = $1 AND tenant_id = $2 FOR UPDATE`, rewardRuleColumns)
+	prev, err := scanRewardRule(tx.QueryRowContext(ctx, query, rule.ID, tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%d %w", rule.ID, reward.ErrNotFound)
+		}
+		return nil, NewExecutingQueryError(err)
+	}
+	query = fmt.Sprintf(`
+		UPDATE reward_rules
+		SET name = $2, merchant = NULLIF($3, ''), match = NULLIF($4, ''), reward = $5, reward_type = $6,
+			max_reward = NULLIF($7, 0), valid_from = $8, valid_until = $9
+		WHERE rule_id = $1
+		RETURNING %s
+	`, rewardRuleColumns)
+	row := tx.QueryRowContext(ctx, query, rule.ID, rule.Name, rule.Merchant, rule.Match, rule.Reward, rule.RewardType,
+		rule.MaxReward, rule.ValidFrom, rule.ValidUntil)
+	updated, err := scanRewardRule(row)
+	if err != nil {
+		return nil, NewExecutingQueryError(err)
+	}
+	r.Action = admin.ActionUpdateRewardRule
+	if err := a.commitRewardRule(ctx, tx, tenantID, prev, updated, r); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Удаляет правило расчёта вознаграждений программы лояльности tenantID с идентификатором id.
+// Действие записывается в журнал действий администраторов от имени r.Admin с причиной r.Reason.
+func (a *Adapter) DeleteRewardRule(ctx context.Context, tenantID tenant.ID, id reward.ID, r admin.AuditRecord) error {
+	tx, err := a.BeginTx(ctx, nil)
+	if err != nil {
+		return NewExecutingQueryError(err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`DELETE FROM reward_rules WHERE rule_id = $1 AND tenant_id = $2 RETURNING %s`, rewardRuleColumns)
+	prev, err := scanRewardRule(tx.QueryRowContext(ctx, query, id, tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%d %w", id, reward.ErrNotFound)
+		}
+		return NewExecutingQueryError(err)
+	}
+	r.Action = admin.ActionDeleteRewardRule
+	return a.commitRewardRule(ctx, tx, tenantID, prev, nil, r)
+}
+
+// Записывает изменение правила prev на rule программы лояльности tenantID в журнал действий администраторов и фиксирует транзакцию tx.
+// prev равен nil для нового правила, rule равен nil для удаленного правила.
+func (a *Adapter) commitRewardRule(ctx context.Context, tx *sql.Tx, tenantID tenant.ID, prev *reward.Rule, rule *reward.Rule, r admin.AuditRecord) error {
+	details, err := json.Marshal(map[string]interface{}{
+		"tenant":   tenantID,
+		"previous": prev,
+		"rule":     rule,
+	})
+	if err != nil {
+		return err
+	}
+	r.Details = details
+	if err := a.newAuditRecord(ctx, tx, r); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/statement"
+	"github.com/k1nky/gophermart/internal/entity/user"
+)
+
+// Последовательно передает в fn записи выписки пользователя за период [from, to) в порядке возрастания времени.
+// Записи читаются из базы по мере обработки и не накапливаются в памяти.
+// Обработка прерывается, если fn вернула ошибку.
+func (a *Adapter) WalkStatement(ctx context.Context, userID user.ID, from time.Time, to time.Time, fn func(e *statement.Entry) error) error {
+	// изменение баланса по транзакции вычисляется по всей цепочке транзакций пользователя,
+	// поэтому ограничение по периоду применяется после объединения
+	const query = `
+		SELECT kind, reference, status, amount, balance, at FROM (
+			SELECT 'ORDER' kind, number reference, status::text status, accrual amount, NULL::real balance, uploaded_at at
+			FROM orders WHERE user_id = $1
+			UNION ALL
+			SELECT 'WITHDRAWAL', order_number, '', amount, NULL, processed_at
+			FROM withdrawals WHERE user_id = $1 AND processed_at IS NOT NULL
+			UNION ALL
+			SELECT 'TRANSACTION', source_type::text, '',
+				balance - COALESCE(LAG(balance) OVER (ORDER BY user_transaction_seq), 0), balance, created_at
+			FROM transactions WHERE user_id = $1
+		) s
+		WHERE at >= $2 AND at < $3
+		ORDER BY at
+	`
+	// время в базе хранится без часового пояса в UTC
+	rows, err := a.QueryContext(ctx, query, userID, from.UTC(), to.UTC())
+	if err != nil {
+		return NewExecutingQueryError(err)
+	}
+	defer rows.Close()
+	e := &statement.Entry{}
+	for rows.Next() {
+		if err := rows.Scan(&e.Kind, &e.Reference, &e.Status, &e.Amount, &e.Balance, &e.Time); err != nil {
+			return NewExecutingQueryError(err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/k1nky/gophermart/internal/entity/transaction"
+	"github.com/k1nky/gophermart/internal/entity/user"
+)
+
+// Добавляет транзакцию пользователя в рамках транзакции tx и возвращает баланс после ее проведения.
+// Для новой транзакции последовательный номер транзакции пользователя увеличивается на 1, а баланс на amount.
+func (a *Adapter) newTransaction(ctx context.Context, tx *sql.Tx, userID user.ID, sourceID uint64, sourceType transaction.SourceType, amount float32) (float32, error) {
+	var withdrawn float32
+	if sourceType == transaction.SourceWithdraw {
+		withdrawn = -amount
+	}
+	// обновляем текущий баланс пользователя, при этом строка баланса блокируется до конца транзакции,
+	// поэтому транзакции одного пользователя проводятся последовательно
+	const query = `
+		WITH b AS (
+			INSERT INTO balances AS b (user_id, current, withdrawn, last_seq)
+			VALUES ($1, $4, $5, 1)
+			ON CONFLICT (user_id) DO UPDATE
+			SET current = b.current + $4, withdrawn = b.withdrawn + $5, last_seq = b.last_seq + 1
+			RETURNING b.last_seq, b.current
+		)
+		INSERT INTO transactions(
+			user_id,
+			tenant_id,
+			user_transaction_seq,
+			source_id, source_type,
+			balance
+		)
+		SELECT $1::int, u.tenant_id, b.last_seq, $2::int, $3::transaction_type, b.current
+		FROM b, users AS u WHERE u.user_id = $1
+		RETURNING balance
+	`
+	var balance float32
+	row := tx.QueryRowContext(ctx, query, userID, sourceID, sourceType, amount, withdrawn)
+	if err := row.Err(); err != nil {
+		return 0, err
+	}
+	if err := row.Scan(&balance); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// Возвращает идентификаторы всех пользователей в порядке возрастания
+func (a *Adapter) GetUserIDs(ctx context.Context) ([]user.ID, error) {
+	ids := make([]user.ID, 0)
+	rows, err := a.QueryContext(ctx, `SELECT user_id FROM users ORDER BY user_id`)
+	if err != nil {
+		return ids, NewExecutingQueryError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id user.ID
+		if err := rows.Scan(&id); err != nil {
+			return ids, NewExecutingQueryError(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return ids, NewExecutingQueryError(err)
+	}
+	return ids, nil
+}
+
+// Возвращает транзакции пользователя в порядке последовательных номеров.
+// Для каждой транзакции определяется изменение баланса по данным ее источника: начисление по обработанному заказу,
+// сумма проведенного списания, погашенного промокода или корректировки того же пользователя.
+// Транзакция ACCRUAL проводит первое начисление по заказу, а последующие изменения начисления проводятся
+// корректировками заказа, поэтому изменение по транзакции ACCRUAL - текущее начисление за вычетом корректировок заказа.
+func (a *Adapter) GetTransactionsByUserID(ctx context.Context, userID user.ID) ([]*transaction.Transaction, error) {
+	const query = `
+		SELECT
+			t.transaction_id, t.user_id, t.user_transaction_seq, t.source_id, t.source_type, t.balance, t.created_at,
+			CASE t.source_type
+				WHEN 'ACCRUAL' THEN (
+					SELECT CASE WHEN o.status = 'PROCESSED' THEN COALESCE(o.accrual, 0) ELSE 0 END - adj.amount
+					FROM orders o, LATERAL (
+						SELECT COALESCE(SUM(a.amount), 0) amount, COUNT(*) n FROM adjustments a WHERE a.order_id = o.order_id
+					) adj
+					WHERE o.order_id = t.source_id AND o.user_id = t.user_id AND (o.status = 'PROCESSED' OR adj.n > 0)
+				)
+				WHEN 'WITHDRAW' THEN (
+					SELECT -w.amount FROM withdrawals w
+					WHERE w.withdraw_id = t.source_id AND w.user_id = t.user_id AND w.processed_at IS NOT NULL
+				)
+				WHEN 'PROMO' THEN (
+					SELECT r.amount FROM promo_redemptions r
+					WHERE r.redemption_id = t.source_id AND r.user_id = t.user_id
+				)
+				WHEN 'ADJUSTMENT' THEN (
+					SELECT a.amount FROM adjustments a
+					WHERE a.adjustment_id = t.source_id AND a.user_id = t.user_id
+				)
+			END
+		FROM transactions t
+		WHERE t.user_id = $1
+		ORDER BY t.user_transaction_seq
+	`
+	transactions := make([]*transaction.Transaction, 0)
+	rows, err := a.QueryContext(ctx, query, userID)
+	if err != nil {
+		return transactions, NewExecutingQueryError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		t := &transaction.Transaction{}
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Seq, &t.SourceID, &t.SourceType, &t.Balance, &t.CreatedAt, &t.Amount); err != nil {
+			return transactions, NewExecutingQueryError(err)
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return transactions, NewExecutingQueryError(err)
+	}
+	return transactions, nil
+}
+
+// Возвращает источники транзакций пользователя, для которых не проведена транзакция, в порядке возрастания времени.
+// Последовательный номер и баланс у возвращаемых транзакций не заполнены.
+func (a *Adapter) GetUnrecordedTransactionsByUserID(ctx context.Context, userID user.ID) ([]*transaction.Transaction, error) {
+	const query = `
+		SELECT s.source_id, s.source_type, s.amount, s.created_at FROM (
+			SELECT o.order_id source_id, 'ACCRUAL'::transaction_type source_type, o.accrual amount, COALESCE(o.uploaded_at, NOW()) created_at
+			FROM orders o WHERE o.user_id = $1 AND o.status = 'PROCESSED' AND o.accrual IS NOT NULL
+			UNION ALL
+			SELECT w.withdraw_id, 'WITHDRAW', -w.amount, w.processed_at
+			FROM withdrawals w WHERE w.user_id = $1 AND w.processed_at IS NOT NULL
+			UNION ALL
+			SELECT r.redemption_id, 'PROMO', r.amount, r.redeemed_at
+			FROM promo_redemptions r WHERE r.user_id = $1
+			UNION ALL
+			SELECT a.adjustment_id, 'ADJUSTMENT', a.amount, a.created_at
+			FROM adjustments a WHERE a.user_id = $1
+		) s
+		WHERE NOT EXISTS (
+			SELECT 1 FROM transactions t WHERE t.source_id = s.source_id AND t.source_type = s.source_type
+		)
+		ORDER BY s.created_at
+	`
+	transactions := make([]*transaction.Transaction, 0)
+	rows, err := a.QueryContext(ctx, query, userID)
+	if err != nil {
+		return transactions, NewExecutingQueryError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		t := &transaction.Transaction{
+			UserID: userID,
+		}
+		if err := rows.Scan(&t.SourceID, &t.SourceType, &t.Amount, &t.CreatedAt); err != nil {
+			return transactions, NewExecutingQueryError(err)
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return transactions, NewExecutingQueryError(err)
+	}
+	return transactions, nil
+}
+
+// Заменяет все транзакции пользователя на transactions и пересчитывает текущий баланс пользователя.
+// Если последовательный номер последней транзакции пользователя отличается от lastSeq, то транзакции
+// были изменены после чтения и замена не выполняется.
+func (a *Adapter) ReplaceTransactions(ctx context.Context, userID user.ID, lastSeq uint64, transactions []*transaction.Transaction) error {
+	tx, err := a.BeginTx(ctx, nil)
+	if err != nil {
+		return NewExecutingQueryError(err)
+	}
+	defer tx.Rollback()
+
+	// блокируем баланс пользователя, чтобы новые транзакции не проводились до окончания замены
+	const lockQuery = `
+		INSERT INTO balances AS b (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE SET last_seq = b.last_seq
+	`
+	if _, err := tx.ExecContext(ctx, lockQuery, userID); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	var seq uint64
+	row := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(user_transaction_seq), 0) FROM transactions WHERE user_id = $1`, userID)
+	if err := row.Scan(&seq); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	if seq != lastSeq {
+		return fmt.Errorf("user %d: %w", userID, transaction.ErrConcurrentUpdate)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM transactions WHERE user_id = $1`, userID); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	const insertQuery = `
+		INSERT INTO transactions(user_id, tenant_id, user_transaction_seq, source_id, source_type, balance, created_at)
+		VALUES ($1, (SELECT tenant_id FROM users WHERE user_id = $1), $2, $3, $4, $5, $6)
+	`
+	for _, t := range transactions {
+		if _, err := tx.ExecContext(ctx, insertQuery, userID, t.Seq, t.SourceID, t.SourceType, t.Balance, t.CreatedAt); err != nil {
+			return NewExecutingQueryError(err)
+		}
+	}
+	const balanceQuery = `
+		UPDATE balances SET
+			current = COALESCE((
+				SELECT balance FROM transactions WHERE user_id = $1 ORDER BY user_transaction_seq DESC LIMIT 1
+			), 0),
+			withdrawn = COALESCE((
+				SELECT SUM(w.amount) FROM transactions t JOIN withdrawals w ON w.withdraw_id = t.source_id
+				WHERE t.user_id = $1 AND t.source_type = 'WITHDRAW'
+			), 0),
+			last_seq = COALESCE((
+				SELECT MAX(user_transaction_seq) FROM transactions WHERE user_id = $1
+			), 0)
+		WHERE user_id = $1
+	`
+	if _, err := tx.ExecContext(ctx, balanceQuery, userID); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	if err = tx.Commit(); err != nil {
+		return NewExecutingQueryError(err)
+	}
+	return nil
+}
@@ -5,17 +5,19 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/k1nky/gophermart/internal/entity/tenant"
 	"github.com/k1nky/gophermart/internal/entity/user"
 )
 
-// Возвращает пользователя с указанным логином. Nil - пользователь не найден
-func (a *Adapter) GetUserByLogin(ctx context.Context, login string) (*user.User, error) {
+// Возвращает пользователя программы лояльности tenantID с указанным логином. Nil - пользователь не найден
+func (a *Adapter) GetUserByLogin(ctx context.Context, tenantID tenant.ID, login string) (*user.User, error) {
 	u := &user.User{
-		Login: login,
+		Login:    login,
+		TenantID: tenantID,
 	}
 
-	const query = `SELECT user_id, password FROM users WHERE login=$1`
-	row := a.QueryRowContext(ctx, query, login)
+	const query = `SELECT user_id, password FROM users WHERE tenant_id=$1 AND login=$2`
+	row := a.QueryRowContext(ctx, query, tenantID, login)
 	if err := row.Err(); err != nil {
 		return nil, NewExecutingQueryError(err)
 	}
@@ -28,16 +30,18 @@ func (a *Adapter) GetUserByLogin(ctx context.Context, login string) (*user.User,
 	return u, nil
 }
 
-// Добавляет и возвращает нового пользователя
+// Добавляет и возвращает нового пользователя. Пользователь без программы лояльности добавляется
+// в программу по умолчанию.
 func (a *Adapter) NewUser(ctx context.Context, u user.User) (*user.User, error) {
 
 	const query = `
-		INSERT INTO users AS u (login, password)
-		VALUES ($1, $2)
+		INSERT INTO users AS u (tenant_id, login, password)
+		VALUES ($1, $2, $3)
 		RETURNING u.user_id
 	`
 
-	row := a.QueryRowContext(ctx, query, u.Login, u.Password)
+	u.TenantID = u.TenantID.OrDefault()
+	row := a.QueryRowContext(ctx, query, u.TenantID, u.Login, u.Password)
 	if err := row.Err(); err != nil {
 		if a.hasUniqueViolationError(err) {
 			return nil, fmt.Errorf("%s %w", u.Login, user.ErrDuplicateLogin)
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 
+	"github.com/k1nky/gophermart/internal/entity/tenant"
 	"github.com/k1nky/gophermart/internal/entity/user"
 	"github.com/stretchr/testify/suite"
 )
@@ -60,14 +61,36 @@ func (suite *usersTestSuite) TestGetUserByLogin() {
 		ID:       1,
 		Login:    "u1",
 		Password: "p1",
+		TenantID: tenant.Default,
 	}
-	got, err := suite.a.GetUserByLogin(context.TODO(), "u1")
+	got, err := suite.a.GetUserByLogin(context.TODO(), tenant.Default, "u1")
 	suite.NoError(err)
 	suite.Equal(u, got)
 }
 
 func (suite *usersTestSuite) TestGetUserByLoginNotExists() {
-	got, err := suite.a.GetUserByLogin(context.TODO(), "u1000")
+	got, err := suite.a.GetUserByLogin(context.TODO(), tenant.Default, "u1000")
+	suite.NoError(err)
+	suite.Nil(got)
+}
+
+func (suite *usersTestSuite) TestSameLoginInTenants() {
+	u := user.User{
+		Login:    "u1",
+		Password: "p3",
+		TenantID: "bork",
+	}
+	newUser, err := suite.a.NewUser(context.TODO(), u)
+	suite.NoError(err)
+	suite.NotEqual(user.ID(1), newUser.ID)
+
+	got, err := suite.a.GetUserByLogin(context.TODO(), "bork", "u1")
+	suite.NoError(err)
+	suite.Equal(newUser.ID, got.ID)
+	got, err = suite.a.GetUserByLogin(context.TODO(), tenant.Default, "u1")
+	suite.NoError(err)
+	suite.Equal(user.ID(1), got.ID)
+	got, err = suite.a.GetUserByLogin(context.TODO(), "acme", "u1")
 	suite.NoError(err)
 	suite.Nil(got)
 }
@@ -2,9 +2,13 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/transaction"
 	"github.com/k1nky/gophermart/internal/entity/user"
 	"github.com/k1nky/gophermart/internal/entity/withdraw"
 )
@@ -46,25 +50,44 @@ func (a *Adapter) GetWithdrawalsByUserID(ctx context.Context, userID user.ID, ma
 // Возврашает баланс указанного пользователя
 func (a *Adapter) GetBalanceByUser(ctx context.Context, userID user.ID) (user.Balance, error) {
 	balance := user.Balance{}
-	// получаем баланс из последней транзакции пользователя
-	const query = `
-		SELECT COALESCE(SUM(balance), 0) 
-		FROM transactions 
-		WHERE transaction_id=(SELECT MAX(transaction_id) FROM transactions WHERE user_id=$1)
-	`
+	const query = `SELECT current, withdrawn FROM balances WHERE user_id = $1`
 	row := a.QueryRowContext(ctx, query, userID)
 	if err := row.Err(); err != nil {
 		return balance, NewExecutingQueryError(err)
 	}
-	if err := row.Scan(&balance.Current); err != nil {
+	if err := row.Scan(&balance.Current, &balance.Withdrawn); err != nil {
+		// у пользователя еще не было транзакций
+		if errors.Is(err, sql.ErrNoRows) {
+			return balance, nil
+		}
 		return balance, NewExecutingQueryError(err)
 	}
-	// получаем сумму всех списаний
-	row = a.QueryRowContext(ctx, `SELECT COALESCE(SUM(amount),0) FROM withdrawals WHERE user_id=$1 AND processed_at IS NOT NULL`, userID)
+	return balance, nil
+}
+
+// Возвращает баланс указанного пользователя на момент времени at
+func (a *Adapter) GetBalanceByUserAt(ctx context.Context, userID user.ID, at time.Time) (user.Balance, error) {
+	balance := user.Balance{}
+	// баланс берем из последней транзакции пользователя, проведенной не позднее at,
+	// а сумму списаний из списаний, проведенных не позднее at
+	const query = `
+		SELECT
+			COALESCE((
+				SELECT balance FROM transactions
+				WHERE user_id = $1 AND created_at <= $2
+				ORDER BY user_transaction_seq DESC LIMIT 1
+			), 0),
+			COALESCE((
+				SELECT SUM(amount) FROM withdrawals
+				WHERE user_id = $1 AND processed_at <= $2
+			), 0)
+	`
+	// время в базе хранится без часового пояса в UTC
+	row := a.QueryRowContext(ctx, query, userID, at.UTC())
 	if err := row.Err(); err != nil {
 		return balance, NewExecutingQueryError(err)
 	}
-	if err := row.Scan(&balance.Withdrawn); err != nil {
+	if err := row.Scan(&balance.Current, &balance.Withdrawn); err != nil {
 		return balance, NewExecutingQueryError(err)
 	}
 	return balance, nil
@@ -80,11 +103,11 @@ func (a *Adapter) NewWithdraw(ctx context.Context, w withdraw.Withdraw) (*withdr
 
 	// создаем новое списание
 	const newWithdrawQuery = `
-		INSERT INTO withdrawals (user_id, amount, order_number, processed_at) 
-		VALUES($1, $2, $3, NOW())
+		INSERT INTO withdrawals (user_id, tenant_id, amount, order_number, processed_at)
+		VALUES($1, (SELECT tenant_id FROM users WHERE user_id = $1), $2, $3, NOW())
 		RETURNING withdraw_id, processed_at
 	`
-	row := a.QueryRowContext(ctx, newWithdrawQuery, w.UserID, w.Sum, w.Number)
+	row := tx.QueryRowContext(ctx, newWithdrawQuery, w.UserID, w.Sum, w.Number)
 	if err := row.Err(); err != nil {
 		if a.hasUniqueViolationError(err) {
 			return nil, fmt.Errorf("order %s %w", w.Number, order.ErrDuplicated)
@@ -96,32 +119,11 @@ func (a *Adapter) NewWithdraw(ctx context.Context, w withdraw.Withdraw) (*withdr
 	}
 
 	// добавляем новую транзакцию на списание
-	const newTransactionQuery = `
-		WITH user_balance AS (
-			SELECT COALESCE(SUM(user_transaction_seq), 0) seq, COALESCE(SUM(balance), 0) balance FROM transactions WHERE user_id = $1 ORDER BY seq DESC LIMIT 1
-		)
-		INSERT INTO transactions(
-			user_id,
-			user_transaction_seq,
-			source_id, source_type,
-			balance
-		) VALUES (
-			$1,
-			(SELECT seq FROM user_balance) + 1,
-			$2, 'WITHDRAW',
-			(SELECT balance FROM user_balance) - $3
-		)
-		RETURNING balance
-	`
-	row = tx.QueryRowContext(ctx, newTransactionQuery, w.UserID, w.ID, w.Sum)
-	if err := row.Err(); err != nil {
+	balance, err := a.newTransaction(ctx, tx, w.UserID, uint64(w.ID), transaction.SourceWithdraw, -w.Sum)
+	if err != nil {
 		return nil, NewExecutingQueryError(err)
 	}
 	// если в результате списание баланс отрицательный, то откатываем транзакцию
-	var balance float32
-	if err := row.Scan(&balance); err != nil {
-		return nil, NewExecutingQueryError(err)
-	}
 	if balance < 0 {
 		return nil, withdraw.ErrInsufficientBalance
 	}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/user"
+	"github.com/k1nky/gophermart/internal/entity/withdraw"
+	"github.com/stretchr/testify/suite"
+)
+
+type withdrawalsTestSuite struct {
+	suite.Suite
+	a *Adapter
+}
+
+func (suite *withdrawalsTestSuite) SetupTest() {
+	if shouldSkipDBTest(suite.T()) {
+		return
+	}
+	var err error
+	if suite.a, err = openTestDB(); err != nil {
+		suite.FailNow(err.Error())
+		return
+	}
+	if _, err := suite.a.Exec(`
+		DELETE FROM transactions CASCADE;
+		DELETE FROM withdrawals CASCADE;
+		DELETE FROM orders CASCADE;
+		DELETE FROM users CASCADE;
+
+		INSERT INTO users(user_id, login, password) 
+			VALUES (1, 'u1', 'p1'), 
+					(2, 'u2', 'p2');
+		INSERT INTO orders(order_id, user_id, number, status, accrual)
+			VALUES (1, 1, '100', 'PROCESSED', 100), (2, 1, '200', 'PROCESSED', 50);
+		INSERT INTO withdrawals(withdraw_id, user_id, amount, order_number, processed_at)
+			VALUES (1, 1, 30, '300', '2020-12-03 00:00:00');
+		INSERT INTO transactions(user_id, user_transaction_seq, source_id, source_type, balance, created_at)
+			VALUES (1, 1, 1, 'ACCRUAL', 100, '2020-12-01 00:00:00'),
+					(1, 2, 2, 'ACCRUAL', 150, '2020-12-02 00:00:00'),
+					(1, 3, 1, 'WITHDRAW', 120, '2020-12-03 00:00:00');
+		INSERT INTO balances(user_id, current, withdrawn, last_seq)
+			VALUES (1, 120, 30, 3);
+	`); err != nil {
+		suite.FailNow(err.Error())
+	}
+}
+
+func (suite *withdrawalsTestSuite) TestNewWithdraw() {
+	w := withdraw.Withdraw{
+		UserID: user.ID(1),
+		Number: "400",
+		Sum:    20,
+	}
+	got, err := suite.a.NewWithdraw(context.TODO(), w)
+	suite.NoError(err)
+	suite.NotEqual(0, got.ID)
+	balance, err := suite.a.GetBalanceByUser(context.TODO(), user.ID(1))
+	suite.NoError(err)
+	suite.Equal(user.Balance{Current: 100, Withdrawn: 50}, balance)
+}
+
+func (suite *withdrawalsTestSuite) TestNewWithdrawInsufficientBalance() {
+	w := withdraw.Withdraw{
+		UserID: user.ID(1),
+		Number: "400",
+		Sum:    200,
+	}
+	got, err := suite.a.NewWithdraw(context.TODO(), w)
+	suite.ErrorIs(err, withdraw.ErrInsufficientBalance)
+	suite.Nil(got)
+	balance, err := suite.a.GetBalanceByUser(context.TODO(), user.ID(1))
+	suite.NoError(err)
+	suite.Equal(user.Balance{Current: 120, Withdrawn: 30}, balance)
+}
+
+func (suite *withdrawalsTestSuite) TestGetBalanceByUserAt() {
+	tests := []struct {
+		name string
+		at   time.Time
+		want user.Balance
+	}{
+		{
+			name: "Before first transaction",
+			at:   time.Date(2020, 11, 30, 0, 0, 0, 0, time.UTC),
+			want: user.Balance{},
+		},
+		{
+			name: "After accruals",
+			at:   time.Date(2020, 12, 2, 12, 0, 0, 0, time.UTC),
+			want: user.Balance{Current: 150},
+		},
+		{
+			name: "After withdraw",
+			at:   time.Date(2020, 12, 3, 0, 0, 0, 0, time.UTC),
+			want: user.Balance{Current: 120, Withdrawn: 30},
+		},
+	}
+	for _, tt := range tests {
+		got, err := suite.a.GetBalanceByUserAt(context.TODO(), user.ID(1), tt.at)
+		suite.NoError(err, tt.name)
+		suite.Equal(tt.want, got, tt.name)
+	}
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/k1nky/gophermart/internal/entity/order"
+)
+
+type accrualCallbackRequest struct {
+	Order   order.OrderNumber `json:"order"`
+	Status  order.OrderStatus `json:"status"`
+	Accrual *float32          `json:"accrual,omitempty"`
+}
+
+// Уведомление от системы расчёта начислений об изменении расчёта по заказу.
+// Хендлер доступен только системе расчёта начислений: тело запроса должно быть подписано общим ключом,
+// подпись передается в заголовке `X-Accrual-Signature` в виде hex(HMAC-SHA256(ключ, тело запроса)).
+// Формат запроса:
+// ```
+// POST /api/internal/accrual/callback HTTP/1.1
+// Content-Type: application/json
+// X-Accrual-Signature: <signature>
+//
+//	{
+//		"order": "<number>",
+//		"status": "PROCESSED",
+//		"accrual": 500
+//	}
+//
+// ```
+// Поля запроса совпадают с ответом системы расчёта на `GET /api/orders/{number}`.
+// Возможные коды ответа:
+//   - `200` — уведомление принято, в том числе повторное;
+//   - `400` — неверный формат запроса или неизвестный статус;
+//   - `401` — неверная подпись запроса;
+//   - `404` — заказ не найден;
+//   - `409` — расчёт по заказу уже окончен с другим результатом;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) AccrualCallback(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	request := accrualCallbackRequest{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	if len(request.Order) == 0 {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	err = a.accrualService.ApplyAccrual(r.Context(), order.Order{
+		Number:  request.Order,
+		Status:  request.Status,
+		Accrual: request.Accrual,
+		Payload: body,
+	})
+	if err != nil {
+		if errors.Is(err, order.ErrUnknownStatus) {
+			http.Error(w, "", http.StatusBadRequest)
+		} else if errors.Is(err, order.ErrNotFound) {
+			http.Error(w, "", http.StatusNotFound)
+		} else if errors.Is(err, order.ErrAlreadyProcessed) {
+			http.Error(w, "", http.StatusConflict)
+		} else {
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,337 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/k1nky/gophermart/internal/entity/admin"
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/promo"
+	"github.com/k1nky/gophermart/internal/entity/reward"
+	"github.com/k1nky/gophermart/internal/entity/user"
+	"github.com/k1nky/gophermart/internal/entity/withdraw"
+)
+
+type requeueOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+type forceOrderStatusRequest struct {
+	Status  order.OrderStatus `json:"status"`
+	Accrual *float32          `json:"accrual,omitempty"`
+	Reason  string            `json:"reason"`
+}
+
+type newAdjustmentRequest struct {
+	Login  string  `json:"login"`
+	Amount float32 `json:"amount"`
+	Reason string  `json:"reason"`
+}
+
+// Повторная обработка заказа. Хендлер доступен только администраторам: запрос должен содержать токен администратора
+// в заголовке `X-Admin-Token` и имя администратора в заголовке `X-Admin-User`.
+// Заказ в любом статусе возвращается в очередь на проверку начислений со статусом `NEW`, уже проведенное
+// начисление по заказу списывается и будет проведено заново по результату проверки. Заказ ищется среди заказов
+// программы лояльности, указанной в заголовке `X-Tenant-ID`, или программы, к которой относится имя хоста.
+// Действие записывается в журнал действий администраторов и историю изменения статуса заказа.
+// Формат запроса:
+// ```
+// POST /api/admin/orders/{number}/requeue HTTP/1.1
+// Content-Type: application/json
+// X-Admin-Token: <token>
+// X-Admin-User: <name>
+//
+//	{
+//		"reason": "<причина>"
+//	}
+//
+// ```
+// Возможные коды ответа:
+//   - `200` — заказ возвращен в очередь, в ответе заказ после изменения;
+//   - `400` — неверный формат запроса или не указана причина;
+//   - `401` — неверный токен или не указано имя администратора;
+//   - `404` — заказ или программа лояльности не найдены;
+//   - `409` — после списания начисления баланс пользователя станет отрицательным;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) RequeueOrder(w http.ResponseWriter, r *http.Request) {
+	by, ok := r.Context().Value(keyAdmin).(string)
+	if !ok {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	request := requeueOrderRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	o, err := a.admin.RequeueOrder(r.Context(), requestTenant(r), order.OrderNumber(chi.URLParam(r, "number")), by, request.Reason)
+	if err != nil {
+		a.writeAdminError(w, err)
+		return
+	}
+	if err := a.writeJSON(w, o); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Принудительное изменение статуса заказа. Хендлер доступен только администраторам: запрос должен содержать токен
+// администратора в заголовке `X-Admin-Token` и имя администратора в заголовке `X-Admin-User`.
+// Статус изменяется независимо от текущего статуса заказа, начисление допускается только для статуса `PROCESSED`.
+// Изменение уже проведенного начисления по заказу проводится корректировкой баланса пользователя.
+// Заказ ищется среди заказов программы лояльности запроса, как при повторной обработке заказа.
+// Действие записывается в журнал действий администраторов и историю изменения статуса заказа.
+// Формат запроса:
+// ```
+// POST /api/admin/orders/{number}/status HTTP/1.1
+// Content-Type: application/json
+// X-Admin-Token: <token>
+// X-Admin-User: <name>
+//
+//	{
+//		"status": "PROCESSED",
+//		"accrual": 500,
+//		"reason": "<причина>"
+//	}
+//
+// ```
+// Возможные коды ответа:
+//   - `200` — статус изменен, в ответе заказ после изменения;
+//   - `400` — неверный формат запроса, неизвестный статус, неверное начисление или не указана причина;
+//   - `401` — неверный токен или не указано имя администратора;
+//   - `404` — заказ или программа лояльности не найдены;
+//   - `409` — после изменения начисления баланс пользователя станет отрицательным;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) ForceOrderStatus(w http.ResponseWriter, r *http.Request) {
+	by, ok := r.Context().Value(keyAdmin).(string)
+	if !ok {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	request := forceOrderStatusRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	number := order.OrderNumber(chi.URLParam(r, "number"))
+	o, err := a.admin.ForceOrderStatus(r.Context(), requestTenant(r), number, request.Status, request.Accrual, by, request.Reason)
+	if err != nil {
+		a.writeAdminError(w, err)
+		return
+	}
+	if err := a.writeJSON(w, o); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Ручная корректировка баланса пользователя. Хендлер доступен только администраторам: запрос должен содержать
+// токен администратора в заголовке `X-Admin-Token` и имя администратора в заголовке `X-Admin-User`.
+// Корректировка проводится по журналу транзакций пользователя, `amount` может быть отрицательным.
+// Пользователь ищется среди пользователей программы лояльности, указанной в заголовке `X-Tenant-ID`,
+// или программы, к которой относится имя хоста. Действие записывается в журнал действий администраторов.
+// Формат запроса:
+// ```
+// POST /api/admin/adjustments HTTP/1.1
+// Content-Type: application/json
+// X-Admin-Token: <token>
+// X-Admin-User: <name>
+//
+//	{
+//		"login": "<login>",
+//		"amount": -50,
+//		"reason": "<причина>"
+//	}
+//
+// ```
+// Возможные коды ответа:
+//   - `200` — корректировка проведена.
+//     Формат ответа:
+//     ```
+//     200 OK HTTP/1.1
+//     Content-Type: application/json
+//     ...
+//     {
+//     "amount": -50,
+//     "reason": "<причина>",
+//     "admin": "<name>",
+//     "balance": 450,
+//     "created_at": "2020-12-10T15:15:45+03:00"
+//     }
+//     ```
+//   - `400` — неверный формат запроса, нулевая сумма или не указана причина;
+//   - `401` — неверный токен или не указано имя администратора;
+//   - `404` — пользователь или программа лояльности не найдены;
+//   - `409` — после корректировки баланс пользователя станет отрицательным;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) NewAdjustment(w http.ResponseWriter, r *http.Request) {
+	by, ok := r.Context().Value(keyAdmin).(string)
+	if !ok {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	request := newAdjustmentRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	adj, err := a.admin.NewAdjustment(r.Context(), requestTenant(r), request.Login, request.Amount, by, request.Reason)
+	if err != nil {
+		a.writeAdminError(w, err)
+		return
+	}
+	if err := a.writeJSON(w, adj); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+type deadLetterOrderResponse struct {
+	Number         order.OrderNumber `json:"number"`
+	Status         order.OrderStatus `json:"status"`
+	Failures       uint              `json:"failures"`
+	LastError      string            `json:"last_error"`
+	UploadedAt     time.Time         `json:"uploaded_at"`
+	DeadLetteredAt *time.Time        `json:"dead_lettered_at"`
+}
+
+type retryDeadLetterOrdersRequest struct {
+	Numbers []order.OrderNumber `json:"numbers"`
+	Reason  string              `json:"reason"`
+}
+
+type retryDeadLetterOrdersResponse struct {
+	Retried int `json:"retried"`
+}
+
+// Получение списка заказов, проверка которых прекращена из-за некорректных ответов системы расчёта начислений
+// (ошибки сервера, некорректный ответ, неизвестный статус). Хендлер доступен только администраторам: запрос должен
+// содержать токен администратора в заголовке `X-Admin-Token` и имя администратора в заголовке `X-Admin-User`.
+// Возвращаются только заказы программы лояльности, указанной в заголовке `X-Tenant-ID`, или программы,
+// к которой относится имя хоста. Заказы отсортированы по времени прекращения проверки от самых старых к самым новым.
+// Формат запроса:
+// ```
+// GET /api/admin/orders/dead-letter HTTP/1.1
+// X-Admin-Token: <token>
+// X-Admin-User: <name>
+// ```
+// Возможные коды ответа:
+//   - `200` — успешная обработка запроса.
+//     Формат ответа:
+//     ```
+//     200 OK HTTP/1.1
+//     Content-Type: application/json
+//     ...
+//     [
+//     {
+//     "number": "9278923470",
+//     "status": "PROCESSING",
+//     "failures": 10,
+//     "last_error": "FetchOrder: failed with code 500 and body : unexpected response: invalid accrual system response",
+//     "uploaded_at": "2020-12-10T15:15:45+03:00",
+//     "dead_lettered_at": "2020-12-10T18:15:45+03:00"
+//     }
+//     ]
+//     ```
+//   - `204` — нет заказов, проверка которых прекращена;
+//   - `401` — неверный токен или не указано имя администратора;
+//   - `404` — программа лояльности не найдена;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) GetDeadLetterOrders(w http.ResponseWriter, r *http.Request) {
+	orders, err := a.admin.GetDeadLetterOrders(r.Context(), requestTenant(r))
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if len(orders) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	response := make([]deadLetterOrderResponse, 0, len(orders))
+	for _, o := range orders {
+		response = append(response, deadLetterOrderResponse{
+			Number:         o.Number,
+			Status:         o.Status,
+			Failures:       o.Failures,
+			LastError:      o.LastError,
+			UploadedAt:     o.UploadedAt,
+			DeadLetteredAt: o.DeadLetteredAt,
+		})
+	}
+	if err := a.writeJSON(w, response); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Возобновление проверки заказов, проверка которых прекращена. Хендлер доступен только администраторам: запрос
+// должен содержать токен администратора в заголовке `X-Admin-Token` и имя администратора в заголовке `X-Admin-User`.
+// Проверка возобновляется для заказов из `numbers`, если `numbers` не указан - для всех таких заказов. Счетчик
+// некорректных ответов заказов сбрасывается, статус и начисление не изменяются. Заказы, проверка которых
+// не была прекращена, и заказы других программ лояльности пропускаются. Действие записывается в журнал действий администраторов для каждого заказа.
+// Формат запроса:
+// ```
+// POST /api/admin/orders/dead-letter/retry HTTP/1.1
+// Content-Type: application/json
+// X-Admin-Token: <token>
+// X-Admin-User: <name>
+//
+//	{
+//		"numbers": ["9278923470", "12345678903"],
+//		"reason": "<причина>"
+//	}
+//
+// ```
+// Возможные коды ответа:
+//   - `200` — проверка возобновлена, в ответе количество заказов, проверка которых возобновлена.
+//     Формат ответа:
+//     ```
+//     200 OK HTTP/1.1
+//     Content-Type: application/json
+//     ...
+//     {
+//     "retried": 2
+//     }
+//     ```
+//   - `400` — неверный формат запроса или не указана причина;
+//   - `401` — неверный токен или не указано имя администратора;
+//   - `404` — программа лояльности не найдена;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) RetryDeadLetterOrders(w http.ResponseWriter, r *http.Request) {
+	by, ok := r.Context().Value(keyAdmin).(string)
+	if !ok {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	request := retryDeadLetterOrdersRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	orders, err := a.admin.RetryDeadLetterOrders(r.Context(), requestTenant(r), request.Numbers, by, request.Reason)
+	if err != nil {
+		a.writeAdminError(w, err)
+		return
+	}
+	if err := a.writeJSON(w, retryDeadLetterOrdersResponse{Retried: len(orders)}); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *Adapter) writeAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, admin.ErrReasonRequired), errors.Is(err, admin.ErrInvalidAmount), errors.Is(err, order.ErrUnknownStatus),
+		errors.Is(err, reward.ErrInvalidRule), errors.Is(err, promo.ErrInvalidPromo):
+		http.Error(w, "", http.StatusBadRequest)
+	case errors.Is(err, order.ErrNotFound), errors.Is(err, user.ErrNotFound), errors.Is(err, reward.ErrNotFound):
+		http.Error(w, "", http.StatusNotFound)
+	case errors.Is(err, withdraw.ErrInsufficientBalance), errors.Is(err, promo.ErrDuplicated):
+		http.Error(w, "", http.StatusConflict)
+	default:
+		http.Error(w, "", http.StatusInternalServerError)
+	}
+}
@@ -2,8 +2,15 @@ package http
 
 import (
 	"context"
+	"time"
 
+	"github.com/k1nky/gophermart/internal/entity/accrual"
+	"github.com/k1nky/gophermart/internal/entity/admin"
 	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/promo"
+	"github.com/k1nky/gophermart/internal/entity/reward"
+	"github.com/k1nky/gophermart/internal/entity/statement"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
 	"github.com/k1nky/gophermart/internal/entity/user"
 	"github.com/k1nky/gophermart/internal/entity/withdraw"
 )
@@ -17,10 +24,39 @@ type authService interface {
 
 type accountService interface {
 	NewOrder(ctx context.Context, o order.Order) (*order.Order, error)
+	NewOrders(ctx context.Context, userID user.ID, tenantID tenant.ID, numbers []order.OrderNumber) ([]order.BatchItem, error)
 	GetUserOrders(ctx context.Context, userID user.ID) ([]*order.Order, error)
+	GetUserOrderTimeline(ctx context.Context, userID user.ID, number order.OrderNumber) (*order.Timeline, error)
 	GetUserBalance(ctx context.Context, userID user.ID) (user.Balance, error)
+	GetUserBalanceAt(ctx context.Context, userID user.ID, at time.Time) (user.Balance, error)
 	GetUserWithdrawals(ctx context.Context, userID user.ID) ([]*withdraw.Withdraw, error)
 	NewWithdraw(ctx context.Context, w withdraw.Withdraw) error
+	RedeemPromo(ctx context.Context, userID user.ID, code promo.Code) (*promo.Redemption, error)
+	GetUserStatement(ctx context.Context, userID user.ID, from time.Time, to time.Time, fn func(e *statement.Entry) error) error
+}
+
+type adminService interface {
+	RequeueOrder(ctx context.Context, tenantID tenant.ID, number order.OrderNumber, by string, reason string) (*order.Order, error)
+	ForceOrderStatus(ctx context.Context, tenantID tenant.ID, number order.OrderNumber, status order.OrderStatus, accrual *float32, by string, reason string) (*order.Order, error)
+	NewAdjustment(ctx context.Context, tenantID tenant.ID, login string, amount float32, by string, reason string) (*admin.Adjustment, error)
+	NewPromo(ctx context.Context, tenantID tenant.ID, p promo.Promo, by string, reason string) (*promo.Promo, error)
+	GetDeadLetterOrders(ctx context.Context, tenantID tenant.ID) ([]*order.Order, error)
+	RetryDeadLetterOrders(ctx context.Context, tenantID tenant.ID, numbers []order.OrderNumber, by string, reason string) ([]*order.Order, error)
+	GetRewardRules(ctx context.Context, tenantID tenant.ID) ([]*reward.Rule, error)
+	NewRewardRule(ctx context.Context, tenantID tenant.ID, rule reward.Rule, by string, reason string) (*reward.Rule, error)
+	UpdateRewardRule(ctx context.Context, tenantID tenant.ID, rule reward.Rule, by string, reason string) (*reward.Rule, error)
+	DeleteRewardRule(ctx context.Context, tenantID tenant.ID, id reward.ID, by string, reason string) error
+}
+
+type accrualService interface {
+	ApplyAccrual(ctx context.Context, o order.Order) error
+}
+
+type accrualMonitor interface {
+	// состояния автоматов защиты систем расчёта начислений по их именам: closed, open или half-open
+	CircuitStates() map[string]accrual.CircuitState
+	// счетчики запросов к системам расчёта начислений по их именам и результату запроса
+	FetchCounters() map[string]map[string]uint64
 }
 
 type logger interface {
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/k1nky/gophermart/internal/entity/accrual"
+)
+
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+)
+
+type accrualHealth struct {
+	Circuit accrual.CircuitState `json:"circuit"`
+}
+
+type healthResponse struct {
+	Status  string                   `json:"status"`
+	Accrual map[string]accrualHealth `json:"accrual"`
+}
+
+// Состояние сервиса. Хендлер доступен без авторизации.
+// Сервис продолжает принимать заказы, когда одна из систем расчёта начислений недоступна, поэтому
+// в этом случае возвращается статус `degraded`, а не ошибка.
+// Формат запроса:
+// ```
+// GET /api/health HTTP/1.1
+// Content-Length: 0
+// ```
+// Возможные коды ответа:
+//   - `200` — успешная обработка запроса.
+//     Формат ответа:
+//     ```
+//     200 OK HTTP/1.1
+//     Content-Type: application/json
+//     ...
+//     {
+//     "status": "degraded",
+//     "accrual": {
+//     "default": {"circuit": "closed"},
+//     "partner": {"circuit": "open"}
+//     }
+//     }
+//     ```
+//     Здесь `accrual` — системы расчёта начислений по их именам, `circuit` — состояние автомата защиты
+//     системы: `closed`, `open` или `half-open`.
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) Health(w http.ResponseWriter, r *http.Request) {
+	response := healthResponse{
+		Status:  HealthStatusOK,
+		Accrual: make(map[string]accrualHealth),
+	}
+	for provider, circuit := range a.accrual.CircuitStates() {
+		response.Accrual[provider] = accrualHealth{Circuit: circuit}
+		if circuit == accrual.CircuitOpen {
+			response.Status = HealthStatusDegraded
+		}
+	}
+	if err := a.writeJSON(w, response); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Метрики сервиса в текстовом формате Prometheus. Хендлер доступен без авторизации.
+// Формат запроса:
+// ```
+// GET /metrics HTTP/1.1
+// Content-Length: 0
+// ```
+// Возможные коды ответа:
+//   - `200` — успешная обработка запроса.
+//     Формат ответа:
+//     ```
+//     200 OK HTTP/1.1
+//     Content-Type: text/plain; version=0.0.4
+//     ...
+//     gophermart_accrual_circuit_state{provider="default",state="closed"} 1
+//     gophermart_accrual_circuit_state{provider="default",state="open"} 0
+//     gophermart_accrual_circuit_state{provider="default",state="half-open"} 0
+//     gophermart_accrual_requests_total{provider="default",result="succeeded"} 42
+//     ...
+//     ```
+func (a *Adapter) Metrics(w http.ResponseWriter, r *http.Request) {
+	b := strings.Builder{}
+	b.WriteString("# HELP gophermart_accrual_circuit_state Circuit breaker state of the accrual system.\n")
+	b.WriteString("# TYPE gophermart_accrual_circuit_state gauge\n")
+	circuits := a.accrual.CircuitStates()
+	for _, provider := range sortedKeys(circuits) {
+		for _, state := range []accrual.CircuitState{accrual.CircuitClosed, accrual.CircuitOpen, accrual.CircuitHalfOpen} {
+			v := 0
+			if state == circuits[provider] {
+				v = 1
+			}
+			fmt.Fprintf(&b, "gophermart_accrual_circuit_state{provider=%q,state=%q} %d\n", provider, state, v)
+		}
+	}
+	b.WriteString("# HELP gophermart_accrual_requests_total Requests to the accrual system by result.\n")
+	b.WriteString("# TYPE gophermart_accrual_requests_total counter\n")
+	counters := a.accrual.FetchCounters()
+	for _, provider := range sortedKeys(counters) {
+		for _, result := range sortedKeys(counters[provider]) {
+			fmt.Fprintf(&b, "gophermart_accrual_requests_total{provider=%q,result=%q} %d\n", provider, result, counters[provider][result])
+		}
+	}
+	w.Header().Set("content-type", "text/plain; version=0.0.4")
+	w.Write([]byte(b.String()))
+}
+
+// Возвращает ключи m в порядке возрастания, чтобы метрики выводились в одном и том же порядке
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
 )
 
 const (
@@ -17,29 +18,64 @@ const (
 )
 
 type Adapter struct {
-	auth    authService
-	account accountService
+	auth           authService
+	account        accountService
+	accrual        accrualMonitor
+	accrualService accrualService
+	admin          adminService
+	// токен администратора, пустой - действия администраторов отключены
+	adminToken []byte
+	// ключ подписи уведомлений системы начислений, пустой - прием уведомлений отключен
+	callbackSecret []byte
+	// программы лояльности, nil - все запросы относятся к программе по умолчанию
+	tenants *tenant.Directory
 	log     logger
 }
 
-func New(auth authService, account accountService, log logger) *Adapter {
+func New(auth authService, account accountService, accrualService accrualService, accrual accrualMonitor, log logger) *Adapter {
 	a := &Adapter{
-		auth:    auth,
-		account: account,
-		log:     log,
+		auth:           auth,
+		account:        account,
+		accrual:        accrual,
+		accrualService: accrualService,
+		log:            log,
 	}
 
 	return a
 }
 
-func (a *Adapter) ListenAndServe(ctx context.Context, addr string) {
+// Включает действия администраторов, доступные по токену token.
+// Должен быть вызван до ListenAndServe.
+func (a *Adapter) EnableAdmin(token string, admin adminService) {
+	a.adminToken = []byte(token)
+	a.admin = admin
+}
+
+// Включает прием уведомлений от системы начислений, подписанных ключом secret.
+// Должен быть вызван до ListenAndServe.
+func (a *Adapter) EnableAccrualCallback(secret string) {
+	a.callbackSecret = []byte(secret)
+}
+
+// Включает программы лояльности из справочника d, см. TenantMiddleware.
+// Должен быть вызван до ListenAndServe.
+func (a *Adapter) EnableTenants(d *tenant.Directory) {
+	a.tenants = d
+}
+
+// Запускает HTTP-сервер на адресе addr и останавливает его при отмене контекста ctx. Возвращает канал,
+// который закрывается после остановки сервера, когда обработка принятых запросов завершена или прервана
+// по истечении DefaultCloseTimeout, а также если сервер не удалось запустить.
+func (a *Adapter) ListenAndServe(ctx context.Context, addr string) <-chan struct{} {
 	srv := &http.Server{
 		Handler:      a.buildRouter(),
 		Addr:         addr,
 		WriteTimeout: DefaultReadTimeout,
 		ReadTimeout:  DefaultWriteTimeout,
 	}
+	serveDone := make(chan struct{})
 	go func() {
+		defer close(serveDone)
 		if err := srv.ListenAndServe(); err != nil {
 			a.log.Debugf("http server was closed")
 			if !errors.Is(err, http.ErrServerClosed) {
@@ -47,27 +83,64 @@ func (a *Adapter) ListenAndServe(ctx context.Context, addr string) {
 			}
 		}
 	}()
+	done := make(chan struct{})
 	go func() {
-		<-ctx.Done()
+		defer close(done)
+		select {
+		case <-ctx.Done():
+		case <-serveDone:
+			// сервер остановлен из-за ошибки, принятых запросов нет
+			return
+		}
 		a.log.Debugf("closing http server")
 		c, cancel := context.WithTimeout(context.Background(), DefaultCloseTimeout)
 		defer cancel()
-		srv.Shutdown(c)
+		// Shutdown возвращает управление после завершения обработки принятых запросов
+		if err := srv.Shutdown(c); err != nil {
+			a.log.Errorf("http server shutdown: %v", err)
+		}
 	}()
+	return done
 }
 
 func (a *Adapter) buildRouter() http.Handler {
 	r := chi.NewRouter()
 	r.Use(LoggingMiddleware(a.log))
+	r.Get("/api/health", a.Health)
+	r.Get("/metrics", a.Metrics)
 	r.Route("/api/user", func(r chi.Router) {
+		r.Use(TenantMiddleware(a.tenants))
 		r.Post("/register", a.Register)
 		r.Post("/login", a.Login)
 		r.With(AuthorizeMiddleware(a.auth)).Get("/balance", a.GetBalance)
 		r.With(AuthorizeMiddleware(a.auth)).Get("/orders", a.GetOrder)
 		r.With(AuthorizeMiddleware(a.auth)).Post("/orders", a.NewOrder)
+		r.With(AuthorizeMiddleware(a.auth)).Post("/orders/batch", a.NewOrders)
+		r.With(AuthorizeMiddleware(a.auth)).Get("/orders/{number}", a.GetOrderTimeline)
 		r.With(AuthorizeMiddleware(a.auth)).Get("/withdrawals", a.GetWithdrawals)
 		r.With(AuthorizeMiddleware(a.auth)).Post("/balance/withdraw", a.NewWithdraw)
+		r.With(AuthorizeMiddleware(a.auth)).Post("/promo", a.RedeemPromo)
+		r.With(AuthorizeMiddleware(a.auth)).Get("/statement", a.GetStatement)
 	})
+	if len(a.callbackSecret) != 0 {
+		r.With(SignatureMiddleware(a.callbackSecret)).Post("/api/internal/accrual/callback", a.AccrualCallback)
+	}
+	if len(a.adminToken) != 0 {
+		r.Route("/api/admin", func(r chi.Router) {
+			r.Use(AdminMiddleware(a.adminToken))
+			r.Use(TenantMiddleware(a.tenants))
+			r.Get("/orders/dead-letter", a.GetDeadLetterOrders)
+			r.Post("/orders/dead-letter/retry", a.RetryDeadLetterOrders)
+			r.Post("/orders/{number}/requeue", a.RequeueOrder)
+			r.Post("/orders/{number}/status", a.ForceOrderStatus)
+			r.Post("/adjustments", a.NewAdjustment)
+			r.Post("/promo", a.NewPromo)
+			r.Get("/rewards", a.GetRewardRules)
+			r.Post("/rewards", a.NewRewardRule)
+			r.Put("/rewards/{id}", a.UpdateRewardRule)
+			r.Delete("/rewards/{id}", a.DeleteRewardRule)
+		})
+	}
 	return r
 }
 
@@ -3,22 +3,40 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/k1nky/gophermart/internal/adapter/http/mock"
+	log "github.com/k1nky/gophermart/internal/logger"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/k1nky/gophermart/internal/entity/accrual"
+	"github.com/k1nky/gophermart/internal/entity/admin"
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/promo"
+	"github.com/k1nky/gophermart/internal/entity/reward"
+	"github.com/k1nky/gophermart/internal/entity/statement"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
 	"github.com/k1nky/gophermart/internal/entity/user"
+	"github.com/k1nky/gophermart/internal/entity/withdraw"
 )
 
 type httpAdapterTestSuite struct {
 	suite.Suite
 	authService    *mock.MockauthService
 	accountService *mock.MockaccountService
+	adminService   *mock.MockadminService
+	accrualMonitor *mock.MockaccrualMonitor
+	accrualService *mock.MockaccrualService
 }
 
 func TestHTTPAdapter(t *testing.T) {
@@ -29,6 +47,9 @@ func (suite *httpAdapterTestSuite) SetupTest() {
 	ctrl := gomock.NewController(suite.T())
 	suite.authService = mock.NewMockauthService(ctrl)
 	suite.accountService = mock.NewMockaccountService(ctrl)
+	suite.adminService = mock.NewMockadminService(ctrl)
+	suite.accrualMonitor = mock.NewMockaccrualMonitor(ctrl)
+	suite.accrualService = mock.NewMockaccrualService(ctrl)
 }
 
 func (suite *httpAdapterTestSuite) TestRegister() {
@@ -152,6 +173,8 @@ func (suite *httpAdapterTestSuite) TestNewOrder() {
 		payload    string
 		want       want
 		mockExpect []interface{}
+		// номер заказа, переданный сервису, если отличается от payload
+		number order.OrderNumber
 	}{
 		{
 			name:       "Success",
@@ -159,11 +182,18 @@ func (suite *httpAdapterTestSuite) TestNewOrder() {
 			want:       want{statusCode: http.StatusAccepted},
 			mockExpect: []interface{}{nil, nil},
 		},
+		{
+			name:       "Normalized number",
+			payload:    "1234-5678 903\r\n",
+			want:       want{statusCode: http.StatusAccepted},
+			mockExpect: []interface{}{nil, nil},
+			number:     "12345678903",
+		},
 		{
 			name:       "Invalid number",
 			payload:    "123456789",
 			want:       want{statusCode: http.StatusUnprocessableEntity},
-			mockExpect: []interface{}{},
+			mockExpect: []interface{}{nil, order.ErrInvalidNumberFormat},
 		},
 	}
 	a := &Adapter{
@@ -178,9 +208,1258 @@ func (suite *httpAdapterTestSuite) TestNewOrder() {
 		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.payload))
 
 		if len(tt.mockExpect) > 0 {
-			suite.accountService.EXPECT().NewOrder(gomock.Any(), gomock.Any()).Return(tt.mockExpect...)
+			number := tt.number
+			if len(number) == 0 {
+				number = order.OrderNumber(tt.payload)
+			}
+			suite.accountService.EXPECT().NewOrder(gomock.Any(), order.Order{UserID: 1, Number: number}).Return(tt.mockExpect...)
 		}
 		a.NewOrder(w, r.WithContext(context.WithValue(r.Context(), keyUserClaims, claims)))
-		suite.Equal(tt.want.statusCode, w.Code)
+		suite.Equal(tt.want.statusCode, w.Code, tt.name)
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestNewOrderWithPurchase() {
+	tests := []struct {
+		name       string
+		payload    string
+		purchase   *order.Purchase
+		statusCode int
+	}{
+		{
+			name:    "Purchase",
+			payload: `{"number":"12345678903","total":7000,"currency":"RUB","merchant":"Bork","items":[{"description":"Чайник Bork","price":7000}]}`,
+			purchase: &order.Purchase{
+				Total:    7000,
+				Currency: "RUB",
+				Merchant: "Bork",
+				Items:    []order.Item{{Description: "Чайник Bork", Price: 7000}},
+			},
+			statusCode: http.StatusAccepted,
+		},
+		{
+			name:       "Number only",
+			payload:    `{"number":"12345678903"}`,
+			statusCode: http.StatusAccepted,
+		},
+		{
+			name:       "Invalid purchase",
+			payload:    `{"number":"12345678903","total":7000,"currency":"rubles"}`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid JSON",
+			payload:    `12345678903`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid number",
+			payload:    `{"number":"123456789","total":7000,"currency":"RUB"}`,
+			statusCode: http.StatusUnprocessableEntity,
+		},
+	}
+	a := &Adapter{
+		account: suite.accountService,
+	}
+	claims := user.PrivateClaims{
+		ID:    user.ID(1),
+		Login: "u1",
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.payload))
+		r.Header.Set("Content-Type", "application/json")
+		if tt.statusCode == http.StatusAccepted {
+			want := order.Order{UserID: 1, Number: "12345678903", Purchase: tt.purchase}
+			suite.accountService.EXPECT().NewOrder(gomock.Any(), want).Return(&want, nil)
+		}
+		if tt.statusCode == http.StatusUnprocessableEntity {
+			suite.accountService.EXPECT().NewOrder(gomock.Any(), gomock.Any()).Return(nil, order.ErrInvalidNumberFormat)
+		}
+		a.NewOrder(w, r.WithContext(context.WithValue(r.Context(), keyUserClaims, claims)))
+		suite.Equal(tt.statusCode, w.Code, tt.name)
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestNewOrders() {
+	type want struct {
+		statusCode int
+		body       string
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		payload     string
+		numbers     []order.OrderNumber
+		items       []order.BatchItem
+		err         error
+		want        want
+	}{
+		{
+			name:        "JSON",
+			contentType: "application/json",
+			payload:     `["12345678903", "9278923470", "123"]`,
+			numbers:     []order.OrderNumber{"12345678903", "9278923470", "123"},
+			items: []order.BatchItem{
+				{Number: "12345678903", Result: order.UploadAccepted},
+				{Number: "9278923470", Result: order.UploadConflict},
+				{Number: "123", Result: order.UploadInvalid},
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body: `[{"number":"12345678903","result":"accepted","code":202},` +
+					`{"number":"9278923470","result":"conflict","code":409},` +
+					`{"number":"123","result":"invalid","code":422}]`,
+			},
+		},
+		{
+			name:        "Plain text",
+			contentType: "text/plain",
+			payload:     "12345678903\r\n\n 927-892-3470 \n",
+			numbers:     []order.OrderNumber{"12345678903", "9278923470"},
+			items: []order.BatchItem{
+				{Number: "12345678903", Result: order.UploadDuplicate},
+				{Number: "9278923470", Result: order.UploadAccepted},
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `[{"number":"12345678903","result":"duplicate","code":200},{"number":"9278923470","result":"accepted","code":202}]`,
+			},
+		},
+		{
+			name:        "Invalid JSON",
+			contentType: "application/json",
+			payload:     `{"numbers": []}`,
+			want:        want{statusCode: http.StatusBadRequest},
+		},
+		{
+			name:        "Empty batch",
+			contentType: "text/plain",
+			payload:     "",
+			numbers:     []order.OrderNumber{},
+			err:         order.ErrInvalidBatch,
+			want:        want{statusCode: http.StatusBadRequest},
+		},
+	}
+	a := &Adapter{
+		account: suite.accountService,
+	}
+	claims := user.PrivateClaims{
+		ID:    user.ID(1),
+		Login: "u1",
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.payload))
+		r.Header.Set("Content-Type", tt.contentType)
+		if tt.numbers != nil {
+			suite.accountService.EXPECT().NewOrders(gomock.Any(), user.ID(1), tenant.Default, tt.numbers).Return(tt.items, tt.err)
+		}
+		a.NewOrders(w, r.WithContext(context.WithValue(r.Context(), keyUserClaims, claims)))
+		suite.Equal(tt.want.statusCode, w.Code, tt.name)
+		if tt.want.statusCode == http.StatusOK {
+			suite.JSONEq(tt.want.body, w.Body.String(), tt.name)
+		}
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestGetOrderTimeline() {
+	uploadedAt := time.Date(2020, 12, 10, 15, 15, 45, 0, time.UTC)
+	accrual := float32(500)
+	timeline := &order.Timeline{
+		Order: order.Order{
+			Number:     "12345678903",
+			Status:     order.StatusProcessed,
+			Accrual:    &accrual,
+			UploadedAt: uploadedAt,
+		},
+		Events: []order.Event{
+			{Status: order.StatusNew, Source: order.SourceUpload, CreatedAt: uploadedAt},
+			{
+				Status:    order.StatusProcessed,
+				Accrual:   &accrual,
+				Source:    order.SourcePoller,
+				Provider:  "default",
+				Payload:   []byte(`{"order":"12345678903","status":"PROCESSED","accrual":500}`),
+				CreatedAt: uploadedAt.Add(time.Minute),
+			},
+		},
+	}
+	tests := []struct {
+		name       string
+		statusCode int
+		want       string
+		mockExpect []interface{}
+	}{
+		{
+			name:       "Success",
+			statusCode: http.StatusOK,
+			want: `{"ID":0,"number":"12345678903","status":"PROCESSED","accrual":500,"uploaded_at":"2020-12-10T15:15:45Z","events":[
+				{"status":"NEW","source":"UPLOAD","created_at":"2020-12-10T15:15:45Z"},
+				{"status":"PROCESSED","accrual":500,"source":"POLLER","provider":"default","payload":{"order":"12345678903","status":"PROCESSED","accrual":500},"created_at":"2020-12-10T15:16:45Z"}
+			]}`,
+			mockExpect: []interface{}{timeline, nil},
+		},
+		{
+			name:       "Not found",
+			statusCode: http.StatusNotFound,
+			mockExpect: []interface{}{nil, order.ErrNotFound},
+		},
+		{
+			name:       "Unexpected error",
+			statusCode: http.StatusInternalServerError,
+			mockExpect: []interface{}{nil, errors.New("unexpected error")},
+		},
+	}
+	a := &Adapter{
+		account: suite.accountService,
+	}
+	claims := user.PrivateClaims{
+		ID:    user.ID(1),
+		Login: "u1",
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/user/orders/12345678903", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("number", "12345678903")
+		ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
+		ctx = context.WithValue(ctx, keyUserClaims, claims)
+		suite.accountService.EXPECT().GetUserOrderTimeline(gomock.Any(), user.ID(1), order.OrderNumber("12345678903")).Return(tt.mockExpect...)
+		a.GetOrderTimeline(w, r.WithContext(ctx))
+		suite.Equal(tt.statusCode, w.Code, tt.name)
+		if len(tt.want) != 0 {
+			suite.JSONEq(tt.want, w.Body.String(), tt.name)
+		}
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestRedeemPromo() {
+	type want struct {
+		statusCode int
+	}
+	tests := []struct {
+		name       string
+		payload    string
+		want       want
+		mockExpect []interface{}
+	}{
+		{
+			name:       "Success",
+			payload:    `{"code": "WELCOME"}`,
+			want:       want{statusCode: http.StatusOK},
+			mockExpect: []interface{}{&promo.Redemption{Code: "WELCOME", Amount: 100}, nil},
+		},
+		{
+			name:       "Invalid json",
+			payload:    `{"code": `,
+			want:       want{statusCode: http.StatusBadRequest},
+			mockExpect: []interface{}{},
+		},
+		{
+			name:       "Empty code",
+			payload:    `{"code": ""}`,
+			want:       want{statusCode: http.StatusBadRequest},
+			mockExpect: []interface{}{},
+		},
+		{
+			name:       "Not found",
+			payload:    `{"code": "UNKNOWN"}`,
+			want:       want{statusCode: http.StatusNotFound},
+			mockExpect: []interface{}{nil, promo.ErrNotFound},
+		},
+		{
+			name:       "Already redeemed",
+			payload:    `{"code": "WELCOME"}`,
+			want:       want{statusCode: http.StatusConflict},
+			mockExpect: []interface{}{nil, promo.ErrAlreadyRedeemed},
+		},
+		{
+			name:       "Exhausted",
+			payload:    `{"code": "WELCOME"}`,
+			want:       want{statusCode: http.StatusGone},
+			mockExpect: []interface{}{nil, promo.ErrExhausted},
+		},
+		{
+			name:       "Unexpected error",
+			payload:    `{"code": "WELCOME"}`,
+			want:       want{statusCode: http.StatusInternalServerError},
+			mockExpect: []interface{}{nil, errors.New("unexpected error")},
+		},
+	}
+	a := &Adapter{
+		account: suite.accountService,
+	}
+	claims := user.PrivateClaims{
+		ID:    user.ID(1),
+		Login: "u1",
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.payload))
+
+		if len(tt.mockExpect) > 0 {
+			suite.accountService.EXPECT().RedeemPromo(gomock.Any(), user.ID(1), gomock.Any()).Return(tt.mockExpect...)
+		}
+		a.RedeemPromo(w, r.WithContext(context.WithValue(r.Context(), keyUserClaims, claims)))
+		suite.Equal(tt.want.statusCode, w.Code, tt.name)
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestNewPromo() {
+	const token = "token"
+	validFrom := time.Date(2020, 12, 10, 15, 15, 45, 0, time.UTC)
+	tests := []struct {
+		name       string
+		token      string
+		user       string
+		payload    string
+		statusCode int
+		body       string
+		expect     func()
+	}{
+		{
+			name:       "Create",
+			token:      token,
+			user:       "support",
+			payload:    `{"code":"WELCOME","amount":100,"reason":"campaign"}`,
+			statusCode: http.StatusOK,
+			body:       `{"code":"WELCOME","amount":100,"max_redemptions":1,"redemptions":0,"valid_from":"2020-12-10T15:15:45Z"}`,
+			expect: func() {
+				p := promo.Promo{Code: "WELCOME", Amount: 100, MaxRedemptions: 1}
+				created := p
+				created.ID = 1
+				created.ValidFrom = validFrom
+				suite.adminService.EXPECT().NewPromo(gomock.Any(), tenant.Default, p, "support", "campaign").Return(&created, nil)
+			},
+		},
+		{
+			name:       "Create with window",
+			token:      token,
+			user:       "support",
+			payload:    `{"code":"FRIDAY","amount":50,"max_redemptions":1000,"valid_from":"2020-12-10T15:15:45Z","reason":"campaign"}`,
+			statusCode: http.StatusOK,
+			expect: func() {
+				p := promo.Promo{Code: "FRIDAY", Amount: 50, MaxRedemptions: 1000, ValidFrom: validFrom}
+				suite.adminService.EXPECT().NewPromo(gomock.Any(), tenant.Default, p, "support", "campaign").Return(&p, nil)
+			},
+		},
+		{
+			name:       "Invalid promo",
+			token:      token,
+			user:       "support",
+			payload:    `{"code":"WELCOME","amount":0}`,
+			statusCode: http.StatusBadRequest,
+			expect: func() {
+				suite.adminService.EXPECT().NewPromo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, promo.ErrInvalidPromo)
+			},
+		},
+		{
+			name:       "Without reason",
+			token:      token,
+			user:       "support",
+			payload:    `{"code":"WELCOME","amount":100}`,
+			statusCode: http.StatusBadRequest,
+			expect: func() {
+				suite.adminService.EXPECT().NewPromo(gomock.Any(), gomock.Any(), gomock.Any(), "support", "").Return(nil, admin.ErrReasonRequired)
+			},
+		},
+		{
+			name:       "Duplicated",
+			token:      token,
+			user:       "support",
+			payload:    `{"code":"WELCOME","amount":100}`,
+			statusCode: http.StatusConflict,
+			expect: func() {
+				suite.adminService.EXPECT().NewPromo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, promo.ErrDuplicated)
+			},
+		},
+		{
+			name:       "Invalid request",
+			token:      token,
+			user:       "support",
+			payload:    `{"code":`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid token",
+			token:      "wrong",
+			user:       "support",
+			payload:    `{"code":"WELCOME","amount":100}`,
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Without admin name",
+			token:      token,
+			payload:    `{"code":"WELCOME","amount":100}`,
+			statusCode: http.StatusUnauthorized,
+		},
+	}
+	a := New(nil, nil, nil, nil, &log.Blackhole{})
+	a.EnableAdmin(token, suite.adminService)
+	router := a.buildRouter()
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/admin/promo", bytes.NewBufferString(tt.payload))
+		r.Header.Set(AdminTokenHeader, tt.token)
+		r.Header.Set(AdminUserHeader, tt.user)
+		if tt.expect != nil {
+			tt.expect()
+		}
+		router.ServeHTTP(w, r)
+		suite.Equal(tt.statusCode, w.Code, tt.name)
+		if len(tt.body) != 0 {
+			suite.JSONEq(tt.body, w.Body.String(), tt.name)
+		}
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestGetStatement() {
+	amount := float32(500)
+	entries := []*statement.Entry{
+		{Kind: statement.KindOrder, Reference: "9278923470", Status: "PROCESSED", Amount: &amount, Time: time.Date(2020, 12, 10, 15, 15, 45, 0, time.UTC)},
+		{Kind: statement.KindTransaction, Reference: "ACCRUAL", Amount: &amount, Balance: &amount, Time: time.Date(2020, 12, 10, 15, 16, 1, 0, time.UTC)},
+	}
+	type want struct {
+		statusCode int
+		body       string
+	}
+	tests := []struct {
+		name       string
+		query      string
+		want       want
+		expectCall bool
+	}{
+		{
+			name:  "CSV",
+			query: "?from=2020-12-01&to=2020-12-31",
+			want: want{statusCode: http.StatusOK, body: "kind,reference,status,amount,balance,time\n" +
+				"ORDER,9278923470,PROCESSED,500,,2020-12-10T15:15:45Z\n" +
+				"TRANSACTION,ACCRUAL,,500,500,2020-12-10T15:16:01Z\n"},
+			expectCall: true,
+		},
+		{
+			name:  "JSON Lines",
+			query: "?format=jsonl",
+			want: want{statusCode: http.StatusOK, body: `{"kind":"ORDER","reference":"9278923470","status":"PROCESSED","amount":500,"time":"2020-12-10T15:15:45Z"}` + "\n" +
+				`{"kind":"TRANSACTION","reference":"ACCRUAL","amount":500,"balance":500,"time":"2020-12-10T15:16:01Z"}` + "\n"},
+			expectCall: true,
+		},
+		{
+			name:       "Invalid format",
+			query:      "?format=xml",
+			want:       want{statusCode: http.StatusBadRequest},
+			expectCall: false,
+		},
+		{
+			name:       "Invalid period",
+			query:      "?from=2020-12-31&to=2020-12-01",
+			want:       want{statusCode: http.StatusBadRequest},
+			expectCall: false,
+		},
+		{
+			name:       "Invalid time",
+			query:      "?from=yesterday",
+			want:       want{statusCode: http.StatusBadRequest},
+			expectCall: false,
+		},
+	}
+	a := &Adapter{
+		account: suite.accountService,
+	}
+	claims := user.PrivateClaims{
+		ID:    user.ID(1),
+		Login: "u1",
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+
+		if tt.expectCall {
+			suite.accountService.EXPECT().GetUserStatement(gomock.Any(), user.ID(1), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ user.ID, _ time.Time, _ time.Time, fn func(e *statement.Entry) error) error {
+					for _, e := range entries {
+						if err := fn(e); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+		}
+		a.GetStatement(w, r.WithContext(context.WithValue(r.Context(), keyUserClaims, claims)))
+		suite.Equal(tt.want.statusCode, w.Code, tt.name)
+		if tt.want.statusCode == http.StatusOK {
+			suite.Equal(tt.want.body, w.Body.String(), tt.name)
+		}
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestGetBalance() {
+	type want struct {
+		statusCode int
+		body       string
+	}
+	tests := []struct {
+		name         string
+		query        string
+		want         want
+		expectAt     *time.Time
+		expectResult []interface{}
+	}{
+		{
+			name:         "Current",
+			query:        "",
+			want:         want{statusCode: http.StatusOK, body: `{"current":500.5,"withdrawn":42}` + "\n"},
+			expectResult: []interface{}{user.Balance{Current: 500.5, Withdrawn: 42}, nil},
+		},
+		{
+			name:         "At point in time",
+			query:        "?at=2020-12-10T15:15:45Z",
+			want:         want{statusCode: http.StatusOK, body: `{"current":100,"withdrawn":0}` + "\n"},
+			expectAt:     &time.Time{},
+			expectResult: []interface{}{user.Balance{Current: 100}, nil},
+		},
+		{
+			name:  "Invalid time",
+			query: "?at=2020-12-10",
+			want:  want{statusCode: http.StatusBadRequest},
+		},
+		{
+			name:         "Unexpected error",
+			query:        "?at=2020-12-10T15:15:45Z",
+			want:         want{statusCode: http.StatusInternalServerError},
+			expectAt:     &time.Time{},
+			expectResult: []interface{}{user.Balance{}, errors.New("unexpected error")},
+		},
+	}
+	a := &Adapter{
+		account: suite.accountService,
+	}
+	claims := user.PrivateClaims{
+		ID:    user.ID(1),
+		Login: "u1",
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+
+		if len(tt.expectResult) > 0 {
+			if tt.expectAt != nil {
+				at := time.Date(2020, 12, 10, 15, 15, 45, 0, time.UTC)
+				suite.accountService.EXPECT().GetUserBalanceAt(gomock.Any(), user.ID(1), at).Return(tt.expectResult...)
+			} else {
+				suite.accountService.EXPECT().GetUserBalance(gomock.Any(), user.ID(1)).Return(tt.expectResult...)
+			}
+		}
+		a.GetBalance(w, r.WithContext(context.WithValue(r.Context(), keyUserClaims, claims)))
+		suite.Equal(tt.want.statusCode, w.Code, tt.name)
+		if tt.want.statusCode == http.StatusOK {
+			suite.Equal(tt.want.body, w.Body.String(), tt.name)
+		}
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestHealth() {
+	tests := []struct {
+		name     string
+		circuits map[string]accrual.CircuitState
+		want     string
+	}{
+		{
+			name:     "OK",
+			circuits: map[string]accrual.CircuitState{"default": accrual.CircuitClosed, "partner": accrual.CircuitHalfOpen},
+			want:     `{"status":"ok","accrual":{"default":{"circuit":"closed"},"partner":{"circuit":"half-open"}}}`,
+		},
+		{
+			name:     "Accrual unavailable",
+			circuits: map[string]accrual.CircuitState{"default": accrual.CircuitClosed, "partner": accrual.CircuitOpen},
+			want:     `{"status":"degraded","accrual":{"default":{"circuit":"closed"},"partner":{"circuit":"open"}}}`,
+		},
+	}
+	a := &Adapter{
+		accrual: suite.accrualMonitor,
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+		suite.accrualMonitor.EXPECT().CircuitStates().Return(tt.circuits)
+		a.Health(w, r)
+		suite.Equal(http.StatusOK, w.Code, tt.name)
+		suite.JSONEq(tt.want, w.Body.String(), tt.name)
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestMetrics() {
+	a := &Adapter{
+		accrual: suite.accrualMonitor,
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	suite.accrualMonitor.EXPECT().CircuitStates().Return(map[string]accrual.CircuitState{"default": accrual.CircuitHalfOpen, "partner": accrual.CircuitClosed})
+	suite.accrualMonitor.EXPECT().FetchCounters().Return(map[string]map[string]uint64{
+		"default": {"succeeded": 3, "failed": 1},
+		"partner": {"succeeded": 2},
+	})
+	a.Metrics(w, r)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Contains(w.Body.String(), `gophermart_accrual_circuit_state{provider="default",state="closed"} 0`)
+	suite.Contains(w.Body.String(), `gophermart_accrual_circuit_state{provider="default",state="half-open"} 1`)
+	suite.Contains(w.Body.String(), `gophermart_accrual_circuit_state{provider="partner",state="closed"} 1`)
+	suite.Contains(w.Body.String(), `gophermart_accrual_requests_total{provider="default",result="failed"} 1`+"\n"+`gophermart_accrual_requests_total{provider="default",result="succeeded"} 3`)
+	suite.Contains(w.Body.String(), `gophermart_accrual_requests_total{provider="partner",result="succeeded"} 2`)
+}
+
+func (suite *httpAdapterTestSuite) TestAccrualCallback() {
+	const secret = "secret"
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	accrual := float32(500)
+	tests := []struct {
+		name       string
+		payload    string
+		signature  string
+		statusCode int
+		mockExpect []interface{}
+	}{
+		{
+			name:       "Success",
+			payload:    `{"order":"12345678903","status":"PROCESSED","accrual":500}`,
+			signature:  sign(`{"order":"12345678903","status":"PROCESSED","accrual":500}`),
+			statusCode: http.StatusOK,
+			mockExpect: []interface{}{nil},
+		},
+		{
+			name:       "Signature with prefix",
+			payload:    `{"order":"12345678903","status":"PROCESSED","accrual":500}`,
+			signature:  "sha256=" + sign(`{"order":"12345678903","status":"PROCESSED","accrual":500}`),
+			statusCode: http.StatusOK,
+			mockExpect: []interface{}{nil},
+		},
+		{
+			name:       "Invalid signature",
+			payload:    `{"order":"12345678903","status":"PROCESSED","accrual":500}`,
+			signature:  sign(`{"order":"12345678903","status":"PROCESSED","accrual":5000}`),
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Without signature",
+			payload:    `{"order":"12345678903","status":"PROCESSED","accrual":500}`,
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Invalid payload",
+			payload:    `{"order":`,
+			signature:  sign(`{"order":`),
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Order not found",
+			payload:    `{"order":"12345678903","status":"PROCESSED","accrual":500}`,
+			signature:  sign(`{"order":"12345678903","status":"PROCESSED","accrual":500}`),
+			statusCode: http.StatusNotFound,
+			mockExpect: []interface{}{order.ErrNotFound},
+		},
+		{
+			name:       "Already processed",
+			payload:    `{"order":"12345678903","status":"PROCESSED","accrual":500}`,
+			signature:  sign(`{"order":"12345678903","status":"PROCESSED","accrual":500}`),
+			statusCode: http.StatusConflict,
+			mockExpect: []interface{}{order.ErrAlreadyProcessed},
+		},
+	}
+	a := New(nil, nil, suite.accrualService, nil, &log.Blackhole{})
+	a.EnableAccrualCallback(secret)
+	router := a.buildRouter()
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/internal/accrual/callback", bytes.NewBufferString(tt.payload))
+		if len(tt.signature) != 0 {
+			r.Header.Set(SignatureHeader, tt.signature)
+		}
+		if len(tt.mockExpect) > 0 {
+			suite.accrualService.EXPECT().ApplyAccrual(gomock.Any(), order.Order{
+				Number:  "12345678903",
+				Status:  order.StatusProcessed,
+				Accrual: &accrual,
+				Payload: []byte(tt.payload),
+			}).Return(tt.mockExpect...)
+		}
+		router.ServeHTTP(w, r)
+		suite.Equal(tt.statusCode, w.Code, tt.name)
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestAccrualCallbackDisabled() {
+	a := New(nil, nil, suite.accrualService, nil, &log.Blackhole{})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/internal/accrual/callback", bytes.NewBufferString(`{}`))
+	a.buildRouter().ServeHTTP(w, r)
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+func (suite *httpAdapterTestSuite) TestAdmin() {
+	const token = "token"
+	accrual := float32(500)
+	tests := []struct {
+		name       string
+		path       string
+		payload    string
+		token      string
+		user       string
+		statusCode int
+		expect     func()
+	}{
+		{
+			name:       "Requeue",
+			path:       "/api/admin/orders/12345678903/requeue",
+			payload:    `{"reason":"accrual system fixed"}`,
+			token:      token,
+			user:       "support",
+			statusCode: http.StatusOK,
+			expect: func() {
+				suite.adminService.EXPECT().RequeueOrder(gomock.Any(), tenant.Default, order.OrderNumber("12345678903"), "support", "accrual system fixed").
+					Return(&order.Order{Number: "12345678903", Status: order.StatusNew}, nil)
+			},
+		},
+		{
+			name:       "Requeue not found",
+			path:       "/api/admin/orders/12345678903/requeue",
+			payload:    `{"reason":"accrual system fixed"}`,
+			token:      token,
+			user:       "support",
+			statusCode: http.StatusNotFound,
+			expect: func() {
+				suite.adminService.EXPECT().RequeueOrder(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, order.ErrNotFound)
+			},
+		},
+		{
+			name:       "Invalid token",
+			path:       "/api/admin/orders/12345678903/requeue",
+			payload:    `{"reason":"accrual system fixed"}`,
+			token:      "invalid",
+			user:       "support",
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Without admin name",
+			path:       "/api/admin/orders/12345678903/requeue",
+			payload:    `{"reason":"accrual system fixed"}`,
+			token:      token,
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Force status",
+			path:       "/api/admin/orders/12345678903/status",
+			payload:    `{"status":"PROCESSED","accrual":500,"reason":"confirmed by partner"}`,
+			token:      token,
+			user:       "support",
+			statusCode: http.StatusOK,
+			expect: func() {
+				suite.adminService.EXPECT().ForceOrderStatus(gomock.Any(), tenant.Default, order.OrderNumber("12345678903"), order.StatusProcessed, &accrual, "support", "confirmed by partner").
+					Return(&order.Order{Number: "12345678903", Status: order.StatusProcessed, Accrual: &accrual}, nil)
+			},
+		},
+		{
+			name:       "Force status without reason",
+			path:       "/api/admin/orders/12345678903/status",
+			payload:    `{"status":"PROCESSED","accrual":500}`,
+			token:      token,
+			user:       "support",
+			statusCode: http.StatusBadRequest,
+			expect: func() {
+				suite.adminService.EXPECT().ForceOrderStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "").Return(nil, admin.ErrReasonRequired)
+			},
+		},
+		{
+			name:       "Adjustment",
+			path:       "/api/admin/adjustments",
+			payload:    `{"login":"u1","amount":-50,"reason":"compensation"}`,
+			token:      token,
+			user:       "support",
+			statusCode: http.StatusOK,
+			expect: func() {
+				suite.adminService.EXPECT().NewAdjustment(gomock.Any(), tenant.Default, "u1", float32(-50), "support", "compensation").
+					Return(&admin.Adjustment{Amount: -50, Reason: "compensation", Admin: "support", Balance: 450}, nil)
+			},
+		},
+		{
+			name:       "Adjustment insufficient balance",
+			path:       "/api/admin/adjustments",
+			payload:    `{"login":"u1","amount":-5000,"reason":"compensation"}`,
+			token:      token,
+			user:       "support",
+			statusCode: http.StatusConflict,
+			expect: func() {
+				suite.adminService.EXPECT().NewAdjustment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, withdraw.ErrInsufficientBalance)
+			},
+		},
+		{
+			name:       "Adjustment user not found",
+			path:       "/api/admin/adjustments",
+			payload:    `{"login":"u3","amount":50,"reason":"compensation"}`,
+			token:      token,
+			user:       "support",
+			statusCode: http.StatusNotFound,
+			expect: func() {
+				suite.adminService.EXPECT().NewAdjustment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, user.ErrNotFound)
+			},
+		},
+		{
+			name:       "Retry dead-letter orders",
+			path:       "/api/admin/orders/dead-letter/retry",
+			payload:    `{"numbers":["12345678903"],"reason":"accrual system fixed"}`,
+			token:      token,
+			user:       "support",
+			statusCode: http.StatusOK,
+			expect: func() {
+				suite.adminService.EXPECT().RetryDeadLetterOrders(gomock.Any(), tenant.Default, []order.OrderNumber{"12345678903"}, "support", "accrual system fixed").
+					Return([]*order.Order{{Number: "12345678903"}}, nil)
+			},
+		},
+		{
+			name:       "Retry dead-letter orders without reason",
+			path:       "/api/admin/orders/dead-letter/retry",
+			payload:    `{}`,
+			token:      token,
+			user:       "support",
+			statusCode: http.StatusBadRequest,
+			expect: func() {
+				suite.adminService.EXPECT().RetryDeadLetterOrders(gomock.Any(), gomock.Any(), gomock.Any(), "support", "").Return(nil, admin.ErrReasonRequired)
+			},
+		},
+	}
+	a := New(nil, nil, nil, nil, &log.Blackhole{})
+	a.EnableAdmin(token, suite.adminService)
+	router := a.buildRouter()
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.payload))
+		r.Header.Set(AdminTokenHeader, tt.token)
+		r.Header.Set(AdminUserHeader, tt.user)
+		if tt.expect != nil {
+			tt.expect()
+		}
+		router.ServeHTTP(w, r)
+		suite.Equal(tt.statusCode, w.Code, tt.name)
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestRewardRules() {
+	const token = "token"
+	rule := reward.Rule{Name: "Чайники Bork", Merchant: "Bork", Match: "чайник", Reward: 10, RewardType: reward.TypePercent, MaxReward: 500}
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		payload    string
+		statusCode int
+		body       string
+		expect     func()
+	}{
+		{
+			name:       "List",
+			method:     http.MethodGet,
+			path:       "/api/admin/rewards",
+			statusCode: http.StatusOK,
+			body:       `[{"id":1,"name":"Чайники Bork","merchant":"Bork","match":"чайник","reward":10,"reward_type":"%","max_reward":500,"created_at":"0001-01-01T00:00:00Z"}]`,
+			expect: func() {
+				created := rule
+				created.ID = 1
+				suite.adminService.EXPECT().GetRewardRules(gomock.Any(), tenant.Default).Return([]*reward.Rule{&created}, nil)
+			},
+		},
+		{
+			name:       "Empty list",
+			method:     http.MethodGet,
+			path:       "/api/admin/rewards",
+			statusCode: http.StatusNoContent,
+			expect: func() {
+				suite.adminService.EXPECT().GetRewardRules(gomock.Any(), tenant.Default).Return([]*reward.Rule{}, nil)
+			},
+		},
+		{
+			name:       "Create",
+			method:     http.MethodPost,
+			path:       "/api/admin/rewards",
+			payload:    `{"name":"Чайники Bork","merchant":"Bork","match":"чайник","reward":10,"reward_type":"%","max_reward":500,"reason":"new partner"}`,
+			statusCode: http.StatusOK,
+			expect: func() {
+				created := rule
+				created.ID = 1
+				suite.adminService.EXPECT().NewRewardRule(gomock.Any(), tenant.Default, rule, "support", "new partner").Return(&created, nil)
+			},
+		},
+		{
+			name:       "Create invalid rule",
+			method:     http.MethodPost,
+			path:       "/api/admin/rewards",
+			payload:    `{"name":"Чайники Bork","reward":10,"reward_type":"x","reason":"new partner"}`,
+			statusCode: http.StatusBadRequest,
+			expect: func() {
+				suite.adminService.EXPECT().NewRewardRule(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, reward.ErrInvalidRule)
+			},
+		},
+		{
+			name:       "Update",
+			method:     http.MethodPut,
+			path:       "/api/admin/rewards/1",
+			payload:    `{"name":"Чайники Bork","merchant":"Bork","match":"чайник","reward":10,"reward_type":"%","max_reward":500,"reason":"new terms"}`,
+			statusCode: http.StatusOK,
+			expect: func() {
+				updated := rule
+				updated.ID = 1
+				suite.adminService.EXPECT().UpdateRewardRule(gomock.Any(), tenant.Default, updated, "support", "new terms").Return(&updated, nil)
+			},
+		},
+		{
+			name:       "Update not found",
+			method:     http.MethodPut,
+			path:       "/api/admin/rewards/2",
+			payload:    `{"name":"Чайники Bork","reward":10,"reward_type":"%","reason":"new terms"}`,
+			statusCode: http.StatusNotFound,
+			expect: func() {
+				suite.adminService.EXPECT().UpdateRewardRule(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, reward.ErrNotFound)
+			},
+		},
+		{
+			name:       "Update invalid id",
+			method:     http.MethodPut,
+			path:       "/api/admin/rewards/abc",
+			payload:    `{}`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Delete",
+			method:     http.MethodDelete,
+			path:       "/api/admin/rewards/1",
+			payload:    `{"reason":"partnership ended"}`,
+			statusCode: http.StatusOK,
+			expect: func() {
+				suite.adminService.EXPECT().DeleteRewardRule(gomock.Any(), tenant.Default, reward.ID(1), "support", "partnership ended").Return(nil)
+			},
+		},
+	}
+	a := New(nil, nil, nil, nil, &log.Blackhole{})
+	a.EnableAdmin(token, suite.adminService)
+	router := a.buildRouter()
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.payload))
+		r.Header.Set(AdminTokenHeader, token)
+		r.Header.Set(AdminUserHeader, "support")
+		if tt.expect != nil {
+			tt.expect()
+		}
+		router.ServeHTTP(w, r)
+		suite.Equal(tt.statusCode, w.Code, tt.name)
+		if len(tt.body) != 0 {
+			suite.JSONEq(tt.body, w.Body.String(), tt.name)
+		}
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestGetDeadLetterOrders() {
+	const token = "token"
+	uploadedAt := time.Date(2020, 12, 10, 15, 15, 45, 0, time.UTC)
+	deadLetteredAt := uploadedAt.Add(3 * time.Hour)
+	a := New(nil, nil, nil, nil, &log.Blackhole{})
+	a.EnableAdmin(token, suite.adminService)
+	router := a.buildRouter()
+
+	suite.adminService.EXPECT().GetDeadLetterOrders(gomock.Any(), tenant.Default).Return([]*order.Order{
+		{
+			Number:         "12345678903",
+			Status:         order.StatusProcessing,
+			Failures:       10,
+			LastError:      "invalid accrual system response",
+			UploadedAt:     uploadedAt,
+			DeadLetteredAt: &deadLetteredAt,
+		},
+	}, nil)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/admin/orders/dead-letter", nil)
+	r.Header.Set(AdminTokenHeader, token)
+	r.Header.Set(AdminUserHeader, "support")
+	router.ServeHTTP(w, r)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.JSONEq(`[{
+		"number": "12345678903",
+		"status": "PROCESSING",
+		"failures": 10,
+		"last_error": "invalid accrual system response",
+		"uploaded_at": "2020-12-10T15:15:45Z",
+		"dead_lettered_at": "2020-12-10T18:15:45Z"
+	}]`, w.Body.String())
+
+	suite.adminService.EXPECT().GetDeadLetterOrders(gomock.Any(), tenant.Default).Return(nil, nil)
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/admin/orders/dead-letter", nil)
+	r.Header.Set(AdminTokenHeader, token)
+	r.Header.Set(AdminUserHeader, "support")
+	router.ServeHTTP(w, r)
+	suite.Equal(http.StatusNoContent, w.Code)
+}
+
+func (suite *httpAdapterTestSuite) TestAdminDisabled() {
+	a := New(nil, nil, nil, nil, &log.Blackhole{})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/admin/adjustments", bytes.NewBufferString(`{}`))
+	r.Header.Set(AdminTokenHeader, "")
+	a.buildRouter().ServeHTTP(w, r)
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+func (suite *httpAdapterTestSuite) TestListenAndServeDrainsRequests() {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	addr := l.Addr().String()
+	l.Close()
+
+	started := make(chan struct{})
+	suite.accrualMonitor.EXPECT().CircuitStates().DoAndReturn(func() map[string]accrual.CircuitState {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		return map[string]accrual.CircuitState{}
+	})
+	a := New(nil, nil, nil, suite.accrualMonitor, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := a.ListenAndServe(ctx, addr)
+
+	code := make(chan int, 1)
+	go func() {
+		var (
+			resp *http.Response
+			err  error
+		)
+		// сервер запускается асинхронно
+		for i := 0; i < 50; i++ {
+			if resp, err = http.Get("http://" + addr + "/api/health"); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err != nil {
+			code <- 0
+			return
+		}
+		resp.Body.Close()
+		code <- resp.StatusCode
+	}()
+	<-started
+	cancel()
+	// сервер останавливается только после обработки принятого запроса
+	select {
+	case <-done:
+		suite.Fail("server stopped before the request was handled")
+	case <-time.After(50 * time.Millisecond):
+	}
+	suite.Equal(http.StatusOK, <-code)
+	select {
+	case <-done:
+	case <-time.After(DefaultCloseTimeout):
+		suite.Fail("server was not stopped")
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestListenAndServeFailed() {
+	a := New(nil, nil, nil, nil, &log.Blackhole{})
+	done := a.ListenAndServe(context.Background(), "invalid address")
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		suite.Fail("done is not closed when the server failed to start")
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestTenants() {
+	directory, err := tenant.NewDirectory(
+		tenant.Tenant{ID: "bork", Hosts: []string{"bonus.bork.ru"}},
+		tenant.Tenant{ID: "acme"},
+	)
+	suite.Require().NoError(err)
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		host       string
+		header     string
+		token      string
+		statusCode int
+		expect     func()
+	}{
+		{
+			name:       "Register by host",
+			method:     http.MethodPost,
+			path:       "/api/user/register",
+			host:       "bonus.bork.ru:8080",
+			statusCode: http.StatusOK,
+			expect: func() {
+				suite.authService.EXPECT().Register(gomock.Any(), user.User{Login: "u1", Password: "p1", TenantID: "bork"}).Return("token", nil)
+			},
+		},
+		{
+			name:       "Login by header",
+			method:     http.MethodPost,
+			path:       "/api/user/login",
+			host:       "bonus.bork.ru",
+			header:     "acme",
+			statusCode: http.StatusOK,
+			expect: func() {
+				suite.authService.EXPECT().Login(gomock.Any(), user.User{Login: "u1", Password: "p1", TenantID: "acme"}).Return("token", nil)
+			},
+		},
+		{
+			name:       "Login to default tenant",
+			method:     http.MethodPost,
+			path:       "/api/user/login",
+			host:       "localhost:8080",
+			statusCode: http.StatusOK,
+			expect: func() {
+				suite.authService.EXPECT().Login(gomock.Any(), user.User{Login: "u1", Password: "p1", TenantID: tenant.Default}).Return("token", nil)
+			},
+		},
+		{
+			name:       "Unknown tenant",
+			method:     http.MethodPost,
+			path:       "/api/user/login",
+			header:     "unknown",
+			statusCode: http.StatusNotFound,
+			expect:     func() {},
+		},
+		{
+			name:       "Token of the same tenant",
+			method:     http.MethodGet,
+			path:       "/api/user/balance",
+			host:       "bonus.bork.ru",
+			token:      "bork-token",
+			statusCode: http.StatusOK,
+			expect: func() {
+				suite.authService.EXPECT().Authorize("bork-token").Return(user.PrivateClaims{ID: 1, Login: "u1", Tenant: "bork"}, nil)
+				suite.accountService.EXPECT().GetUserBalance(gomock.Any(), user.ID(1)).Return(user.Balance{}, nil)
+			},
+		},
+		{
+			name:       "Token of another tenant",
+			method:     http.MethodGet,
+			path:       "/api/user/balance",
+			host:       "localhost",
+			token:      "bork-token",
+			statusCode: http.StatusUnauthorized,
+			expect: func() {
+				suite.authService.EXPECT().Authorize("bork-token").Return(user.PrivateClaims{ID: 1, Login: "u1", Tenant: "bork"}, nil)
+			},
+		},
+		{
+			name:       "Token issued before tenants",
+			method:     http.MethodGet,
+			path:       "/api/user/balance",
+			host:       "localhost",
+			token:      "old-token",
+			statusCode: http.StatusOK,
+			expect: func() {
+				suite.authService.EXPECT().Authorize("old-token").Return(user.PrivateClaims{ID: 2, Login: "u2"}, nil)
+				suite.accountService.EXPECT().GetUserBalance(gomock.Any(), user.ID(2)).Return(user.Balance{}, nil)
+			},
+		},
+	}
+	a := New(suite.authService, suite.accountService, suite.accrualService, suite.accrualMonitor, &log.Blackhole{})
+	a.EnableTenants(directory)
+	router := a.buildRouter()
+	for _, tt := range tests {
+		tt.expect()
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(`{"login":"u1","password":"p1"}`))
+		if len(tt.host) != 0 {
+			r.Host = tt.host
+		}
+		if len(tt.header) != 0 {
+			r.Header.Set(TenantHeader, tt.header)
+		}
+		if len(tt.token) != 0 {
+			r.Header.Set("Authorization", tt.token)
+		}
+		router.ServeHTTP(w, r)
+		suite.Equal(tt.statusCode, w.Code, tt.name)
+	}
+}
+
+func (suite *httpAdapterTestSuite) TestAdminTenants() {
+	const token = "token"
+	directory, err := tenant.NewDirectory(tenant.Tenant{ID: "bork", Hosts: []string{"bonus.bork.ru"}})
+	suite.Require().NoError(err)
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		host       string
+		header     string
+		payload    string
+		statusCode int
+		expect     func()
+	}{
+		{
+			name:       "Reward rules by header",
+			method:     http.MethodGet,
+			path:       "/api/admin/rewards",
+			header:     "bork",
+			statusCode: http.StatusNoContent,
+			expect: func() {
+				suite.adminService.EXPECT().GetRewardRules(gomock.Any(), tenant.ID("bork")).Return([]*reward.Rule{}, nil)
+			},
+		},
+		{
+			name:       "Dead-letter orders by host",
+			method:     http.MethodGet,
+			path:       "/api/admin/orders/dead-letter",
+			host:       "bonus.bork.ru",
+			statusCode: http.StatusNoContent,
+			expect: func() {
+				suite.adminService.EXPECT().GetDeadLetterOrders(gomock.Any(), tenant.ID("bork")).Return(nil, nil)
+			},
+		},
+		{
+			name:       "Requeue in tenant",
+			method:     http.MethodPost,
+			path:       "/api/admin/orders/12345678903/requeue",
+			header:     "bork",
+			payload:    `{"reason":"accrual system fixed"}`,
+			statusCode: http.StatusNotFound,
+			expect: func() {
+				suite.adminService.EXPECT().RequeueOrder(gomock.Any(), tenant.ID("bork"), order.OrderNumber("12345678903"), "support", "accrual system fixed").
+					Return(nil, order.ErrNotFound)
+			},
+		},
+		{
+			name:       "Unknown tenant",
+			method:     http.MethodPost,
+			path:       "/api/admin/rewards",
+			header:     "unknown",
+			payload:    `{"name":"r","reward":1,"reward_type":"pt","reason":"new partner"}`,
+			statusCode: http.StatusNotFound,
+			expect:     func() {},
+		},
+	}
+	a := New(nil, nil, nil, nil, &log.Blackhole{})
+	a.EnableAdmin(token, suite.adminService)
+	a.EnableTenants(directory)
+	router := a.buildRouter()
+	for _, tt := range tests {
+		tt.expect()
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.payload))
+		r.Header.Set(AdminTokenHeader, token)
+		r.Header.Set(AdminUserHeader, "support")
+		if len(tt.host) != 0 {
+			r.Host = tt.host
+		}
+		if len(tt.header) != 0 {
+			r.Header.Set(TenantHeader, tt.header)
+		}
+		router.ServeHTTP(w, r)
+		suite.Equal(tt.statusCode, w.Code, tt.name)
 	}
 }
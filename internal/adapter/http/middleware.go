@@ -1,11 +1,19 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/k1nky/gophermart/internal/entity/tenant"
 	"github.com/k1nky/gophermart/internal/entity/user"
 )
 
@@ -13,6 +21,21 @@ type contextKey int
 
 const (
 	keyUserClaims contextKey = iota
+	keyAdmin
+	keyTenant
+)
+
+const (
+	// заголовок с подписью тела запроса: hex(HMAC-SHA256(ключ, тело запроса)), допускается префикс `sha256=`
+	SignatureHeader = "X-Accrual-Signature"
+	// максимальный размер подписываемого тела запроса
+	MaxSignedBodySize = 1 << 20
+	// заголовок с токеном администратора
+	AdminTokenHeader = "X-Admin-Token"
+	// заголовок с именем администратора, от имени которого действия записываются в журнал действий администраторов
+	AdminUserHeader = "X-Admin-User"
+	// заголовок с идентификатором программы лояльности
+	TenantHeader = "X-Tenant-ID"
 )
 
 type loggingWriter struct {
@@ -25,6 +48,47 @@ func (bw *loggingWriter) WriteHeader(statusCode int) {
 	bw.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Возвращает исходный http.ResponseWriter для http.ResponseController
+func (bw *loggingWriter) Unwrap() http.ResponseWriter {
+	return bw.ResponseWriter
+}
+
+// Возвращает программу лояльности запроса, см. TenantMiddleware
+func requestTenant(r *http.Request) tenant.ID {
+	if id, ok := r.Context().Value(keyTenant).(tenant.ID); ok {
+		return id
+	}
+	return tenant.Default
+}
+
+// Определяет программу лояльности запроса из справочника d: по заголовку TenantHeader, а если он не указан, -
+// по имени хоста запроса. Запросы с неизвестной программой в заголовке отклоняются с кодом 404, запросы
+// к хосту без программы относятся к программе по умолчанию. Если d равен nil, все запросы относятся
+// к программе по умолчанию.
+func TenantMiddleware(d *tenant.Directory) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := tenant.Default
+			if d != nil {
+				if header := r.Header.Get(TenantHeader); len(header) != 0 {
+					t, ok := d.Get(tenant.ID(header))
+					if !ok {
+						http.Error(w, "", http.StatusNotFound)
+						return
+					}
+					id = t.ID
+				} else if t, ok := d.ByHost(r.Host); ok {
+					id = t.ID
+				}
+			}
+			ctx := context.WithValue(r.Context(), keyTenant, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Проверяет токен пользователя. Запросы без токена, с неверным токеном или с токеном пользователя
+// другой программы лояльности отклоняются с кодом 401.
 func AuthorizeMiddleware(auth authService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -42,6 +106,10 @@ func AuthorizeMiddleware(auth authService) func(http.Handler) http.Handler {
 				}
 				return
 			}
+			if claims.Tenant.OrDefault() != requestTenant(r) {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
 			ctx := context.WithValue(r.Context(), keyUserClaims, claims)
 			newRequest := r.WithContext(ctx)
 			next.ServeHTTP(w, newRequest)
@@ -49,6 +117,53 @@ func AuthorizeMiddleware(auth authService) func(http.Handler) http.Handler {
 	}
 }
 
+// Проверяет подпись тела запроса ключом secret. Запросы без подписи или с неверной подписью отклоняются с кодом 401.
+func SignatureMiddleware(secret []byte) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			signature, err := hex.DecodeString(strings.TrimPrefix(r.Header.Get(SignatureHeader), "sha256="))
+			if err != nil || len(signature) == 0 {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxSignedBodySize))
+			if err != nil {
+				http.Error(w, "", http.StatusBadRequest)
+				return
+			}
+			mac := hmac.New(sha256.New, secret)
+			mac.Write(body)
+			if !hmac.Equal(signature, mac.Sum(nil)) {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Проверяет токен администратора token. Запросы без токена, с неверным токеном или без имени администратора
+// отклоняются с кодом 401.
+func AdminMiddleware(token []byte) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := []byte(r.Header.Get(AdminTokenHeader))
+			if subtle.ConstantTimeCompare(got, token) != 1 {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+			name := strings.TrimSpace(r.Header.Get(AdminUserHeader))
+			if len(name) == 0 {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+			ctx := context.WithValue(r.Context(), keyAdmin, name)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 func LoggingMiddleware(l logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
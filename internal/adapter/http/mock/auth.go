@@ -7,9 +7,16 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	accrual "github.com/k1nky/gophermart/internal/entity/accrual"
+	admin "github.com/k1nky/gophermart/internal/entity/admin"
 	order "github.com/k1nky/gophermart/internal/entity/order"
+	promo "github.com/k1nky/gophermart/internal/entity/promo"
+	reward "github.com/k1nky/gophermart/internal/entity/reward"
+	statement "github.com/k1nky/gophermart/internal/entity/statement"
+	tenant "github.com/k1nky/gophermart/internal/entity/tenant"
 	user "github.com/k1nky/gophermart/internal/entity/user"
 	withdraw "github.com/k1nky/gophermart/internal/entity/withdraw"
 )
@@ -120,6 +127,36 @@ func (mr *MockaccountServiceMockRecorder) GetUserBalance(ctx, userID interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserBalance", reflect.TypeOf((*MockaccountService)(nil).GetUserBalance), ctx, userID)
 }
 
+// GetUserBalanceAt mocks base method.
+func (m *MockaccountService) GetUserBalanceAt(ctx context.Context, userID user.ID, at time.Time) (user.Balance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserBalanceAt", ctx, userID, at)
+	ret0, _ := ret[0].(user.Balance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserBalanceAt indicates an expected call of GetUserBalanceAt.
+func (mr *MockaccountServiceMockRecorder) GetUserBalanceAt(ctx, userID, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserBalanceAt", reflect.TypeOf((*MockaccountService)(nil).GetUserBalanceAt), ctx, userID, at)
+}
+
+// GetUserOrderTimeline mocks base method.
+func (m *MockaccountService) GetUserOrderTimeline(ctx context.Context, userID user.ID, number order.OrderNumber) (*order.Timeline, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrderTimeline", ctx, userID, number)
+	ret0, _ := ret[0].(*order.Timeline)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserOrderTimeline indicates an expected call of GetUserOrderTimeline.
+func (mr *MockaccountServiceMockRecorder) GetUserOrderTimeline(ctx, userID, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrderTimeline", reflect.TypeOf((*MockaccountService)(nil).GetUserOrderTimeline), ctx, userID, number)
+}
+
 // GetUserOrders mocks base method.
 func (m *MockaccountService) GetUserOrders(ctx context.Context, userID user.ID) ([]*order.Order, error) {
 	m.ctrl.T.Helper()
@@ -135,6 +172,20 @@ func (mr *MockaccountServiceMockRecorder) GetUserOrders(ctx, userID interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrders", reflect.TypeOf((*MockaccountService)(nil).GetUserOrders), ctx, userID)
 }
 
+// GetUserStatement mocks base method.
+func (m *MockaccountService) GetUserStatement(ctx context.Context, userID user.ID, from, to time.Time, fn func(*statement.Entry) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserStatement", ctx, userID, from, to, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetUserStatement indicates an expected call of GetUserStatement.
+func (mr *MockaccountServiceMockRecorder) GetUserStatement(ctx, userID, from, to, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserStatement", reflect.TypeOf((*MockaccountService)(nil).GetUserStatement), ctx, userID, from, to, fn)
+}
+
 // GetUserWithdrawals mocks base method.
 func (m *MockaccountService) GetUserWithdrawals(ctx context.Context, userID user.ID) ([]*withdraw.Withdraw, error) {
 	m.ctrl.T.Helper()
@@ -165,6 +216,21 @@ func (mr *MockaccountServiceMockRecorder) NewOrder(ctx, o interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewOrder", reflect.TypeOf((*MockaccountService)(nil).NewOrder), ctx, o)
 }
 
+// NewOrders mocks base method.
+func (m *MockaccountService) NewOrders(ctx context.Context, userID user.ID, tenantID tenant.ID, numbers []order.OrderNumber) ([]order.BatchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewOrders", ctx, userID, tenantID, numbers)
+	ret0, _ := ret[0].([]order.BatchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewOrders indicates an expected call of NewOrders.
+func (mr *MockaccountServiceMockRecorder) NewOrders(ctx, userID, tenantID, numbers interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewOrders", reflect.TypeOf((*MockaccountService)(nil).NewOrders), ctx, userID, tenantID, numbers)
+}
+
 // NewWithdraw mocks base method.
 func (m *MockaccountService) NewWithdraw(ctx context.Context, w withdraw.Withdraw) error {
 	m.ctrl.T.Helper()
@@ -179,6 +245,281 @@ func (mr *MockaccountServiceMockRecorder) NewWithdraw(ctx, w interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewWithdraw", reflect.TypeOf((*MockaccountService)(nil).NewWithdraw), ctx, w)
 }
 
+// RedeemPromo mocks base method.
+func (m *MockaccountService) RedeemPromo(ctx context.Context, userID user.ID, code promo.Code) (*promo.Redemption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeemPromo", ctx, userID, code)
+	ret0, _ := ret[0].(*promo.Redemption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeemPromo indicates an expected call of RedeemPromo.
+func (mr *MockaccountServiceMockRecorder) RedeemPromo(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemPromo", reflect.TypeOf((*MockaccountService)(nil).RedeemPromo), ctx, userID, code)
+}
+
+// MockadminService is a mock of adminService interface.
+type MockadminService struct {
+	ctrl     *gomock.Controller
+	recorder *MockadminServiceMockRecorder
+}
+
+// MockadminServiceMockRecorder is the mock recorder for MockadminService.
+type MockadminServiceMockRecorder struct {
+	mock *MockadminService
+}
+
+// NewMockadminService creates a new mock instance.
+func NewMockadminService(ctrl *gomock.Controller) *MockadminService {
+	mock := &MockadminService{ctrl: ctrl}
+	mock.recorder = &MockadminServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockadminService) EXPECT() *MockadminServiceMockRecorder {
+	return m.recorder
+}
+
+// DeleteRewardRule mocks base method.
+func (m *MockadminService) DeleteRewardRule(ctx context.Context, tenantID tenant.ID, id reward.ID, by, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRewardRule", ctx, tenantID, id, by, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRewardRule indicates an expected call of DeleteRewardRule.
+func (mr *MockadminServiceMockRecorder) DeleteRewardRule(ctx, tenantID, id, by, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRewardRule", reflect.TypeOf((*MockadminService)(nil).DeleteRewardRule), ctx, tenantID, id, by, reason)
+}
+
+// ForceOrderStatus mocks base method.
+func (m *MockadminService) ForceOrderStatus(ctx context.Context, tenantID tenant.ID, number order.OrderNumber, status order.OrderStatus, accrual *float32, by, reason string) (*order.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceOrderStatus", ctx, tenantID, number, status, accrual, by, reason)
+	ret0, _ := ret[0].(*order.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForceOrderStatus indicates an expected call of ForceOrderStatus.
+func (mr *MockadminServiceMockRecorder) ForceOrderStatus(ctx, tenantID, number, status, accrual, by, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceOrderStatus", reflect.TypeOf((*MockadminService)(nil).ForceOrderStatus), ctx, tenantID, number, status, accrual, by, reason)
+}
+
+// GetDeadLetterOrders mocks base method.
+func (m *MockadminService) GetDeadLetterOrders(ctx context.Context, tenantID tenant.ID) ([]*order.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadLetterOrders", ctx, tenantID)
+	ret0, _ := ret[0].([]*order.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLetterOrders indicates an expected call of GetDeadLetterOrders.
+func (mr *MockadminServiceMockRecorder) GetDeadLetterOrders(ctx, tenantID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadLetterOrders", reflect.TypeOf((*MockadminService)(nil).GetDeadLetterOrders), ctx, tenantID)
+}
+
+// GetRewardRules mocks base method.
+func (m *MockadminService) GetRewardRules(ctx context.Context, tenantID tenant.ID) ([]*reward.Rule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRewardRules", ctx, tenantID)
+	ret0, _ := ret[0].([]*reward.Rule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRewardRules indicates an expected call of GetRewardRules.
+func (mr *MockadminServiceMockRecorder) GetRewardRules(ctx, tenantID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRewardRules", reflect.TypeOf((*MockadminService)(nil).GetRewardRules), ctx, tenantID)
+}
+
+// NewAdjustment mocks base method.
+func (m *MockadminService) NewAdjustment(ctx context.Context, tenantID tenant.ID, login string, amount float32, by, reason string) (*admin.Adjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewAdjustment", ctx, tenantID, login, amount, by, reason)
+	ret0, _ := ret[0].(*admin.Adjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewAdjustment indicates an expected call of NewAdjustment.
+func (mr *MockadminServiceMockRecorder) NewAdjustment(ctx, tenantID, login, amount, by, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewAdjustment", reflect.TypeOf((*MockadminService)(nil).NewAdjustment), ctx, tenantID, login, amount, by, reason)
+}
+
+// NewPromo mocks base method.
+func (m *MockadminService) NewPromo(ctx context.Context, tenantID tenant.ID, p promo.Promo, by, reason string) (*promo.Promo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewPromo", ctx, tenantID, p, by, reason)
+	ret0, _ := ret[0].(*promo.Promo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewPromo indicates an expected call of NewPromo.
+func (mr *MockadminServiceMockRecorder) NewPromo(ctx, tenantID, p, by, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewPromo", reflect.TypeOf((*MockadminService)(nil).NewPromo), ctx, tenantID, p, by, reason)
+}
+
+// NewRewardRule mocks base method.
+func (m *MockadminService) NewRewardRule(ctx context.Context, tenantID tenant.ID, rule reward.Rule, by, reason string) (*reward.Rule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewRewardRule", ctx, tenantID, rule, by, reason)
+	ret0, _ := ret[0].(*reward.Rule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewRewardRule indicates an expected call of NewRewardRule.
+func (mr *MockadminServiceMockRecorder) NewRewardRule(ctx, tenantID, rule, by, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewRewardRule", reflect.TypeOf((*MockadminService)(nil).NewRewardRule), ctx, tenantID, rule, by, reason)
+}
+
+// RequeueOrder mocks base method.
+func (m *MockadminService) RequeueOrder(ctx context.Context, tenantID tenant.ID, number order.OrderNumber, by, reason string) (*order.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueOrder", ctx, tenantID, number, by, reason)
+	ret0, _ := ret[0].(*order.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequeueOrder indicates an expected call of RequeueOrder.
+func (mr *MockadminServiceMockRecorder) RequeueOrder(ctx, tenantID, number, by, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueOrder", reflect.TypeOf((*MockadminService)(nil).RequeueOrder), ctx, tenantID, number, by, reason)
+}
+
+// RetryDeadLetterOrders mocks base method.
+func (m *MockadminService) RetryDeadLetterOrders(ctx context.Context, tenantID tenant.ID, numbers []order.OrderNumber, by, reason string) ([]*order.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryDeadLetterOrders", ctx, tenantID, numbers, by, reason)
+	ret0, _ := ret[0].([]*order.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetryDeadLetterOrders indicates an expected call of RetryDeadLetterOrders.
+func (mr *MockadminServiceMockRecorder) RetryDeadLetterOrders(ctx, tenantID, numbers, by, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryDeadLetterOrders", reflect.TypeOf((*MockadminService)(nil).RetryDeadLetterOrders), ctx, tenantID, numbers, by, reason)
+}
+
+// UpdateRewardRule mocks base method.
+func (m *MockadminService) UpdateRewardRule(ctx context.Context, tenantID tenant.ID, rule reward.Rule, by, reason string) (*reward.Rule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRewardRule", ctx, tenantID, rule, by, reason)
+	ret0, _ := ret[0].(*reward.Rule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRewardRule indicates an expected call of UpdateRewardRule.
+func (mr *MockadminServiceMockRecorder) UpdateRewardRule(ctx, tenantID, rule, by, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRewardRule", reflect.TypeOf((*MockadminService)(nil).UpdateRewardRule), ctx, tenantID, rule, by, reason)
+}
+
+// MockaccrualService is a mock of accrualService interface.
+type MockaccrualService struct {
+	ctrl     *gomock.Controller
+	recorder *MockaccrualServiceMockRecorder
+}
+
+// MockaccrualServiceMockRecorder is the mock recorder for MockaccrualService.
+type MockaccrualServiceMockRecorder struct {
+	mock *MockaccrualService
+}
+
+// NewMockaccrualService creates a new mock instance.
+func NewMockaccrualService(ctrl *gomock.Controller) *MockaccrualService {
+	mock := &MockaccrualService{ctrl: ctrl}
+	mock.recorder = &MockaccrualServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockaccrualService) EXPECT() *MockaccrualServiceMockRecorder {
+	return m.recorder
+}
+
+// ApplyAccrual mocks base method.
+func (m *MockaccrualService) ApplyAccrual(ctx context.Context, o order.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyAccrual", ctx, o)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyAccrual indicates an expected call of ApplyAccrual.
+func (mr *MockaccrualServiceMockRecorder) ApplyAccrual(ctx, o interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyAccrual", reflect.TypeOf((*MockaccrualService)(nil).ApplyAccrual), ctx, o)
+}
+
+// MockaccrualMonitor is a mock of accrualMonitor interface.
+type MockaccrualMonitor struct {
+	ctrl     *gomock.Controller
+	recorder *MockaccrualMonitorMockRecorder
+}
+
+// MockaccrualMonitorMockRecorder is the mock recorder for MockaccrualMonitor.
+type MockaccrualMonitorMockRecorder struct {
+	mock *MockaccrualMonitor
+}
+
+// NewMockaccrualMonitor creates a new mock instance.
+func NewMockaccrualMonitor(ctrl *gomock.Controller) *MockaccrualMonitor {
+	mock := &MockaccrualMonitor{ctrl: ctrl}
+	mock.recorder = &MockaccrualMonitorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockaccrualMonitor) EXPECT() *MockaccrualMonitorMockRecorder {
+	return m.recorder
+}
+
+// CircuitStates mocks base method.
+func (m *MockaccrualMonitor) CircuitStates() map[string]accrual.CircuitState {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CircuitStates")
+	ret0, _ := ret[0].(map[string]accrual.CircuitState)
+	return ret0
+}
+
+// CircuitStates indicates an expected call of CircuitStates.
+func (mr *MockaccrualMonitorMockRecorder) CircuitStates() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CircuitStates", reflect.TypeOf((*MockaccrualMonitor)(nil).CircuitStates))
+}
+
+// FetchCounters mocks base method.
+func (m *MockaccrualMonitor) FetchCounters() map[string]map[string]uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchCounters")
+	ret0, _ := ret[0].(map[string]map[string]uint64)
+	return ret0
+}
+
+// FetchCounters indicates an expected call of FetchCounters.
+func (mr *MockaccrualMonitorMockRecorder) FetchCounters() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchCounters", reflect.TypeOf((*MockaccrualMonitor)(nil).FetchCounters))
+}
+
 // Mocklogger is a mock of logger interface.
 type Mocklogger struct {
 	ctrl     *gomock.Controller
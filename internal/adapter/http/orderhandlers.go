@@ -1,16 +1,31 @@
 package http
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"errors"
 	"net/http"
+	"strings"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/k1nky/gophermart/internal/entity/order"
 	"github.com/k1nky/gophermart/internal/entity/user"
 )
 
+type newOrderRequest struct {
+	Number   order.OrderNumber `json:"number"`
+	Total    *float32          `json:"total"`
+	Currency string            `json:"currency"`
+	Merchant string            `json:"merchant"`
+	Items    []order.Item      `json:"items"`
+}
+
 // Загрузка номера заказа. Хендлер доступен только аутентифицированным пользователям. Номером заказа является последовательность цифр произвольной длины.
 // Номер заказа может быть проверен на корректность ввода с помощью [алгоритма Луна](https://ru.wikipedia.org/wiki/Алгоритм_Луна){target="_blank"}.
+// Схема проверки номера выбирается настройками сервиса по префиксу номера: кроме алгоритма Луна поддерживаются
+// алгоритмы Верхуффа, Дамма и ISO 7064, номера с буквенным префиксом проверяются без учета префикса.
+// Пробельные символы, переводы строк и дефисы в номере игнорируются.
 // Формат запроса:
 // ```
 // POST /api/user/orders HTTP/1.1
@@ -18,10 +33,29 @@ import (
 // ...
 // 12345678903
 // ```
+// Вместе с номером заказа можно передать сведения о покупке: сумму, код валюты по ISO 4217, продавца и позиции.
+// Сведения о покупке сохраняются в заказе и передаются системе расчёта начислений при регистрации заказа.
+// ```
+// POST /api/user/orders HTTP/1.1
+// Content-Type: application/json
+// ...
+//
+//	{
+//		"number": "12345678903",
+//		"total": 47399.99,
+//		"currency": "RUB",
+//		"merchant": "Bork",
+//		"items": [
+//			{"description": "Чайник Bork", "price": 7000},
+//			{"description": "Пылесос Bork", "price": 40399.99}
+//		]
+//	}
+//
+// ```
 // Возможные коды ответа:
 // - `200` — номер заказа уже был загружен этим пользователем;
 // - `202` — новый номер заказа принят в обработку;
-// - `400` — неверный формат запроса;
+// - `400` — неверный формат запроса или неверные сведения о покупке;
 // - `401` — пользователь не аутентифицирован;
 // - `409` — номер заказа уже был загружен другим пользователем;
 // - `422` — неверный формат номера заказа;
@@ -35,18 +69,32 @@ func (a *Adapter) NewOrder(w http.ResponseWriter, r *http.Request) {
 	newOrder := order.Order{
 		UserID: claims.ID,
 	}
-	buf := bytes.NewBuffer(nil)
-	if _, err := buf.ReadFrom(r.Body); err != nil {
-		http.Error(w, "", http.StatusBadRequest)
-		return
-	}
-	newOrder.Number = order.OrderNumber(buf.String())
-	if !newOrder.Number.IsValid() {
-		http.Error(w, "", http.StatusUnprocessableEntity)
-		return
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		request := newOrderRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		newOrder.Number = order.NormalizeNumber(string(request.Number))
+		newOrder.Purchase = request.purchase()
+		if newOrder.Purchase != nil {
+			if err := newOrder.Purchase.Validate(); err != nil {
+				http.Error(w, "", http.StatusBadRequest)
+				return
+			}
+		}
+	} else {
+		buf := bytes.NewBuffer(nil)
+		if _, err := buf.ReadFrom(r.Body); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		newOrder.Number = order.NormalizeNumber(buf.String())
 	}
 	if _, err := a.account.NewOrder(r.Context(), newOrder); err != nil {
-		if errors.Is(err, order.ErrDuplicated) {
+		if errors.Is(err, order.ErrInvalidNumberFormat) {
+			http.Error(w, "", http.StatusUnprocessableEntity)
+		} else if errors.Is(err, order.ErrDuplicated) {
 			http.Error(w, "", http.StatusOK)
 		} else if errors.Is(err, order.ErrBelongsToAnotherUser) {
 			http.Error(w, "", http.StatusConflict)
@@ -58,6 +106,134 @@ func (a *Adapter) NewOrder(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusAccepted)
 }
 
+// Возвращает сведения о покупке из запроса, nil - сведения о покупке не переданы
+func (r newOrderRequest) purchase() *order.Purchase {
+	if r.Total == nil && len(r.Currency) == 0 && len(r.Merchant) == 0 && len(r.Items) == 0 {
+		return nil
+	}
+	p := &order.Purchase{
+		Currency: r.Currency,
+		Merchant: r.Merchant,
+		Items:    r.Items,
+	}
+	if r.Total != nil {
+		p.Total = *r.Total
+	}
+	return p
+}
+
+type batchOrderResponse struct {
+	Number order.OrderNumber  `json:"number"`
+	Result order.UploadResult `json:"result"`
+	// код ответа, который вернула бы загрузка номера по одному
+	Code int `json:"code"`
+}
+
+// Коды ответа загрузки номера заказа по результату загрузки
+var uploadResultCodes = map[order.UploadResult]int{
+	order.UploadAccepted:  http.StatusAccepted,
+	order.UploadDuplicate: http.StatusOK,
+	order.UploadConflict:  http.StatusConflict,
+	order.UploadInvalid:   http.StatusUnprocessableEntity,
+}
+
+// Пакетная загрузка номеров заказов. Хендлер доступен только аутентифицированным пользователям.
+// Номера заказов передаются JSON-массивом строк или списком, разделенным переводами строк.
+// Каждый номер приводится к каноническому виду и проверяется так же, как при загрузке по одному, все корректные новые номера
+// принимаются в обработку в рамках одной транзакции. Пакет содержит не более 1000 номеров.
+// Повторный номер в пакете получает тот же результат, что и его первое вхождение.
+// Формат запроса:
+// ```
+// POST /api/user/orders/batch HTTP/1.1
+// Content-Type: application/json
+// ...
+// ["12345678903", "9278923470"]
+// ```
+// или
+// ```
+// POST /api/user/orders/batch HTTP/1.1
+// Content-Type: text/plain
+// ...
+// 12345678903
+// 9278923470
+// ```
+// Возможные коды ответа:
+//   - `200` — пакет обработан, в ответе результат загрузки каждого номера в порядке номеров в запросе.
+//     Результат загрузки номера и код ответа, который вернула бы загрузка номера по одному:
+//     `accepted` (`202`) — новый номер заказа принят в обработку;
+//     `duplicate` (`200`) — номер заказа уже был загружен этим пользователем;
+//     `conflict` (`409`) — номер заказа уже был загружен другим пользователем;
+//     `invalid` (`422`) — неверный формат номера заказа.
+//     Формат ответа:
+//     ```
+//     200 OK HTTP/1.1
+//     Content-Type: application/json
+//     ...
+//     [
+//     {"number": "12345678903", "result": "accepted", "code": 202},
+//     {"number": "9278923470", "result": "duplicate", "code": 200}
+//     ]
+//     ```
+//   - `400` — неверный формат запроса, пустой пакет или превышен размер пакета;
+//   - `401` — пользователь не аутентифицирован;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) NewOrders(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(keyUserClaims).(user.PrivateClaims)
+	if !ok {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	numbers, err := readOrderNumbers(r)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	items, err := a.account.NewOrders(r.Context(), claims.ID, claims.Tenant.OrDefault(), numbers)
+	if err != nil {
+		if errors.Is(err, order.ErrInvalidBatch) {
+			http.Error(w, "", http.StatusBadRequest)
+		} else {
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	response := make([]batchOrderResponse, 0, len(items))
+	for _, item := range items {
+		response = append(response, batchOrderResponse{
+			Number: item.Number,
+			Result: item.Result,
+			Code:   uploadResultCodes[item.Result],
+		})
+	}
+	if err := a.writeJSON(w, response); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Читает номера заказов из тела запроса: JSON-массив строк, если тип содержимого application/json,
+// иначе список номеров, разделенных переводами строк. Номера приводятся к каноническому виду,
+// пустые строки пропускаются.
+func readOrderNumbers(r *http.Request) ([]order.OrderNumber, error) {
+	numbers := make([]order.OrderNumber, 0)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&numbers); err != nil {
+			return nil, err
+		}
+		for i, number := range numbers {
+			numbers[i] = order.NormalizeNumber(string(number))
+		}
+		return numbers, nil
+	}
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		if number := order.NormalizeNumber(scanner.Text()); len(number) != 0 {
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers, scanner.Err()
+}
+
 // Получение списка загруженных номеров заказов. Хендлер доступен только авторизованному пользователю. Номера заказа в выдаче должны быть отсортированы по времени загрузки от самых старых к самым новым. Формат даты — RFC3339.
 // Доступные статусы обработки расчётов:
 // - `NEW` — заказ загружен в систему, но не попал в обработку;
@@ -86,7 +262,13 @@ func (a *Adapter) NewOrder(w http.ResponseWriter, r *http.Request) {
 //     {
 //     "number": "12345678903",
 //     "status": "PROCESSING",
-//     "uploaded_at": "2020-12-10T15:12:01+03:00"
+//     "uploaded_at": "2020-12-10T15:12:01+03:00",
+//     "purchase": {
+//     "total": 7000,
+//     "currency": "RUB",
+//     "merchant": "Bork",
+//     "items": [{"description": "Чайник Bork", "price": 7000}]
+//     }
 //     },
 //     {
 //     "number": "346436439",
@@ -95,6 +277,7 @@ func (a *Adapter) NewOrder(w http.ResponseWriter, r *http.Request) {
 //     }
 //     ]
 //     ```
+//     Здесь `purchase` — сведения о покупке, если они были переданы при загрузке заказа.
 //   - `204` — нет данных для ответа.
 //   - `401` — пользователь не авторизован.
 //   - `500` — внутренняя ошибка сервера.
@@ -119,3 +302,60 @@ func (a *Adapter) GetOrder(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// Получение истории изменения статуса заказа. Хендлер доступен только авторизованному пользователю.
+// События отсортированы по времени изменения от самых старых к самым новым. Формат даты — RFC3339.
+// Источники изменения статуса:
+// - `UPLOAD` — заказ загружен пользователем;
+// - `POLLER` — статус получен при опросе системы расчёта начислений;
+// - `CALLBACK` — статус получен из уведомления системы расчёта начислений;
+// - `ADMIN` — статус изменен администратором.
+// Формат запроса:
+// ```
+// GET /api/user/orders/{number} HTTP/1.1
+// Content-Length: 0
+// ```
+// Возможные коды ответа:
+//   - `200` — успешная обработка запроса.
+//     Формат ответа:
+//     ```
+//     200 OK HTTP/1.1
+//     Content-Type: application/json
+//     ...
+//     {
+//     "number": "9278923470",
+//     "status": "PROCESSED",
+//     "accrual": 500,
+//     "uploaded_at": "2020-12-10T15:15:45+03:00",
+//     "events": [
+//     {"status": "NEW", "source": "UPLOAD", "created_at": "2020-12-10T15:15:45+03:00"},
+//     {"status": "PROCESSING", "source": "POLLER", "provider": "default", "payload": {"order": "9278923470", "status": "PROCESSING"}, "created_at": "2020-12-10T15:15:50+03:00"},
+//     {"status": "PROCESSED", "accrual": 500, "source": "CALLBACK", "payload": {"order": "9278923470", "status": "PROCESSED", "accrual": 500}, "created_at": "2020-12-10T15:16:01+03:00"}
+//     ]
+//     }
+//     ```
+//     Здесь `payload` — ответ системы расчёта начислений, на основании которого изменен статус.
+//   - `401` — пользователь не авторизован.
+//   - `404` — заказ не найден или загружен другим пользователем.
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) GetOrderTimeline(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(keyUserClaims).(user.PrivateClaims)
+	if !ok {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	number := order.OrderNumber(chi.URLParam(r, "number"))
+	timeline, err := a.account.GetUserOrderTimeline(r.Context(), claims.ID, number)
+	if err != nil {
+		if errors.Is(err, order.ErrNotFound) {
+			http.Error(w, "", http.StatusNotFound)
+		} else {
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	if err := a.writeJSON(w, timeline); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
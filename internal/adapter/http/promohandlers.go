@@ -0,0 +1,167 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/promo"
+	"github.com/k1nky/gophermart/internal/entity/user"
+)
+
+type newPromoRequest struct {
+	Code           promo.Code `json:"code"`
+	Amount         float32    `json:"amount"`
+	MaxRedemptions uint       `json:"max_redemptions"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	Reason         string     `json:"reason"`
+}
+
+func (r newPromoRequest) promo() promo.Promo {
+	p := promo.Promo{
+		Code:           r.Code,
+		Amount:         r.Amount,
+		MaxRedemptions: r.MaxRedemptions,
+		ValidUntil:     r.ValidUntil,
+	}
+	if r.ValidFrom != nil {
+		p.ValidFrom = *r.ValidFrom
+	}
+	return p
+}
+
+// Погашение промокода. Хендлер доступен только авторизованному пользователю.
+// При успешном погашении на счет пользователя начисляются баллы промокода. Пользователь может погасить промокод только один раз
+// и только промокод своей программы лояльности.
+// Формат запроса:
+// ```
+// POST /api/user/promo HTTP/1.1
+// Content-Type: application/json
+//
+//	{
+//		"code": "<code>"
+//	}
+//
+// ```
+// Возможные коды ответа:
+//   - `200` — промокод успешно погашен.
+//     Формат ответа:
+//     ```
+//     200 OK HTTP/1.1
+//     Content-Type: application/json
+//     ...
+//     {
+//     "code": "WELCOME",
+//     "amount": 100,
+//     "redeemed_at": "2020-12-10T15:15:45+03:00"
+//     }
+//     ```
+//   - `400` — неверный формат запроса;
+//   - `401` — пользователь не авторизован;
+//   - `404` — промокод не найден в программе лояльности пользователя;
+//   - `409` — промокод уже погашен пользователем;
+//   - `410` — срок действия промокода истек или исчерпан лимит погашений;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) RedeemPromo(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(keyUserClaims).(user.PrivateClaims)
+	if !ok {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	request := promo.Redemption{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	if !request.Code.IsValid() {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	redemption, err := a.account.RedeemPromo(r.Context(), claims.ID, request.Code)
+	if err != nil {
+		if errors.Is(err, promo.ErrNotFound) {
+			http.Error(w, "", http.StatusNotFound)
+		} else if errors.Is(err, promo.ErrAlreadyRedeemed) {
+			http.Error(w, "", http.StatusConflict)
+		} else if errors.Is(err, promo.ErrNotActive) || errors.Is(err, promo.ErrExhausted) {
+			http.Error(w, "", http.StatusGone)
+		} else {
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	if err := a.writeJSON(w, redemption); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Создание промокода. Хендлер доступен только администраторам: запрос должен содержать токен
+// администратора в заголовке `X-Admin-Token` и имя администратора в заголовке `X-Admin-User`.
+// При погашении промокода на счет пользователя начисляется `amount` баллов. Необязательные поля:
+// `max_redemptions` - максимальное количество погашений (по умолчанию 1 - одноразовый промокод),
+// `valid_from` и `valid_until` - период действия промокода (по умолчанию с момента создания и бессрочно).
+// Промокод создается в программе лояльности, указанной в заголовке `X-Tenant-ID`, или в программе, к которой
+// относится имя хоста, и погашается только пользователями этой программы. Код промокода уникален в рамках программы.
+// Действие записывается в журнал действий администраторов.
+// Формат запроса:
+// ```
+// POST /api/admin/promo HTTP/1.1
+// Content-Type: application/json
+// X-Admin-Token: <token>
+// X-Admin-User: <name>
+//
+//	{
+//		"code": "WELCOME",
+//		"amount": 100,
+//		"max_redemptions": 1000,
+//		"valid_until": "2021-01-01T00:00:00Z",
+//		"reason": "<причина>"
+//	}
+//
+// ```
+// Возможные коды ответа:
+//   - `200` — промокод создан.
+//     Формат ответа:
+//     ```
+//     200 OK HTTP/1.1
+//     Content-Type: application/json
+//     ...
+//     {
+//     "code": "WELCOME",
+//     "amount": 100,
+//     "max_redemptions": 1000,
+//     "redemptions": 0,
+//     "valid_from": "2020-12-10T15:15:45Z",
+//     "valid_until": "2021-01-01T00:00:00Z"
+//     }
+//     ```
+//   - `400` — неверный формат запроса, неверный промокод или не указана причина;
+//   - `401` — неверный токен или не указано имя администратора;
+//   - `404` — программа лояльности не найдена;
+//   - `409` — промокод с таким кодом уже существует в программе лояльности;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) NewPromo(w http.ResponseWriter, r *http.Request) {
+	by, ok := r.Context().Value(keyAdmin).(string)
+	if !ok {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	request := newPromoRequest{MaxRedemptions: 1}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	created, err := a.admin.NewPromo(r.Context(), requestTenant(r), request.promo(), by, request.Reason)
+	if err != nil {
+		a.writeAdminError(w, err)
+		return
+	}
+	if err := a.writeJSON(w, created); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
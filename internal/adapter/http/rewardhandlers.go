@@ -0,0 +1,250 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/k1nky/gophermart/internal/entity/reward"
+)
+
+type rewardRuleRequest struct {
+	Name       string      `json:"name"`
+	Merchant   string      `json:"merchant"`
+	Match      string      `json:"match"`
+	Reward     float32     `json:"reward"`
+	RewardType reward.Type `json:"reward_type"`
+	MaxReward  float32     `json:"max_reward"`
+	ValidFrom  *time.Time  `json:"valid_from"`
+	ValidUntil *time.Time  `json:"valid_until"`
+	Reason     string      `json:"reason"`
+}
+
+type deleteRewardRuleRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (r rewardRuleRequest) rule() reward.Rule {
+	return reward.Rule{
+		Name:       r.Name,
+		Merchant:   r.Merchant,
+		Match:      r.Match,
+		Reward:     r.Reward,
+		RewardType: r.RewardType,
+		MaxReward:  r.MaxReward,
+		ValidFrom:  r.ValidFrom,
+		ValidUntil: r.ValidUntil,
+	}
+}
+
+// Получение списка правил расчёта вознаграждений собственной системы расчёта начислений. Хендлер доступен только
+// администраторам: запрос должен содержать токен администратора в заголовке `X-Admin-Token` и имя администратора
+// в заголовке `X-Admin-User`. Возвращаются правила программы лояльности, указанной в заголовке `X-Tenant-ID`,
+// или программы, к которой относится имя хоста. Правила отсортированы по времени создания от самых старых к самым новым.
+// Формат запроса:
+// ```
+// GET /api/admin/rewards HTTP/1.1
+// X-Admin-Token: <token>
+// X-Admin-User: <name>
+// ```
+// Возможные коды ответа:
+//   - `200` — успешная обработка запроса.
+//     Формат ответа:
+//     ```
+//     200 OK HTTP/1.1
+//     Content-Type: application/json
+//     ...
+//     [
+//     {
+//     "id": 1,
+//     "name": "Чайники Bork",
+//     "merchant": "Bork",
+//     "match": "чайник",
+//     "reward": 10,
+//     "reward_type": "%",
+//     "max_reward": 500,
+//     "valid_from": "2020-12-01T00:00:00Z",
+//     "valid_until": "2021-01-01T00:00:00Z",
+//     "created_at": "2020-11-30T15:15:45Z"
+//     }
+//     ]
+//     ```
+//   - `204` — нет правил;
+//   - `401` — неверный токен или не указано имя администратора;
+//   - `404` — программа лояльности не найдена;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) GetRewardRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := a.admin.GetRewardRules(r.Context(), requestTenant(r))
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if len(rules) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err := a.writeJSON(w, rules); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Создание правила расчёта вознаграждений. Хендлер доступен только администраторам: запрос должен содержать токен
+// администратора в заголовке `X-Admin-Token` и имя администратора в заголовке `X-Admin-User`.
+// Правило начисляет вознаграждение `reward` в процентах от стоимости (`reward_type` равен `%`) или
+// в баллах (`reward_type` равен `pt`) за покупку целиком либо, если указан `match`, за каждую позицию покупки,
+// описание которой содержит `match` без учета регистра. Необязательные поля: `merchant` - правило применяется
+// только к покупкам у продавца, `max_reward` - максимальное вознаграждение по правилу за одну покупку,
+// `valid_from` и `valid_until` - период действия правила по времени загрузки заказа.
+// Вознаграждение за заказ - сумма вознаграждений по всем подходящим правилам.
+// Правило создается в программе лояльности запроса, как при получении списка правил, и применяется к заказам
+// этой программы, начисление по которым еще не рассчитано.
+// Действие записывается в журнал действий администраторов.
+// Формат запроса:
+// ```
+// POST /api/admin/rewards HTTP/1.1
+// Content-Type: application/json
+// X-Admin-Token: <token>
+// X-Admin-User: <name>
+//
+//	{
+//		"name": "Чайники Bork",
+//		"merchant": "Bork",
+//		"match": "чайник",
+//		"reward": 10,
+//		"reward_type": "%",
+//		"max_reward": 500,
+//		"reason": "<причина>"
+//	}
+//
+// ```
+// Возможные коды ответа:
+//   - `200` — правило создано, в ответе созданное правило;
+//   - `400` — неверный формат запроса, неверное правило или не указана причина;
+//   - `401` — неверный токен или не указано имя администратора;
+//   - `404` — программа лояльности не найдена;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) NewRewardRule(w http.ResponseWriter, r *http.Request) {
+	by, ok := r.Context().Value(keyAdmin).(string)
+	if !ok {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	request := rewardRuleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	rule, err := a.admin.NewRewardRule(r.Context(), requestTenant(r), request.rule(), by, request.Reason)
+	if err != nil {
+		a.writeAdminError(w, err)
+		return
+	}
+	if err := a.writeJSON(w, rule); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Изменение правила расчёта вознаграждений. Хендлер доступен только администраторам: запрос должен содержать токен
+// администратора в заголовке `X-Admin-Token` и имя администратора в заголовке `X-Admin-User`.
+// Правило заменяется целиком, формат запроса совпадает с форматом создания правила. Правила других программ
+// лояльности, кроме программы запроса, не изменяются.
+// Уже рассчитанные начисления не пересчитываются. Действие записывается в журнал действий администраторов.
+// Формат запроса:
+// ```
+// PUT /api/admin/rewards/{id} HTTP/1.1
+// Content-Type: application/json
+// X-Admin-Token: <token>
+// X-Admin-User: <name>
+//
+//	{
+//		"name": "Чайники Bork",
+//		"match": "чайник",
+//		"reward": 200,
+//		"reward_type": "pt",
+//		"reason": "<причина>"
+//	}
+//
+// ```
+// Возможные коды ответа:
+//   - `200` — правило изменено, в ответе правило после изменения;
+//   - `400` — неверный формат запроса, неверное правило или не указана причина;
+//   - `401` — неверный токен или не указано имя администратора;
+//   - `404` — правило не найдено в программе лояльности запроса или программа не найдена;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) UpdateRewardRule(w http.ResponseWriter, r *http.Request) {
+	by, ok := r.Context().Value(keyAdmin).(string)
+	if !ok {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	request := rewardRuleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	rule := request.rule()
+	rule.ID = reward.ID(id)
+	updated, err := a.admin.UpdateRewardRule(r.Context(), requestTenant(r), rule, by, request.Reason)
+	if err != nil {
+		a.writeAdminError(w, err)
+		return
+	}
+	if err := a.writeJSON(w, updated); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Удаление правила расчёта вознаграждений. Хендлер доступен только администраторам: запрос должен содержать токен
+// администратора в заголовке `X-Admin-Token` и имя администратора в заголовке `X-Admin-User`.
+// Удаляется только правило программы лояльности запроса. Уже рассчитанные начисления не пересчитываются.
+// Действие записывается в журнал действий администраторов.
+// Формат запроса:
+// ```
+// DELETE /api/admin/rewards/{id} HTTP/1.1
+// Content-Type: application/json
+// X-Admin-Token: <token>
+// X-Admin-User: <name>
+//
+//	{
+//		"reason": "<причина>"
+//	}
+//
+// ```
+// Возможные коды ответа:
+//   - `200` — правило удалено;
+//   - `400` — неверный формат запроса или не указана причина;
+//   - `401` — неверный токен или не указано имя администратора;
+//   - `404` — правило не найдено в программе лояльности запроса или программа не найдена;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) DeleteRewardRule(w http.ResponseWriter, r *http.Request) {
+	by, ok := r.Context().Value(keyAdmin).(string)
+	if !ok {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	request := deleteRewardRuleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	if err := a.admin.DeleteRewardRule(r.Context(), requestTenant(r), reward.ID(id), by, request.Reason); err != nil {
+		a.writeAdminError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/statement"
+	"github.com/mailru/easyjson"
+)
+
+const (
+	// количество записей выписки, после записи которых ответ отправляется клиенту
+	DefaultStatementFlushRows = 100
+)
+
+// Форматы выгрузки выписки
+const (
+	statementFormatCSV   = "csv"
+	statementFormatJSONL = "jsonl"
+)
+
+type statementWriter interface {
+	ContentType() string
+	Write(e *statement.Entry) error
+	Flush() error
+}
+
+// Записывает выписку в формате CSV с заголовком
+type csvStatementWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func newCSVStatementWriter(w io.Writer) *csvStatementWriter {
+	return &csvStatementWriter{
+		w: csv.NewWriter(w),
+	}
+}
+
+func (sw *csvStatementWriter) ContentType() string {
+	return "text/csv"
+}
+
+func (sw *csvStatementWriter) Write(e *statement.Entry) error {
+	if !sw.headerWritten {
+		if err := sw.w.Write([]string{"kind", "reference", "status", "amount", "balance", "time"}); err != nil {
+			return err
+		}
+		sw.headerWritten = true
+	}
+	return sw.w.Write([]string{
+		string(e.Kind),
+		e.Reference,
+		e.Status,
+		formatAmount(e.Amount),
+		formatAmount(e.Balance),
+		e.Time.Format(time.RFC3339),
+	})
+}
+
+func (sw *csvStatementWriter) Flush() error {
+	sw.w.Flush()
+	return sw.w.Error()
+}
+
+// Записывает выписку в формате JSON Lines: по одному JSON объекту на строку
+type jsonlStatementWriter struct {
+	w *bufio.Writer
+}
+
+func newJSONLStatementWriter(w io.Writer) *jsonlStatementWriter {
+	return &jsonlStatementWriter{
+		w: bufio.NewWriter(w),
+	}
+}
+
+func (sw *jsonlStatementWriter) ContentType() string {
+	return "application/jsonl"
+}
+
+func (sw *jsonlStatementWriter) Write(e *statement.Entry) error {
+	if _, err := easyjson.MarshalToWriter(e, sw.w); err != nil {
+		return err
+	}
+	return sw.w.WriteByte('\n')
+}
+
+func (sw *jsonlStatementWriter) Flush() error {
+	return sw.w.Flush()
+}
+
+func formatAmount(v *float32) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(float64(*v), 'f', -1, 32)
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/statement"
+	"github.com/k1nky/gophermart/internal/entity/user"
+)
+
+// Выгрузка выписки по счету пользователя: заказы, списания и транзакции за период.
+// Хендлер доступен только авторизованному пользователю. Записи выписки отсортированы по времени от самых старых к самым новым.
+// Параметры запроса:
+//   - `from` — начало периода включительно в формате RFC3339 или YYYY-MM-DD, по умолчанию без ограничения;
+//   - `to` — конец периода не включительно в формате RFC3339 или YYYY-MM-DD (в этом случае день включается в период), по умолчанию текущий момент;
+//   - `format` — формат выписки: `csv` (по умолчанию) или `jsonl`.
+//
+// Формат запроса:
+// ```
+// GET /api/user/statement?from=2020-12-01&to=2020-12-31&format=csv HTTP/1.1
+// Content-Length: 0
+// ```
+// Возможные коды ответа:
+//   - `200` — успешная обработка запроса.
+//     Формат ответа:
+//     ```
+//     200 OK HTTP/1.1
+//     Content-Type: text/csv
+//     ...
+//     kind,reference,status,amount,balance,time
+//     ORDER,9278923470,PROCESSED,500,,2020-12-10T15:15:45Z
+//     TRANSACTION,ACCRUAL,,500,500,2020-12-10T15:16:01Z
+//     ```
+//   - `400` — неверный формат запроса;
+//   - `401` — пользователь не авторизован;
+//   - `500` — внутренняя ошибка сервера.
+func (a *Adapter) GetStatement(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(keyUserClaims).(user.PrivateClaims)
+	if !ok {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	query := r.URL.Query()
+	from, err := parseStatementTime(query.Get("from"), false)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	to, err := parseStatementTime(query.Get("to"), true)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if to.Before(from) {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	var sw statementWriter
+	switch query.Get("format") {
+	case "", statementFormatCSV:
+		sw = newCSVStatementWriter(w)
+	case statementFormatJSONL:
+		sw = newJSONLStatementWriter(w)
+	default:
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	// выписка за большой период может выгружаться дольше таймаута записи сервера
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+	rows := 0
+	err = a.account.GetUserStatement(r.Context(), claims.ID, from, to, func(e *statement.Entry) error {
+		if rows == 0 {
+			w.Header().Set("content-type", sw.ContentType())
+			w.WriteHeader(http.StatusOK)
+		}
+		if err := sw.Write(e); err != nil {
+			return err
+		}
+		rows++
+		if rows%DefaultStatementFlushRows == 0 {
+			if err := sw.Flush(); err != nil {
+				return err
+			}
+			rc.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if rows == 0 {
+			http.Error(w, "", http.StatusInternalServerError)
+		} else {
+			// заголовок ответа уже отправлен, поэтому остается только прервать выгрузку
+			a.log.Errorf("statement for user %d interrupted: %v", claims.ID, err)
+		}
+		return
+	}
+	if rows == 0 {
+		w.Header().Set("content-type", sw.ContentType())
+		w.WriteHeader(http.StatusOK)
+	}
+	if err := sw.Flush(); err != nil {
+		a.log.Errorf("statement for user %d interrupted: %v", claims.ID, err)
+	}
+}
+
+// Разбирает границу периода выписки в формате RFC3339 или YYYY-MM-DD.
+// Для конца периода в формате YYYY-MM-DD указанный день включается в период.
+func parseStatementTime(s string, isEnd bool) (time.Time, error) {
+	if len(s) == 0 {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		if isEnd {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -8,7 +8,8 @@ import (
 	"github.com/k1nky/gophermart/internal/entity/user"
 )
 
-// Регистрация пользователя. Регистрация производится по паре логин/пароль. Каждый логин должен быть уникальным.
+// Регистрация пользователя. Регистрация производится по паре логин/пароль. Каждый логин должен быть уникальным
+// в рамках программы лояльности, которая определяется по заголовку `X-Tenant-ID` или имени хоста.
 // После успешной регистрации должна происходить автоматическая аутентификация пользователя.
 //
 // POST /api/user/register HTTP/1.1
@@ -23,6 +24,7 @@ import (
 // Возможные коды ответа:
 // - `200` — пользователь успешно зарегистрирован и аутентифицирован;
 // - `400` — неверный формат запроса;
+// - `404` — программа лояльности не найдена;
 // - `409` — логин уже занят;
 // - `500` — внутренняя ошибка сервера.
 func (a *Adapter) Register(w http.ResponseWriter, r *http.Request) {
@@ -35,6 +37,7 @@ func (a *Adapter) Register(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "", http.StatusBadRequest)
 		return
 	}
+	credentials.TenantID = requestTenant(r)
 	signedToken, err := a.auth.Register(r.Context(), credentials)
 	if err != nil {
 		if errors.Is(err, user.ErrDuplicateLogin) {
@@ -48,7 +51,8 @@ func (a *Adapter) Register(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-// Аутентификация пользователя. Аутентификация производится по паре логин/пароль.
+// Аутентификация пользователя. Аутентификация производится по паре логин/пароль среди пользователей
+// программы лояльности запроса. Токен действителен только для запросов к этой программе.
 // Формат запроса:
 //
 //	 ```
@@ -62,6 +66,7 @@ func (a *Adapter) Register(w http.ResponseWriter, r *http.Request) {
 // - `200` — пользователь успешно аутентифицирован;
 // - `400` — неверный формат запроса;
 // - `401` — неверная пара логин/пароль;
+// - `404` — программа лояльности не найдена;
 // - `500` — внутренняя ошибка сервера.
 func (a *Adapter) Login(w http.ResponseWriter, r *http.Request) {
 	credentials := user.User{}
@@ -73,6 +78,7 @@ func (a *Adapter) Login(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "", http.StatusBadRequest)
 		return
 	}
+	credentials.TenantID = requestTenant(r)
 	signedToken, err := a.auth.Login(r.Context(), credentials)
 	if err != nil {
 		if errors.Is(err, user.ErrInvalidCredentials) {
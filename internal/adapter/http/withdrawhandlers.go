@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/k1nky/gophermart/internal/entity/order"
 	"github.com/k1nky/gophermart/internal/entity/user"
@@ -12,11 +13,17 @@ import (
 
 // Получение текущего баланса пользователя
 // Хендлер доступен только авторизованному пользователю. В ответе должны содержаться данные о текущей сумме баллов лояльности, а также сумме использованных за весь период регистрации баллов.
+// Если указан параметр `at` в формате RFC3339, то возвращается баланс и сумма использованных баллов на этот момент времени.
 // Формат запроса:
 // ```
 // GET /api/user/balance HTTP/1.1
 // Content-Length: 0
 // ```
+// или
+// ```
+// GET /api/user/balance?at=2020-12-10T15:15:45+03:00 HTTP/1.1
+// Content-Length: 0
+// ```
 // Возможные коды ответа:
 //   - `200` — успешная обработка запроса.
 //     Формат ответа:
@@ -29,6 +36,7 @@ import (
 //     "withdrawn": 42
 //     }
 //     ```
+//   - `400` — неверный формат параметра `at`.
 //   - `401` — пользователь не авторизован.
 //   - `500` — внутренняя ошибка сервера.
 func (a *Adapter) GetBalance(w http.ResponseWriter, r *http.Request) {
@@ -37,7 +45,20 @@ func (a *Adapter) GetBalance(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "", http.StatusInternalServerError)
 		return
 	}
-	balance, err := a.account.GetUserBalance(r.Context(), claims.ID)
+	var (
+		balance user.Balance
+		err     error
+	)
+	if at := r.URL.Query().Get("at"); len(at) != 0 {
+		t, parseErr := time.Parse(time.RFC3339, at)
+		if parseErr != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		balance, err = a.account.GetUserBalanceAt(r.Context(), claims.ID, t)
+	} else {
+		balance, err = a.account.GetUserBalance(r.Context(), claims.ID)
+	}
 	if err != nil {
 		http.Error(w, "", http.StatusInternalServerError)
 		return
@@ -0,0 +1,13 @@
+package accrual
+
+// Состояние автомата защиты системы расчёта начислений
+type CircuitState string
+
+const (
+	// запросы выполняются
+	CircuitClosed CircuitState = "closed"
+	// запросы не выполняются, сервис считается недоступным
+	CircuitOpen CircuitState = "open"
+	// выполняется пробный запрос, по результату которого автомат замкнется или снова разомкнется
+	CircuitHalfOpen CircuitState = "half-open"
+)
@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/user"
+)
+
+type Action string
+
+// Действия администраторов
+const (
+	// повторная обработка заказа
+	ActionRequeue Action = "REQUEUE"
+	// принудительное изменение статуса заказа
+	ActionForceStatus Action = "FORCE_STATUS"
+	// корректировка баланса пользователя
+	ActionAdjustment Action = "ADJUSTMENT"
+	// создание промокода
+	ActionNewPromo Action = "NEW_PROMO"
+	// возобновление проверки заказа, проверка которого была прекращена
+	ActionRetry Action = "RETRY"
+	// создание, изменение и удаление правила расчёта вознаграждений
+	ActionNewRewardRule    Action = "NEW_REWARD_RULE"
+	ActionUpdateRewardRule Action = "UPDATE_REWARD_RULE"
+	ActionDeleteRewardRule Action = "DELETE_REWARD_RULE"
+)
+
+type AdjustmentID uint64
+
+// Корректировка баланса пользователя
+//
+//go:generate easyjson admin.go
+//easyjson:json
+type Adjustment struct {
+	ID     AdjustmentID `json:"-"`
+	UserID user.ID      `json:"-"`
+	// заказ, начисление по которому изменилось, 0 - корректировка не связана с заказом
+	OrderID order.ID `json:"-"`
+	// изменение баланса, может быть отрицательным
+	Amount float32 `json:"amount"`
+	Reason string  `json:"reason"`
+	// администратор, выполнивший корректировку
+	Admin string `json:"admin"`
+	// баланс пользователя после корректировки
+	Balance   float32   `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Запись журнала действий администраторов
+type AuditRecord struct {
+	Admin   string
+	Action  Action
+	OrderID order.ID
+	UserID  user.ID
+	Reason  string
+	// параметры действия и его результат
+	Details   json.RawMessage
+	CreatedAt time.Time
+}
@@ -0,0 +1,8 @@
+package admin
+
+import "errors"
+
+var (
+	ErrReasonRequired = errors.New("reason is required")
+	ErrInvalidAmount  = errors.New("invalid adjustment amount")
+)
@@ -0,0 +1,21 @@
+package order
+
+// Результат загрузки номера заказа
+type UploadResult string
+
+const (
+	// новый номер заказа принят в обработку
+	UploadAccepted UploadResult = "accepted"
+	// номер заказа уже был загружен этим пользователем
+	UploadDuplicate UploadResult = "duplicate"
+	// номер заказа уже был загружен другим пользователем
+	UploadConflict UploadResult = "conflict"
+	// неверный формат номера заказа
+	UploadInvalid UploadResult = "invalid"
+)
+
+// Результат загрузки номера заказа в составе пакета
+type BatchItem struct {
+	Number OrderNumber
+	Result UploadResult
+}
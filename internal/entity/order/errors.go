@@ -7,4 +7,12 @@ var (
 	ErrInvalidNumberFormat  = errors.New("invalid order number format")
 	ErrBelongsToAnotherUser = errors.New("order belongs to another user")
 	ErrAlreadyProcessed     = errors.New("order has already processed")
+	ErrNotFound             = errors.New("order not found")
+	ErrUnknownStatus        = errors.New("unknown order status")
+	ErrInvalidBatch         = errors.New("invalid order batch")
+	ErrInvalidPurchase      = errors.New("invalid purchase")
+	// аренда заказа истекла и перешла к другому экземпляру сервиса или была снята
+	ErrLeaseLost = errors.New("order lease lost")
+	// система расчёта начислений вернула некорректный ответ по заказу
+	ErrInvalidAccrualResponse = errors.New("invalid accrual system response")
 )
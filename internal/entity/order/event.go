@@ -0,0 +1,43 @@
+package order
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventSource string
+
+// Источники изменения статуса заказа
+const (
+	// загрузка заказа пользователем
+	SourceUpload EventSource = "UPLOAD"
+	// опрос системы расчёта начислений
+	SourcePoller EventSource = "POLLER"
+	// уведомление от системы расчёта начислений
+	SourceCallback EventSource = "CALLBACK"
+	// действие администратора
+	SourceAdmin EventSource = "ADMIN"
+)
+
+// Изменение статуса заказа
+//
+//easyjson:json
+type Event struct {
+	OrderID ID          `json:"-"`
+	Status  OrderStatus `json:"status"`
+	Accrual *float32    `json:"accrual,omitempty"`
+	Source  EventSource `json:"source"`
+	// поставщик начисления, пустой - статус изменен не системой расчёта начислений
+	Provider string `json:"provider,omitempty"`
+	// ответ системы расчёта начислений, на основании которого изменен статус
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// История изменения статуса заказа в порядке возрастания времени изменения
+//
+//easyjson:json
+type Timeline struct {
+	Order
+	Events []Event `json:"events"`
+}
@@ -1,9 +1,12 @@
 package order
 
 import (
-	"strconv"
+	"encoding/json"
+	"fmt"
+	"regexp"
 	"time"
 
+	"github.com/k1nky/gophermart/internal/entity/tenant"
 	"github.com/k1nky/gophermart/internal/entity/user"
 )
 
@@ -16,10 +19,12 @@ const (
 	StatusProcessing OrderStatus = "PROCESSING"
 	StatusInvalid    OrderStatus = "INVALID"
 	StatusProcessed  OrderStatus = "PROCESSED"
+	// внутренний статус: заказ зарегистрирован в системе расчёта начислений, но расчёт еще не начат.
+	// Пользователю показывается как PROCESSING.
 	StatusRegistered OrderStatus = "REGISTERED"
 )
 
-//go:generate easyjson order.go
+//go:generate easyjson order.go event.go
 //easyjson:json
 type Order struct {
 	ID         ID
@@ -27,34 +32,78 @@ type Order struct {
 	Status     OrderStatus `json:"status"`
 	Accrual    *float32    `json:"accrual,omitempty"`
 	UploadedAt time.Time   `json:"uploaded_at"`
-	UserID     user.ID     `json:"-"`
+	// сведения о покупке, nil - заказ загружен только номером
+	Purchase *Purchase `json:"purchase,omitempty"`
+	UserID   user.ID   `json:"-"`
+	// программа лояльности пользователя
+	TenantID tenant.ID `json:"-"`
+	// количество выполненных проверок начислений по заказу
+	Attempts uint `json:"-"`
+	// система расчёта начислений, которая рассчитала начисление по заказу
+	Provider string `json:"-"`
+	// ответ системы расчёта начислений, из которого получен заказ
+	Payload json.RawMessage `json:"-"`
+	// количество некорректных ответов системы расчёта начислений по заказу
+	Failures uint `json:"-"`
+	// последняя ошибка проверки заказа
+	LastError string `json:"-"`
+	// момент прекращения проверки заказа после MaxFailures некорректных ответов, nil - заказ проверяется
+	DeadLetteredAt *time.Time `json:"-"`
 }
 
-func (n OrderNumber) IsValid() bool {
-	digits := make([]int, 0, len(n))
-	for _, c := range n {
-		v, err := strconv.Atoi(string(c))
-		if err != nil {
-			return false
+// Сведения о покупке, по которой загружен заказ
+//
+//easyjson:json
+type Purchase struct {
+	// сумма покупки
+	Total float32 `json:"total"`
+	// код валюты по ISO 4217
+	Currency string `json:"currency"`
+	// продавец
+	Merchant string `json:"merchant,omitempty"`
+	// позиции покупки
+	Items []Item `json:"items,omitempty"`
+}
+
+// Позиция покупки
+type Item struct {
+	Description string  `json:"description"`
+	Price       float32 `json:"price"`
+}
+
+var currencyRe = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Проверяет сведения о покупке: сумма и цены позиций неотрицательные, код валюты из трех заглавных латинских букв,
+// у каждой позиции есть описание
+func (p *Purchase) Validate() error {
+	if p.Total < 0 {
+		return fmt.Errorf("total %v: %w", p.Total, ErrInvalidPurchase)
+	}
+	if !currencyRe.MatchString(p.Currency) {
+		return fmt.Errorf("currency %q: %w", p.Currency, ErrInvalidPurchase)
+	}
+	for i, item := range p.Items {
+		if len(item.Description) == 0 || item.Price < 0 {
+			return fmt.Errorf("item %d: %w", i, ErrInvalidPurchase)
 		}
-		digits = append(digits, v)
 	}
-	checksum := luhnChecksum(digits)
-	return (checksum % 10) == 0
+	return nil
 }
 
-func luhnChecksum(digits []int) int {
-	sum := 0
-	l := len(digits)
-	for i := 1; i <= l; i++ {
-		d := digits[l-i]
-		if i%2 == 0 {
-			d = d * 2
-			if d > 9 {
-				d = d - 9
-			}
-		}
-		sum = sum + int(d)
+// Возвращает истину, если статус заказа окончательный и больше не изменится
+func (s OrderStatus) IsFinal() bool {
+	return s == StatusInvalid || s == StatusProcessed
+}
+
+// Возвращает статус заказа, который показывается пользователю
+func (s OrderStatus) Public() OrderStatus {
+	if s == StatusRegistered {
+		return StatusProcessing
 	}
-	return sum
+	return s
+}
+
+// Возвращает истину, если номер заказа корректен по валидатору по умолчанию DefaultValidator
+func (n OrderNumber) IsValid() bool {
+	return DefaultValidator.IsValid(n)
 }
@@ -17,7 +17,173 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjson120d1ca2DecodeGithubComK1nkyGophermartInternalEntityOrder(in *jlexer.Lexer, out *Order) {
+func easyjson120d1ca2DecodeGithubComK1nkyGophermartInternalEntityOrder(in *jlexer.Lexer, out *Purchase) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "total":
+			out.Total = float32(in.Float32())
+		case "currency":
+			out.Currency = string(in.String())
+		case "merchant":
+			out.Merchant = string(in.String())
+		case "items":
+			if in.IsNull() {
+				in.Skip()
+				out.Items = nil
+			} else {
+				in.Delim('[')
+				if out.Items == nil {
+					if !in.IsDelim(']') {
+						out.Items = make([]Item, 0, 2)
+					} else {
+						out.Items = []Item{}
+					}
+				} else {
+					out.Items = (out.Items)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 Item
+					easyjson120d1ca2DecodeGithubComK1nkyGophermartInternalEntityOrder1(in, &v1)
+					out.Items = append(out.Items, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson120d1ca2EncodeGithubComK1nkyGophermartInternalEntityOrder(out *jwriter.Writer, in Purchase) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"total\":"
+		out.RawString(prefix[1:])
+		out.Float32(float32(in.Total))
+	}
+	{
+		const prefix string = ",\"currency\":"
+		out.RawString(prefix)
+		out.String(string(in.Currency))
+	}
+	if in.Merchant != "" {
+		const prefix string = ",\"merchant\":"
+		out.RawString(prefix)
+		out.String(string(in.Merchant))
+	}
+	if len(in.Items) != 0 {
+		const prefix string = ",\"items\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('[')
+			for v2, v3 := range in.Items {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				easyjson120d1ca2EncodeGithubComK1nkyGophermartInternalEntityOrder1(out, v3)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Purchase) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson120d1ca2EncodeGithubComK1nkyGophermartInternalEntityOrder(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Purchase) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson120d1ca2EncodeGithubComK1nkyGophermartInternalEntityOrder(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Purchase) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson120d1ca2DecodeGithubComK1nkyGophermartInternalEntityOrder(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Purchase) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson120d1ca2DecodeGithubComK1nkyGophermartInternalEntityOrder(l, v)
+}
+func easyjson120d1ca2DecodeGithubComK1nkyGophermartInternalEntityOrder1(in *jlexer.Lexer, out *Item) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "description":
+			out.Description = string(in.String())
+		case "price":
+			out.Price = float32(in.Float32())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson120d1ca2EncodeGithubComK1nkyGophermartInternalEntityOrder1(out *jwriter.Writer, in Item) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"description\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Description))
+	}
+	{
+		const prefix string = ",\"price\":"
+		out.RawString(prefix)
+		out.Float32(float32(in.Price))
+	}
+	out.RawByte('}')
+}
+func easyjson120d1ca2DecodeGithubComK1nkyGophermartInternalEntityOrder2(in *jlexer.Lexer, out *Order) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -56,6 +222,16 @@ func easyjson120d1ca2DecodeGithubComK1nkyGophermartInternalEntityOrder(in *jlexe
 			if data := in.Raw(); in.Ok() {
 				in.AddError((out.UploadedAt).UnmarshalJSON(data))
 			}
+		case "purchase":
+			if in.IsNull() {
+				in.Skip()
+				out.Purchase = nil
+			} else {
+				if out.Purchase == nil {
+					out.Purchase = new(Purchase)
+				}
+				(*out.Purchase).UnmarshalEasyJSON(in)
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -66,7 +242,7 @@ func easyjson120d1ca2DecodeGithubComK1nkyGophermartInternalEntityOrder(in *jlexe
 		in.Consumed()
 	}
 }
-func easyjson120d1ca2EncodeGithubComK1nkyGophermartInternalEntityOrder(out *jwriter.Writer, in Order) {
+func easyjson120d1ca2EncodeGithubComK1nkyGophermartInternalEntityOrder2(out *jwriter.Writer, in Order) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -95,29 +271,34 @@ func easyjson120d1ca2EncodeGithubComK1nkyGophermartInternalEntityOrder(out *jwri
 		out.RawString(prefix)
 		out.Raw((in.UploadedAt).MarshalJSON())
 	}
+	if in.Purchase != nil {
+		const prefix string = ",\"purchase\":"
+		out.RawString(prefix)
+		(*in.Purchase).MarshalEasyJSON(out)
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v Order) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson120d1ca2EncodeGithubComK1nkyGophermartInternalEntityOrder(&w, v)
+	easyjson120d1ca2EncodeGithubComK1nkyGophermartInternalEntityOrder2(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Order) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson120d1ca2EncodeGithubComK1nkyGophermartInternalEntityOrder(w, v)
+	easyjson120d1ca2EncodeGithubComK1nkyGophermartInternalEntityOrder2(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Order) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson120d1ca2DecodeGithubComK1nkyGophermartInternalEntityOrder(&r, v)
+	easyjson120d1ca2DecodeGithubComK1nkyGophermartInternalEntityOrder2(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Order) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson120d1ca2DecodeGithubComK1nkyGophermartInternalEntityOrder(l, v)
+	easyjson120d1ca2DecodeGithubComK1nkyGophermartInternalEntityOrder2(l, v)
 }
@@ -1,6 +1,9 @@
 package order
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestOrderNumber_IsValid(t *testing.T) {
 	tests := []struct {
@@ -32,3 +35,71 @@ func TestOrderNumber_IsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestOrderStatus_Public(t *testing.T) {
+	tests := []struct {
+		s    OrderStatus
+		want OrderStatus
+	}{
+		{s: StatusNew, want: StatusNew},
+		{s: StatusRegistered, want: StatusProcessing},
+		{s: StatusProcessing, want: StatusProcessing},
+		{s: StatusInvalid, want: StatusInvalid},
+		{s: StatusProcessed, want: StatusProcessed},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.s), func(t *testing.T) {
+			if got := tt.s.Public(); got != tt.want {
+				t.Errorf("OrderStatus.Public() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPurchase_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       Purchase
+		wantErr bool
+	}{
+		{
+			name: "Valid",
+			p:    Purchase{Total: 7000, Currency: "RUB", Merchant: "Bork", Items: []Item{{Description: "Чайник Bork", Price: 7000}}},
+		},
+		{
+			name: "Without items",
+			p:    Purchase{Total: 100, Currency: "USD"},
+		},
+		{
+			name:    "Negative total",
+			p:       Purchase{Total: -1, Currency: "RUB"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid currency",
+			p:       Purchase{Total: 100, Currency: "rub"},
+			wantErr: true,
+		},
+		{
+			name:    "Item without description",
+			p:       Purchase{Total: 100, Currency: "RUB", Items: []Item{{Price: 100}}},
+			wantErr: true,
+		},
+		{
+			name:    "Negative price",
+			p:       Purchase{Total: 100, Currency: "RUB", Items: []Item{{Description: "Чайник", Price: -100}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Purchase.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPurchase) {
+				t.Errorf("Purchase.Validate() error = %v, want %v", err, ErrInvalidPurchase)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package order
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Scheme схема проверки контрольной цифры номера заказа
+type Scheme string
+
+const (
+	SchemeLuhn           Scheme = "luhn"
+	SchemeVerhoeff       Scheme = "verhoeff"
+	SchemeDamm           Scheme = "damm"
+	SchemeISO7064Mod112  Scheme = "iso7064-mod11-2"
+	SchemeISO7064Mod9710 Scheme = "iso7064-mod97-10"
+)
+
+// CheckFunc возвращает истину, если номер number корректен по схеме проверки
+type CheckFunc func(number string) bool
+
+// Реестр схем проверки номеров заказов
+var schemes = map[Scheme]CheckFunc{
+	SchemeLuhn:           checkLuhn,
+	SchemeVerhoeff:       checkVerhoeff,
+	SchemeDamm:           checkDamm,
+	SchemeISO7064Mod112:  checkISO7064Mod112,
+	SchemeISO7064Mod9710: checkISO7064Mod9710,
+}
+
+// Добавляет в реестр схему проверки номеров заказов s, существующая схема с тем же именем заменяется.
+// Схемы регистрируются до создания валидаторов, регистрация не безопасна для конкурентного использования.
+func RegisterScheme(s Scheme, fn CheckFunc) {
+	schemes[s] = fn
+}
+
+// Правило выбора схемы проверки по префиксу номера заказа. Префикс сравнивается с номером,
+// приведенным к каноническому виду NormalizeNumber, поэтому пробелы и дефисы в префиксе не учитываются.
+type SchemeRule struct {
+	Prefix string
+	Scheme Scheme
+}
+
+// Validator проверяет номера заказов по схеме, выбранной по префиксу номера. Если номер подходит под
+// несколько правил, выбирается правило с самым длинным совпавшим префиксом, номер, не подходящий ни под одно
+// правило, проверяется схемой по умолчанию. Префикс, содержащий не только цифры, не участвует в проверке:
+// проверяется часть номера после префикса. Validator безопасен для конкурентного использования.
+type Validator struct {
+	fallback CheckFunc
+	rules    []validatorRule
+}
+
+type validatorRule struct {
+	prefix string
+	// префикс не является частью проверяемого номера
+	strip bool
+	check CheckFunc
+}
+
+// Валидатор номеров заказов по умолчанию: все номера проверяются алгоритмом Луна
+var DefaultValidator = &Validator{fallback: checkLuhn}
+
+// Создает валидатор номеров заказов со схемой по умолчанию fallback, пустая fallback - алгоритм Луна,
+// и правилами выбора схемы по префиксу rules. Возвращает ошибку, если схема не зарегистрирована.
+func NewValidator(fallback Scheme, rules ...SchemeRule) (*Validator, error) {
+	if len(fallback) == 0 {
+		fallback = SchemeLuhn
+	}
+	v := &Validator{}
+	var ok bool
+	if v.fallback, ok = schemes[fallback]; !ok {
+		return nil, fmt.Errorf("order number scheme %q is not registered", fallback)
+	}
+	for _, r := range rules {
+		check, ok := schemes[r.Scheme]
+		if !ok {
+			return nil, fmt.Errorf("order number scheme %q is not registered", r.Scheme)
+		}
+		prefix := string(NormalizeNumber(r.Prefix))
+		if len(prefix) == 0 {
+			return nil, fmt.Errorf("order number scheme %q: prefix is required", r.Scheme)
+		}
+		v.rules = append(v.rules, validatorRule{
+			prefix: prefix,
+			strip:  strings.IndexFunc(prefix, func(c rune) bool { return c < '0' || c > '9' }) >= 0,
+			check:  check,
+		})
+	}
+	return v, nil
+}
+
+// Возвращает истину, если номер заказа n корректен по схеме, выбранной по префиксу номера
+func (v *Validator) IsValid(n OrderNumber) bool {
+	number := string(n)
+	check := v.fallback
+	longest := 0
+	for _, r := range v.rules {
+		if len(r.prefix) > longest && strings.HasPrefix(number, r.prefix) {
+			check = r.check
+			longest = len(r.prefix)
+			if r.strip {
+				number = string(n)[len(r.prefix):]
+			} else {
+				number = string(n)
+			}
+		}
+	}
+	return check(number)
+}
+
+// Приводит загруженный номер заказа к каноническому виду: удаляет пробельные символы, в том числе
+// переводы строк, и дефисы
+func NormalizeNumber(s string) OrderNumber {
+	return OrderNumber(strings.Map(func(c rune) rune {
+		if unicode.IsSpace(c) || c == '-' {
+			return -1
+		}
+		return c
+	}, s))
+}
+
+// Возвращает цифры номера, nil - номер пустой или содержит не только цифры
+func digitsOf(number string) []int {
+	if len(number) == 0 {
+		return nil
+	}
+	digits := make([]int, 0, len(number))
+	for _, c := range number {
+		if c < '0' || c > '9' {
+			return nil
+		}
+		digits = append(digits, int(c-'0'))
+	}
+	return digits
+}
+
+func checkLuhn(number string) bool {
+	digits := digitsOf(number)
+	return digits != nil && luhnChecksum(digits)%10 == 0
+}
+
+func luhnChecksum(digits []int) int {
+	sum := 0
+	l := len(digits)
+	for i := 1; i <= l; i++ {
+		d := digits[l-i]
+		if i%2 == 0 {
+			d = d * 2
+			if d > 9 {
+				d = d - 9
+			}
+		}
+		sum = sum + int(d)
+	}
+	return sum
+}
+
+var (
+	verhoeffD = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+		{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+		{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffP = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0},
+		{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+		{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+func checkVerhoeff(number string) bool {
+	digits := digitsOf(number)
+	if digits == nil {
+		return false
+	}
+	c := 0
+	l := len(digits)
+	for i := 0; i < l; i++ {
+		c = verhoeffD[c][verhoeffP[i%8][digits[l-1-i]]]
+	}
+	return c == 0
+}
+
+var dammTable = [10][10]int{
+	{0, 3, 1, 7, 5, 9, 8, 6, 4, 2},
+	{7, 0, 9, 2, 1, 5, 4, 8, 6, 3},
+	{4, 2, 0, 6, 8, 7, 1, 3, 5, 9},
+	{1, 7, 5, 0, 9, 8, 3, 4, 2, 6},
+	{6, 1, 2, 3, 0, 4, 5, 9, 7, 8},
+	{3, 6, 7, 4, 2, 0, 9, 5, 8, 1},
+	{5, 8, 6, 9, 7, 2, 0, 1, 3, 4},
+	{8, 9, 4, 5, 3, 6, 2, 0, 1, 7},
+	{9, 4, 3, 8, 6, 1, 7, 2, 0, 5},
+	{2, 5, 8, 1, 4, 3, 6, 7, 9, 0},
+}
+
+func checkDamm(number string) bool {
+	digits := digitsOf(number)
+	if digits == nil {
+		return false
+	}
+	interim := 0
+	for _, d := range digits {
+		interim = dammTable[interim][d]
+	}
+	return interim == 0
+}
+
+// ISO 7064 MOD 11-2: контрольный символ - цифра или X, обозначающий 10
+func checkISO7064Mod112(number string) bool {
+	if len(number) < 2 {
+		return false
+	}
+	last := 10
+	if c := number[len(number)-1]; c != 'X' {
+		last = int(c - '0')
+	}
+	digits := digitsOf(number[:len(number)-1])
+	if digits == nil || last < 0 || last > 10 {
+		return false
+	}
+	r := 0
+	for _, d := range append(digits, last) {
+		r = (r*2 + d) % 11
+	}
+	return r == 1
+}
+
+// ISO 7064 MOD 97-10: две контрольные цифры, остаток от деления номера на 97 равен 1
+func checkISO7064Mod9710(number string) bool {
+	digits := digitsOf(number)
+	if len(digits) < 3 {
+		return false
+	}
+	r := 0
+	for _, d := range digits {
+		r = (r*10 + d) % 97
+	}
+	return r == 1
+}
@@ -0,0 +1,105 @@
+package order
+
+import "testing"
+
+func TestSchemes(t *testing.T) {
+	tests := []struct {
+		scheme Scheme
+		valid  []string
+		wrong  []string
+	}{
+		{scheme: SchemeLuhn, valid: []string{"12345678903", "79927398713"}, wrong: []string{"12345678901", "", "1234a"}},
+		{scheme: SchemeVerhoeff, valid: []string{"2363", "2411"}, wrong: []string{"2364", "6323", ""}},
+		{scheme: SchemeDamm, valid: []string{"5724", "0"}, wrong: []string{"5723", "7524", ""}},
+		{scheme: SchemeISO7064Mod112, valid: []string{"11010519491231002X", "07940"}, wrong: []string{"110105194912310021", "X", "07X4"}},
+		{scheme: SchemeISO7064Mod9710, valid: []string{"79444"}, wrong: []string{"79445", "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.scheme), func(t *testing.T) {
+			v, err := NewValidator(tt.scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, n := range tt.valid {
+				if !v.IsValid(OrderNumber(n)) {
+					t.Errorf("IsValid(%q) = false, want true", n)
+				}
+			}
+			for _, n := range tt.wrong {
+				if v.IsValid(OrderNumber(n)) {
+					t.Errorf("IsValid(%q) = true, want false", n)
+				}
+			}
+		})
+	}
+}
+
+func TestValidator_IsValid(t *testing.T) {
+	v, err := NewValidator("",
+		SchemeRule{Prefix: "ACME-", Scheme: SchemeDamm},
+		SchemeRule{Prefix: "2", Scheme: SchemeVerhoeff},
+		SchemeRule{Prefix: "23", Scheme: SchemeDamm},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		n    OrderNumber
+		want bool
+	}{
+		// схема по умолчанию
+		{n: "12345678903", want: true},
+		// нецифровой префикс не участвует в проверке
+		{n: "ACME5724", want: true},
+		{n: "ACME5723", want: false},
+		{n: "ACME", want: false},
+		// цифровой префикс является частью номера
+		{n: "2411", want: true},
+		{n: "2410", want: false},
+		// выбирается самый длинный совпавший префикс
+		{n: "2312", want: true},
+		{n: "2363", want: false},
+	}
+	for _, tt := range tests {
+		if got := v.IsValid(tt.n); got != tt.want {
+			t.Errorf("Validator.IsValid(%q) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+
+	if _, err := NewValidator("mod10"); err == nil {
+		t.Errorf("NewValidator() with unknown scheme error = nil")
+	}
+	if _, err := NewValidator(SchemeLuhn, SchemeRule{Scheme: SchemeDamm}); err == nil {
+		t.Errorf("NewValidator() without prefix error = nil")
+	}
+}
+
+func TestRegisterScheme(t *testing.T) {
+	RegisterScheme("even", func(number string) bool {
+		return len(number)%2 == 0
+	})
+	defer delete(schemes, "even")
+	v, err := NewValidator(SchemeLuhn, SchemeRule{Prefix: "R", Scheme: "even"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.IsValid("R12") || v.IsValid("R123") {
+		t.Errorf("registered scheme is not applied")
+	}
+}
+
+func TestNormalizeNumber(t *testing.T) {
+	tests := []struct {
+		s    string
+		want OrderNumber
+	}{
+		{s: "12345678903\n", want: "12345678903"},
+		{s: " 1234-5678 903\r\n", want: "12345678903"},
+		{s: "ACME-5724\t", want: "ACME5724"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeNumber(tt.s); got != tt.want {
+			t.Errorf("NormalizeNumber(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package promo
+
+import "errors"
+
+var (
+	ErrDuplicated      = errors.New("promo code already exists")
+	ErrNotFound        = errors.New("promo code not found")
+	ErrNotActive       = errors.New("promo code is not active")
+	ErrExhausted       = errors.New("promo code redemption limit exceeded")
+	ErrAlreadyRedeemed = errors.New("promo code has already redeemed")
+	ErrInvalidPromo    = errors.New("invalid promo code")
+)
@@ -0,0 +1,62 @@
+package promo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/user"
+)
+
+type ID uint64
+type Code string
+
+//go:generate easyjson promo.go
+//easyjson:json
+type Promo struct {
+	ID     ID      `json:"-"`
+	Code   Code    `json:"code"`
+	Amount float32 `json:"amount"`
+	// максимальное количество погашений, 1 - одноразовый промокод
+	MaxRedemptions uint `json:"max_redemptions"`
+	// количество выполненных погашений
+	Redemptions uint      `json:"redemptions"`
+	ValidFrom   time.Time `json:"valid_from"`
+	// nil - бессрочный промокод
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+}
+
+//easyjson:json
+type Redemption struct {
+	ID         ID        `json:"-"`
+	Code       Code      `json:"code"`
+	Amount     float32   `json:"amount"`
+	RedeemedAt time.Time `json:"redeemed_at"`
+	UserID     user.ID   `json:"-"`
+}
+
+func (c Code) IsValid() bool {
+	return len(c) > 0
+}
+
+// Проверяет промокод: код не пустой, количество баллов и лимит погашений положительные,
+// период действия не пустой. Нулевое начало действия означает момент проверки.
+func (p *Promo) Validate() error {
+	if len(strings.TrimSpace(string(p.Code))) == 0 {
+		return fmt.Errorf("code is required: %w", ErrInvalidPromo)
+	}
+	if p.Amount <= 0 {
+		return fmt.Errorf("amount %v: %w", p.Amount, ErrInvalidPromo)
+	}
+	if p.MaxRedemptions == 0 {
+		return fmt.Errorf("max redemptions %v: %w", p.MaxRedemptions, ErrInvalidPromo)
+	}
+	validFrom := p.ValidFrom
+	if validFrom.IsZero() {
+		validFrom = time.Now()
+	}
+	if p.ValidUntil != nil && !p.ValidUntil.After(validFrom) {
+		return fmt.Errorf("valid until %v is not after valid from %v: %w", p.ValidUntil, validFrom, ErrInvalidPromo)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package promo
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+	time "time"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjson47107b8bDecodeGithubComK1nkyGophermartInternalEntityPromo(in *jlexer.Lexer, out *Redemption) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "code":
+			out.Code = Code(in.String())
+		case "amount":
+			out.Amount = float32(in.Float32())
+		case "redeemed_at":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.RedeemedAt).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson47107b8bEncodeGithubComK1nkyGophermartInternalEntityPromo(out *jwriter.Writer, in Redemption) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"code\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Code))
+	}
+	{
+		const prefix string = ",\"amount\":"
+		out.RawString(prefix)
+		out.Float32(float32(in.Amount))
+	}
+	{
+		const prefix string = ",\"redeemed_at\":"
+		out.RawString(prefix)
+		out.Raw((in.RedeemedAt).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Redemption) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson47107b8bEncodeGithubComK1nkyGophermartInternalEntityPromo(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Redemption) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson47107b8bEncodeGithubComK1nkyGophermartInternalEntityPromo(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Redemption) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson47107b8bDecodeGithubComK1nkyGophermartInternalEntityPromo(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Redemption) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson47107b8bDecodeGithubComK1nkyGophermartInternalEntityPromo(l, v)
+}
+func easyjson47107b8bDecodeGithubComK1nkyGophermartInternalEntityPromo1(in *jlexer.Lexer, out *Promo) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "code":
+			out.Code = Code(in.String())
+		case "amount":
+			out.Amount = float32(in.Float32())
+		case "max_redemptions":
+			out.MaxRedemptions = uint(in.Uint())
+		case "redemptions":
+			out.Redemptions = uint(in.Uint())
+		case "valid_from":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.ValidFrom).UnmarshalJSON(data))
+			}
+		case "valid_until":
+			if in.IsNull() {
+				in.Skip()
+				out.ValidUntil = nil
+			} else {
+				if out.ValidUntil == nil {
+					out.ValidUntil = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.ValidUntil).UnmarshalJSON(data))
+				}
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson47107b8bEncodeGithubComK1nkyGophermartInternalEntityPromo1(out *jwriter.Writer, in Promo) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"code\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Code))
+	}
+	{
+		const prefix string = ",\"amount\":"
+		out.RawString(prefix)
+		out.Float32(float32(in.Amount))
+	}
+	{
+		const prefix string = ",\"max_redemptions\":"
+		out.RawString(prefix)
+		out.Uint(uint(in.MaxRedemptions))
+	}
+	{
+		const prefix string = ",\"redemptions\":"
+		out.RawString(prefix)
+		out.Uint(uint(in.Redemptions))
+	}
+	{
+		const prefix string = ",\"valid_from\":"
+		out.RawString(prefix)
+		out.Raw((in.ValidFrom).MarshalJSON())
+	}
+	if in.ValidUntil != nil {
+		const prefix string = ",\"valid_until\":"
+		out.RawString(prefix)
+		out.Raw((*in.ValidUntil).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Promo) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson47107b8bEncodeGithubComK1nkyGophermartInternalEntityPromo1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Promo) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson47107b8bEncodeGithubComK1nkyGophermartInternalEntityPromo1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Promo) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson47107b8bDecodeGithubComK1nkyGophermartInternalEntityPromo1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Promo) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson47107b8bDecodeGithubComK1nkyGophermartInternalEntityPromo1(l, v)
+}
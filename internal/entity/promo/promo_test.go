@@ -0,0 +1,37 @@
+package promo
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	from := time.Date(2020, 12, 1, 0, 0, 0, 0, time.UTC)
+	until := from.Add(24 * time.Hour)
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name    string
+		p       Promo
+		wantErr bool
+	}{
+		{name: "Single", p: Promo{Code: "NEW", Amount: 10, MaxRedemptions: 1}},
+		{name: "With window", p: Promo{Code: "NEW", Amount: 10, MaxRedemptions: 5, ValidFrom: from, ValidUntil: &until}},
+		{name: "Without code", p: Promo{Code: " ", Amount: 10, MaxRedemptions: 1}, wantErr: true},
+		{name: "Zero amount", p: Promo{Code: "NEW", MaxRedemptions: 1}, wantErr: true},
+		{name: "Zero redemptions", p: Promo{Code: "NEW", Amount: 10}, wantErr: true},
+		{name: "Empty window", p: Promo{Code: "NEW", Amount: 10, MaxRedemptions: 1, ValidFrom: from, ValidUntil: &from}, wantErr: true},
+		{name: "Expired", p: Promo{Code: "NEW", Amount: 10, MaxRedemptions: 1, ValidUntil: &past}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Promo.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPromo) {
+				t.Errorf("Promo.Validate() error = %v, want %v", err, ErrInvalidPromo)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package reward
+
+import "errors"
+
+var (
+	ErrNotFound    = errors.New("reward rule not found")
+	ErrInvalidRule = errors.New("invalid reward rule")
+)
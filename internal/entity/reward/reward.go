@@ -0,0 +1,124 @@
+package reward
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/order"
+)
+
+type ID uint64
+type Type string
+
+// Способы начисления вознаграждения
+const (
+	// процент от стоимости покупки или подходящей позиции
+	TypePercent Type = "%"
+	// фиксированное количество баллов за покупку или за каждую подходящую позицию
+	TypePoints Type = "pt"
+)
+
+// Правило расчёта вознаграждения за покупку
+//
+//go:generate easyjson reward.go
+//easyjson:json
+type Rule struct {
+	ID   ID     `json:"id"`
+	Name string `json:"name"`
+	// продавец, пустой - правило применяется к покупкам у любого продавца
+	Merchant string `json:"merchant,omitempty"`
+	// подстрока описания позиции покупки, пустая - правило применяется к покупке целиком
+	Match      string  `json:"match,omitempty"`
+	Reward     float32 `json:"reward"`
+	RewardType Type    `json:"reward_type"`
+	// максимальное вознаграждение по правилу за одну покупку, 0 - без ограничений
+	MaxReward float32 `json:"max_reward,omitempty"`
+	// период действия правила по времени загрузки заказа, nil - без ограничения
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Проверяет правило: вознаграждение положительное и в процентах не превышает 100,
+// ограничение вознаграждения неотрицательное, период действия не пустой
+func (r *Rule) Validate() error {
+	if len(strings.TrimSpace(r.Name)) == 0 {
+		return fmt.Errorf("name is required: %w", ErrInvalidRule)
+	}
+	switch r.RewardType {
+	case TypePercent:
+		if r.Reward > 100 {
+			return fmt.Errorf("reward %v%%: %w", r.Reward, ErrInvalidRule)
+		}
+	case TypePoints:
+	default:
+		return fmt.Errorf("reward type %q: %w", r.RewardType, ErrInvalidRule)
+	}
+	if r.Reward <= 0 {
+		return fmt.Errorf("reward %v: %w", r.Reward, ErrInvalidRule)
+	}
+	if r.MaxReward < 0 {
+		return fmt.Errorf("max reward %v: %w", r.MaxReward, ErrInvalidRule)
+	}
+	if r.ValidFrom != nil && r.ValidUntil != nil && !r.ValidUntil.After(*r.ValidFrom) {
+		return fmt.Errorf("valid until %v is not after valid from %v: %w", r.ValidUntil, r.ValidFrom, ErrInvalidRule)
+	}
+	return nil
+}
+
+// Возвращает истину, если правило действует в момент at
+func (r *Rule) ActiveAt(at time.Time) bool {
+	if r.ValidFrom != nil && at.Before(*r.ValidFrom) {
+		return false
+	}
+	if r.ValidUntil != nil && !at.Before(*r.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+// Возвращает вознаграждение по правилу за покупку p без учета периода действия правила.
+// Правило с подстрокой Match начисляет вознаграждение за каждую позицию, описание которой ее содержит,
+// без учета регистра. Правило без Match начисляет вознаграждение за покупку целиком.
+func (r *Rule) Evaluate(p order.Purchase) float32 {
+	if len(r.Merchant) != 0 && !strings.EqualFold(r.Merchant, p.Merchant) {
+		return 0
+	}
+	var reward float32
+	if len(r.Match) == 0 {
+		reward = r.reward(p.Total)
+	} else {
+		match := strings.ToLower(r.Match)
+		for _, item := range p.Items {
+			if strings.Contains(strings.ToLower(item.Description), match) {
+				reward += r.reward(item.Price)
+			}
+		}
+	}
+	if r.MaxReward > 0 && reward > r.MaxReward {
+		reward = r.MaxReward
+	}
+	return reward
+}
+
+// Возвращает вознаграждение за покупку или позицию стоимостью price
+func (r *Rule) reward(price float32) float32 {
+	if r.RewardType == TypePercent {
+		return price * r.Reward / 100
+	}
+	return r.Reward
+}
+
+// Возвращает вознаграждение за покупку p, загруженную в момент at: сумму вознаграждений по всем правилам rules,
+// действующим в момент at, округленную до сотых
+func Evaluate(rules []*Rule, p order.Purchase, at time.Time) float32 {
+	var total float64
+	for _, r := range rules {
+		if r.ActiveAt(at) {
+			total += float64(r.Evaluate(p))
+		}
+	}
+	return float32(math.Round(total*100) / 100)
+}
@@ -0,0 +1,171 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package reward
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+	time "time"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjsonF9c09a39DecodeGithubComK1nkyGophermartInternalEntityReward(in *jlexer.Lexer, out *Rule) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = ID(in.Uint64())
+		case "name":
+			out.Name = string(in.String())
+		case "merchant":
+			out.Merchant = string(in.String())
+		case "match":
+			out.Match = string(in.String())
+		case "reward":
+			out.Reward = float32(in.Float32())
+		case "reward_type":
+			out.RewardType = Type(in.String())
+		case "max_reward":
+			out.MaxReward = float32(in.Float32())
+		case "valid_from":
+			if in.IsNull() {
+				in.Skip()
+				out.ValidFrom = nil
+			} else {
+				if out.ValidFrom == nil {
+					out.ValidFrom = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.ValidFrom).UnmarshalJSON(data))
+				}
+			}
+		case "valid_until":
+			if in.IsNull() {
+				in.Skip()
+				out.ValidUntil = nil
+			} else {
+				if out.ValidUntil == nil {
+					out.ValidUntil = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.ValidUntil).UnmarshalJSON(data))
+				}
+			}
+		case "created_at":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.CreatedAt).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF9c09a39EncodeGithubComK1nkyGophermartInternalEntityReward(out *jwriter.Writer, in Rule) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.Uint64(uint64(in.ID))
+	}
+	{
+		const prefix string = ",\"name\":"
+		out.RawString(prefix)
+		out.String(string(in.Name))
+	}
+	if in.Merchant != "" {
+		const prefix string = ",\"merchant\":"
+		out.RawString(prefix)
+		out.String(string(in.Merchant))
+	}
+	if in.Match != "" {
+		const prefix string = ",\"match\":"
+		out.RawString(prefix)
+		out.String(string(in.Match))
+	}
+	{
+		const prefix string = ",\"reward\":"
+		out.RawString(prefix)
+		out.Float32(float32(in.Reward))
+	}
+	{
+		const prefix string = ",\"reward_type\":"
+		out.RawString(prefix)
+		out.String(string(in.RewardType))
+	}
+	if in.MaxReward != 0 {
+		const prefix string = ",\"max_reward\":"
+		out.RawString(prefix)
+		out.Float32(float32(in.MaxReward))
+	}
+	if in.ValidFrom != nil {
+		const prefix string = ",\"valid_from\":"
+		out.RawString(prefix)
+		out.Raw((*in.ValidFrom).MarshalJSON())
+	}
+	if in.ValidUntil != nil {
+		const prefix string = ",\"valid_until\":"
+		out.RawString(prefix)
+		out.Raw((*in.ValidUntil).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"created_at\":"
+		out.RawString(prefix)
+		out.Raw((in.CreatedAt).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Rule) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF9c09a39EncodeGithubComK1nkyGophermartInternalEntityReward(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Rule) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF9c09a39EncodeGithubComK1nkyGophermartInternalEntityReward(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Rule) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF9c09a39DecodeGithubComK1nkyGophermartInternalEntityReward(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Rule) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF9c09a39DecodeGithubComK1nkyGophermartInternalEntityReward(l, v)
+}
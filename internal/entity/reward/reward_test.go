@@ -0,0 +1,114 @@
+package reward
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/order"
+)
+
+func TestEvaluate(t *testing.T) {
+	at := time.Date(2020, 12, 10, 15, 0, 0, 0, time.UTC)
+	before := at.Add(-time.Hour)
+	after := at.Add(time.Hour)
+	purchase := order.Purchase{
+		Total:    8000,
+		Currency: "RUB",
+		Merchant: "Bork",
+		Items: []order.Item{
+			{Description: "Чайник Bork", Price: 7000},
+			{Description: "Фильтр для чайника", Price: 500},
+			{Description: "Доставка", Price: 500},
+		},
+	}
+	tests := []struct {
+		name  string
+		rules []*Rule
+		want  float32
+	}{
+		{
+			name:  "Percent of total",
+			rules: []*Rule{{Reward: 5, RewardType: TypePercent}},
+			want:  400,
+		},
+		{
+			name:  "Points per matching item",
+			rules: []*Rule{{Match: "ЧАЙНИК", Reward: 50, RewardType: TypePoints}},
+			want:  100,
+		},
+		{
+			name:  "Percent of matching items",
+			rules: []*Rule{{Match: "чайник", Reward: 10, RewardType: TypePercent}},
+			want:  750,
+		},
+		{
+			name:  "Cap",
+			rules: []*Rule{{Match: "чайник", Reward: 10, RewardType: TypePercent, MaxReward: 300}},
+			want:  300,
+		},
+		{
+			name:  "Other merchant",
+			rules: []*Rule{{Merchant: "Tefal", Reward: 5, RewardType: TypePercent}},
+			want:  0,
+		},
+		{
+			name:  "Merchant",
+			rules: []*Rule{{Merchant: "bork", Reward: 100, RewardType: TypePoints}},
+			want:  100,
+		},
+		{
+			name: "Date window",
+			rules: []*Rule{
+				{Reward: 1, RewardType: TypePoints, ValidFrom: &before, ValidUntil: &after},
+				{Reward: 10, RewardType: TypePoints, ValidFrom: &after},
+				{Reward: 100, RewardType: TypePoints, ValidUntil: &at},
+			},
+			want: 1,
+		},
+		{
+			name: "Sum of rules",
+			rules: []*Rule{
+				{Reward: 1.5, RewardType: TypePercent},
+				{Match: "фильтр", Reward: 20, RewardType: TypePoints},
+			},
+			want: 140,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Evaluate(tt.rules, purchase, at); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRule_Validate(t *testing.T) {
+	from := time.Date(2020, 12, 10, 15, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		r       Rule
+		wantErr bool
+	}{
+		{name: "Percent", r: Rule{Name: "r", Reward: 10, RewardType: TypePercent}},
+		{name: "Points", r: Rule{Name: "r", Reward: 500, RewardType: TypePoints, MaxReward: 1000}},
+		{name: "Without name", r: Rule{Reward: 10, RewardType: TypePercent}, wantErr: true},
+		{name: "Unknown type", r: Rule{Name: "r", Reward: 10, RewardType: "x"}, wantErr: true},
+		{name: "Percent over 100", r: Rule{Name: "r", Reward: 150, RewardType: TypePercent}, wantErr: true},
+		{name: "Zero reward", r: Rule{Name: "r", RewardType: TypePoints}, wantErr: true},
+		{name: "Negative cap", r: Rule{Name: "r", Reward: 10, RewardType: TypePoints, MaxReward: -1}, wantErr: true},
+		{name: "Empty window", r: Rule{Name: "r", Reward: 10, RewardType: TypePoints, ValidFrom: &from, ValidUntil: &from}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.r.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Rule.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("Rule.Validate() error = %v, want %v", err, ErrInvalidRule)
+			}
+		})
+	}
+}
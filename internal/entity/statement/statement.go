@@ -0,0 +1,30 @@
+package statement
+
+import (
+	"time"
+)
+
+type Kind string
+
+const (
+	KindOrder       Kind = "ORDER"
+	KindWithdrawal  Kind = "WITHDRAWAL"
+	KindTransaction Kind = "TRANSACTION"
+)
+
+// Запись выписки по счету пользователя
+//
+//go:generate easyjson statement.go
+//easyjson:json
+type Entry struct {
+	Kind Kind `json:"kind"`
+	// номер заказа для заказов и списаний, тип источника для транзакций
+	Reference string `json:"reference"`
+	// статус заказа
+	Status string `json:"status,omitempty"`
+	// начисление по заказу, сумма списания или изменение баланса в результате транзакции
+	Amount *float32 `json:"amount,omitempty"`
+	// баланс в результате проведения транзакции
+	Balance *float32  `json:"balance,omitempty"`
+	Time    time.Time `json:"time"`
+}
@@ -0,0 +1,138 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package statement
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjsonF64f85b5DecodeGithubComK1nkyGophermartInternalEntityStatement(in *jlexer.Lexer, out *Entry) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "kind":
+			out.Kind = Kind(in.String())
+		case "reference":
+			out.Reference = string(in.String())
+		case "status":
+			out.Status = string(in.String())
+		case "amount":
+			if in.IsNull() {
+				in.Skip()
+				out.Amount = nil
+			} else {
+				if out.Amount == nil {
+					out.Amount = new(float32)
+				}
+				*out.Amount = float32(in.Float32())
+			}
+		case "balance":
+			if in.IsNull() {
+				in.Skip()
+				out.Balance = nil
+			} else {
+				if out.Balance == nil {
+					out.Balance = new(float32)
+				}
+				*out.Balance = float32(in.Float32())
+			}
+		case "time":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Time).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF64f85b5EncodeGithubComK1nkyGophermartInternalEntityStatement(out *jwriter.Writer, in Entry) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"kind\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Kind))
+	}
+	{
+		const prefix string = ",\"reference\":"
+		out.RawString(prefix)
+		out.String(string(in.Reference))
+	}
+	if in.Status != "" {
+		const prefix string = ",\"status\":"
+		out.RawString(prefix)
+		out.String(string(in.Status))
+	}
+	if in.Amount != nil {
+		const prefix string = ",\"amount\":"
+		out.RawString(prefix)
+		out.Float32(float32(*in.Amount))
+	}
+	if in.Balance != nil {
+		const prefix string = ",\"balance\":"
+		out.RawString(prefix)
+		out.Float32(float32(*in.Balance))
+	}
+	{
+		const prefix string = ",\"time\":"
+		out.RawString(prefix)
+		out.Raw((in.Time).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Entry) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF64f85b5EncodeGithubComK1nkyGophermartInternalEntityStatement(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Entry) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF64f85b5EncodeGithubComK1nkyGophermartInternalEntityStatement(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Entry) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF64f85b5DecodeGithubComK1nkyGophermartInternalEntityStatement(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Entry) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF64f85b5DecodeGithubComK1nkyGophermartInternalEntityStatement(l, v)
+}
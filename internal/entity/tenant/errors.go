@@ -0,0 +1,8 @@
+package tenant
+
+import "errors"
+
+var (
+	ErrInvalidID    = errors.New("invalid tenant id")
+	ErrDuplicatedID = errors.New("tenant id already exists")
+)
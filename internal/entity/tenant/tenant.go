@@ -0,0 +1,120 @@
+package tenant
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type ID string
+
+// Программа лояльности по умолчанию: к ней относятся запросы, не отнесенные ни к одной другой программе,
+// и все данные, созданные до появления программ
+const Default ID = "default"
+
+// Tenant программа лояльности. Пользователи, заказы, транзакции и списания относятся к одной программе,
+// пользователи разных программ не видят данных друг друга.
+type Tenant struct {
+	ID ID
+	// имена хостов, запросы к которым относятся к программе
+	Hosts []string
+	// время с момента загрузки, после которого необработанный заказ помечается как INVALID, 0 - общая настройка
+	GiveUpAfter time.Duration
+	// максимальное количество номеров заказов в одном пакете, 0 - общая настройка
+	MaxBatchSize uint
+}
+
+var idRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// Возвращает истину, если идентификатор программы состоит из не более 50 строчных латинских букв, цифр,
+// дефисов и подчеркиваний и начинается с буквы или цифры
+func (id ID) IsValid() bool {
+	return idRe.MatchString(string(id))
+}
+
+// Возвращает программу по умолчанию вместо пустого идентификатора, например, из токена,
+// выданного до появления программ
+func (id ID) OrDefault() ID {
+	if len(id) == 0 {
+		return Default
+	}
+	return id
+}
+
+// Directory программы лояльности развертывания. Программа по умолчанию присутствует всегда.
+// Directory безопасен для конкурентного использования.
+type Directory struct {
+	tenants map[ID]Tenant
+	hosts   map[string]ID
+}
+
+// Создает справочник программ tenants. Возвращает ошибку, если идентификатор программы некорректен
+// или идентификатор либо имя хоста повторяется.
+func NewDirectory(tenants ...Tenant) (*Directory, error) {
+	d := &Directory{
+		tenants: map[ID]Tenant{Default: {ID: Default}},
+		hosts:   make(map[string]ID),
+	}
+	for _, t := range tenants {
+		if !t.ID.IsValid() {
+			return nil, fmt.Errorf("%q %w", t.ID, ErrInvalidID)
+		}
+		if _, ok := d.tenants[t.ID]; ok && t.ID != Default {
+			return nil, fmt.Errorf("%s %w", t.ID, ErrDuplicatedID)
+		}
+		for _, h := range t.Hosts {
+			h = strings.ToLower(h)
+			if id, ok := d.hosts[h]; ok {
+				return nil, fmt.Errorf("host %s of %s is already used by %s", h, t.ID, id)
+			}
+			d.hosts[h] = t.ID
+		}
+		d.tenants[t.ID] = t
+	}
+	return d, nil
+}
+
+// Возвращает программу с идентификатором id
+func (d *Directory) Get(id ID) (Tenant, bool) {
+	t, ok := d.tenants[id]
+	return t, ok
+}
+
+// Возвращает программу, к которой относится хост host, порт не учитывается
+func (d *Directory) ByHost(host string) (Tenant, bool) {
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+	id, ok := d.hosts[strings.ToLower(host)]
+	if !ok {
+		return Tenant{}, false
+	}
+	return d.tenants[id], true
+}
+
+// Filter отбор данных по программам лояльности. Пустой Filter отбирает данные всех программ.
+type Filter struct {
+	// программы, данные которых отбираются, пустой - все программы, кроме Exclude
+	Include []ID
+	// программы, данные которых не отбираются
+	Exclude []ID
+}
+
+// Возвращает истину, если программа id подходит под отбор
+func (f Filter) Match(id ID) bool {
+	for _, v := range f.Exclude {
+		if v == id {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, v := range f.Include {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
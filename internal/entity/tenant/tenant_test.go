@@ -0,0 +1,93 @@
+package tenant
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsValid(t *testing.T) {
+	tests := []struct {
+		id   ID
+		want bool
+	}{
+		{id: "default", want: true},
+		{id: "shop-1_a", want: true},
+		{id: "", want: false},
+		{id: "-shop", want: false},
+		{id: "Shop", want: false},
+		{id: "shop.ru", want: false},
+		{id: "a12345678901234567890123456789012345678901234567890", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.id), func(t *testing.T) {
+			if got := tt.id.IsValid(); got != tt.want {
+				t.Errorf("IsValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirectory(t *testing.T) {
+	d, err := NewDirectory(
+		Tenant{ID: "bork", Hosts: []string{"bonus.bork.ru", "Loyalty.Bork.ru"}, MaxBatchSize: 10},
+		Tenant{ID: "acme", Hosts: []string{"acme.example"}},
+	)
+	if err != nil {
+		t.Fatalf("NewDirectory() error = %v", err)
+	}
+	if _, ok := d.Get(Default); !ok {
+		t.Errorf("Get(%q) not found", Default)
+	}
+	if got, ok := d.Get("bork"); !ok || got.MaxBatchSize != 10 {
+		t.Errorf("Get(bork) = %v, %v", got, ok)
+	}
+	if _, ok := d.Get("unknown"); ok {
+		t.Errorf("Get(unknown) found")
+	}
+	hosts := map[string]ID{
+		"bonus.bork.ru":      "bork",
+		"loyalty.bork.ru:80": "bork",
+		"ACME.example:8080":  "acme",
+		"localhost:8080":     "",
+	}
+	for host, want := range hosts {
+		got, _ := d.ByHost(host)
+		if got.ID != want {
+			t.Errorf("ByHost(%s) = %s, want %s", host, got.ID, want)
+		}
+	}
+}
+
+func TestNewDirectoryErrors(t *testing.T) {
+	if _, err := NewDirectory(Tenant{ID: "Bork"}); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("NewDirectory() error = %v, want %v", err, ErrInvalidID)
+	}
+	if _, err := NewDirectory(Tenant{ID: "bork"}, Tenant{ID: "bork"}); !errors.Is(err, ErrDuplicatedID) {
+		t.Errorf("NewDirectory() error = %v, want %v", err, ErrDuplicatedID)
+	}
+	if _, err := NewDirectory(Tenant{ID: "bork", Hosts: []string{"a.ru"}}, Tenant{ID: "acme", Hosts: []string{"A.ru"}}); err == nil {
+		t.Errorf("NewDirectory() expected error for duplicated host")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		id     ID
+		want   bool
+	}{
+		{name: "empty", filter: Filter{}, id: Default, want: true},
+		{name: "included", filter: Filter{Include: []ID{"bork"}}, id: "bork", want: true},
+		{name: "not included", filter: Filter{Include: []ID{"bork"}}, id: Default, want: false},
+		{name: "excluded", filter: Filter{Exclude: []ID{"bork"}}, id: "bork", want: false},
+		{name: "not excluded", filter: Filter{Exclude: []ID{"bork"}}, id: Default, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(tt.id); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
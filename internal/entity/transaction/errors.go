@@ -0,0 +1,7 @@
+package transaction
+
+import "errors"
+
+var (
+	ErrConcurrentUpdate = errors.New("transactions have been changed concurrently")
+)
@@ -0,0 +1,77 @@
+package transaction
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/user"
+)
+
+type ID uint64
+type SourceType string
+
+// Источники транзакций
+const (
+	SourceAccrual    SourceType = "ACCRUAL"
+	SourceWithdraw   SourceType = "WITHDRAW"
+	SourcePromo      SourceType = "PROMO"
+	SourceAdjustment SourceType = "ADJUSTMENT"
+)
+
+// Транзакция журнала пользователя
+type Transaction struct {
+	ID     ID
+	UserID user.ID
+	// последовательный номер транзакции в рамках одного пользователя
+	Seq        uint64
+	SourceID   uint64
+	SourceType SourceType
+	// баланс в результате проведения транзакции
+	Balance float32
+	// изменение баланса по данным источника транзакции, nil - подходящий источник не найден
+	Amount    *float32
+	CreatedAt time.Time
+}
+
+type DiscrepancyKind string
+
+// Виды расхождений журнала транзакций
+const (
+	// пропуск в последовательных номерах транзакций пользователя
+	DiscrepancySequenceGap DiscrepancyKind = "SEQUENCE_GAP"
+	// баланс транзакции не равен сумме баланса предыдущей транзакции и изменения по источнику
+	DiscrepancyBalanceMismatch DiscrepancyKind = "BALANCE_MISMATCH"
+	// у транзакции нет подходящего источника: обработанного заказа, списания, погашения промокода или корректировки
+	DiscrepancyOrphanTransaction DiscrepancyKind = "ORPHAN_TRANSACTION"
+	// у источника нет транзакции
+	DiscrepancyMissingTransaction DiscrepancyKind = "MISSING_TRANSACTION"
+	// текущий баланс или сумма списаний пользователя не соответствует журналу транзакций
+	DiscrepancyBalanceSnapshot DiscrepancyKind = "BALANCE_SNAPSHOT_MISMATCH"
+	// баланс пользователя после пересчета журнала отрицательный, журнал не исправляется
+	DiscrepancyNegativeBalance DiscrepancyKind = "NEGATIVE_BALANCE"
+)
+
+// Расхождение журнала транзакций пользователя
+type Discrepancy struct {
+	Kind   DiscrepancyKind
+	UserID user.ID
+	// последовательный номер транзакции, 0 - транзакция отсутствует
+	Seq        uint64
+	SourceID   uint64
+	SourceType SourceType
+	Details    string
+}
+
+func (d Discrepancy) String() string {
+	s := fmt.Sprintf("user %d: %s", d.UserID, d.Kind)
+	if d.SourceID != 0 {
+		s = fmt.Sprintf("%s: %s #%d", s, d.SourceType, d.SourceID)
+	}
+	if d.Seq != 0 {
+		s = fmt.Sprintf("%s (seq %d)", s, d.Seq)
+	}
+	if len(d.Details) != 0 {
+		s = fmt.Sprintf("%s: %s", s, d.Details)
+	}
+	return s
+}
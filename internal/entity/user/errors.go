@@ -7,4 +7,5 @@ var (
 	ErrInvalidCredentials       = errors.New("login or password is not correct")
 	ErrUnathorized              = errors.New("user is not authorized")
 	ErrCredentialsInvalidFormat = errors.New("login or password has invalid format")
+	ErrNotFound                 = errors.New("user not found")
 )
@@ -1,6 +1,9 @@
 package user
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+	"golang.org/x/crypto/bcrypt"
+)
 
 type ID uint64
 
@@ -10,11 +13,15 @@ type User struct {
 	ID       ID
 	Login    string `json:"login"`
 	Password string `json:"password"`
+	// программа лояльности, к которой относится пользователь
+	TenantID tenant.ID `json:"-"`
 }
 
 type PrivateClaims struct {
 	ID    ID
 	Login string
+	// программа лояльности пользователя, пустая в токенах, выданных до появления программ
+	Tenant tenant.ID
 }
 
 type Balance struct {
@@ -43,7 +50,8 @@ func (u *User) IsValid() error {
 
 func NewPrivateClaims(u User) PrivateClaims {
 	return PrivateClaims{
-		ID:    u.ID,
-		Login: u.Login,
+		ID:     u.ID,
+		Login:  u.Login,
+		Tenant: u.TenantID,
 	}
 }
@@ -1,17 +1,51 @@
 package account
 
+import (
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+)
+
 const (
 	DefaultMaxRows = 100
+	// максимальное количество номеров заказов в одном пакете
+	DefaultMaxBatchSize = 1000
 )
 
 type Service struct {
 	store storage
 	log   logger
+	// валидатор номеров загружаемых заказов
+	validator *order.Validator
+	// программы лояльности с собственными ограничениями, nil - общие ограничения для всех
+	tenants *tenant.Directory
 }
 
 func New(store storage, log logger) *Service {
 	return &Service{
-		store: store,
-		log:   log,
+		store:     store,
+		log:       log,
+		validator: order.DefaultValidator,
+	}
+}
+
+// Заменяет валидатор номеров загружаемых заказов, по умолчанию order.DefaultValidator
+func (s *Service) WithValidator(v *order.Validator) *Service {
+	s.validator = v
+	return s
+}
+
+// Задает программы лояльности с собственными ограничениями
+func (s *Service) WithTenants(d *tenant.Directory) *Service {
+	s.tenants = d
+	return s
+}
+
+// Возвращает максимальное количество номеров заказов в одном пакете для программы лояльности tenantID
+func (s *Service) maxBatchSize(tenantID tenant.ID) int {
+	if s.tenants != nil {
+		if t, ok := s.tenants.Get(tenantID.OrDefault()); ok && t.MaxBatchSize > 0 {
+			return int(t.MaxBatchSize)
+		}
 	}
+	return DefaultMaxBatchSize
 }
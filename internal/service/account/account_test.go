@@ -1,9 +1,16 @@
 package account
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/statement"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+	"github.com/k1nky/gophermart/internal/entity/user"
+	log "github.com/k1nky/gophermart/internal/logger"
 	"github.com/k1nky/gophermart/internal/service/account/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -21,3 +28,119 @@ func (suite *accountServiceTestSuite) SetupTest() {
 	ctrl := gomock.NewController(suite.T())
 	suite.store = mock.NewMockstorage(ctrl)
 }
+
+func (suite *accountServiceTestSuite) TestPublicOrderStatus() {
+	svc := New(suite.store, &log.Blackhole{})
+	suite.store.EXPECT().GetOrdersByUserID(gomock.Any(), user.ID(1), gomock.Any()).Return([]*order.Order{
+		{ID: 1, Number: "100", Status: order.StatusRegistered},
+		{ID: 2, Number: "200", Status: order.StatusProcessed},
+	}, nil)
+	orders, err := svc.GetUserOrders(context.TODO(), 1)
+	suite.NoError(err)
+	suite.Equal(order.StatusProcessing, orders[0].Status)
+	suite.Equal(order.StatusProcessed, orders[1].Status)
+
+	suite.store.EXPECT().GetOrderByNumber(gomock.Any(), order.OrderNumber("100")).
+		Return(&order.Order{ID: 1, UserID: 1, Number: "100", Status: order.StatusRegistered}, nil)
+	suite.store.EXPECT().GetOrderEvents(gomock.Any(), order.ID(1)).Return([]order.Event{
+		{OrderID: 1, Status: order.StatusNew, Source: order.SourceUpload},
+		{OrderID: 1, Status: order.StatusRegistered, Source: order.SourcePoller},
+	}, nil)
+	timeline, err := svc.GetUserOrderTimeline(context.TODO(), 1, "100")
+	suite.NoError(err)
+	suite.Equal(order.StatusProcessing, timeline.Status)
+	suite.Equal(order.StatusNew, timeline.Events[0].Status)
+	suite.Equal(order.StatusProcessing, timeline.Events[1].Status)
+
+	suite.store.EXPECT().WalkStatement(gomock.Any(), user.ID(1), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ user.ID, _, _ time.Time, fn func(e *statement.Entry) error) error {
+			return fn(&statement.Entry{Kind: statement.KindOrder, Reference: "100", Status: string(order.StatusRegistered)})
+		})
+	var statuses []string
+	err = svc.GetUserStatement(context.TODO(), 1, timeline.UploadedAt, timeline.UploadedAt, func(e *statement.Entry) error {
+		statuses = append(statuses, e.Status)
+		return nil
+	})
+	suite.NoError(err)
+	suite.Equal([]string{string(order.StatusProcessing)}, statuses)
+}
+
+func (suite *accountServiceTestSuite) TestNewOrders() {
+	svc := New(suite.store, &log.Blackhole{})
+	numbers := []order.OrderNumber{"12345678903", "123", "9278923470", "12345678903", "79927398713"}
+	suite.store.EXPECT().NewOrders(gomock.Any(), user.ID(1), []order.OrderNumber{"12345678903", "9278923470", "79927398713"}).
+		Return([]*order.Order{{ID: 1, UserID: 1, Number: "12345678903"}}, nil)
+	suite.store.EXPECT().GetOrdersByNumbers(gomock.Any(), gomock.Len(2)).Return([]*order.Order{
+		{ID: 2, UserID: 1, Number: "9278923470"},
+		{ID: 3, UserID: 2, Number: "79927398713"},
+	}, nil)
+	items, err := svc.NewOrders(context.TODO(), 1, tenant.Default, numbers)
+	suite.NoError(err)
+	suite.Equal([]order.BatchItem{
+		{Number: "12345678903", Result: order.UploadAccepted},
+		{Number: "123", Result: order.UploadInvalid},
+		{Number: "9278923470", Result: order.UploadDuplicate},
+		{Number: "12345678903", Result: order.UploadAccepted},
+		{Number: "79927398713", Result: order.UploadConflict},
+	}, items)
+
+	// повторный номер получает результат первого вхождения, в том числе уже загруженного номера
+	suite.store.EXPECT().NewOrders(gomock.Any(), user.ID(1), []order.OrderNumber{"9278923470"}).Return([]*order.Order{}, nil)
+	suite.store.EXPECT().GetOrdersByNumbers(gomock.Any(), []order.OrderNumber{"9278923470"}).Return([]*order.Order{
+		{ID: 2, UserID: 2, Number: "9278923470"},
+	}, nil)
+	items, err = svc.NewOrders(context.TODO(), 1, tenant.Default, []order.OrderNumber{"9278923470", "9278923470"})
+	suite.NoError(err)
+	suite.Equal([]order.BatchItem{
+		{Number: "9278923470", Result: order.UploadConflict},
+		{Number: "9278923470", Result: order.UploadConflict},
+	}, items)
+
+	// пакет без корректных номеров не обращается к хранилищу
+	items, err = svc.NewOrders(context.TODO(), 1, tenant.Default, []order.OrderNumber{"123"})
+	suite.NoError(err)
+	suite.Equal([]order.BatchItem{{Number: "123", Result: order.UploadInvalid}}, items)
+
+	_, err = svc.NewOrders(context.TODO(), 1, tenant.Default, nil)
+	suite.ErrorIs(err, order.ErrInvalidBatch)
+	_, err = svc.NewOrders(context.TODO(), 1, tenant.Default, make([]order.OrderNumber, DefaultMaxBatchSize+1))
+	suite.ErrorIs(err, order.ErrInvalidBatch)
+}
+
+func (suite *accountServiceTestSuite) TestNewOrdersTenantBatchSize() {
+	directory, err := tenant.NewDirectory(tenant.Tenant{ID: "bork", MaxBatchSize: 1})
+	suite.Require().NoError(err)
+	svc := New(suite.store, &log.Blackhole{}).WithTenants(directory)
+
+	numbers := []order.OrderNumber{"12345678903", "9278923470"}
+	_, err = svc.NewOrders(context.TODO(), 1, "bork", numbers)
+	suite.ErrorIs(err, order.ErrInvalidBatch)
+
+	suite.store.EXPECT().NewOrders(gomock.Any(), user.ID(2), numbers).Return([]*order.Order{
+		{ID: 1, UserID: 2, Number: "12345678903"},
+		{ID: 2, UserID: 2, Number: "9278923470"},
+	}, nil)
+	_, err = svc.NewOrders(context.TODO(), 2, tenant.Default, numbers)
+	suite.NoError(err)
+}
+
+func (suite *accountServiceTestSuite) TestNewOrderValidator() {
+	svc := New(suite.store, &log.Blackhole{})
+	_, err := svc.NewOrder(context.TODO(), order.Order{UserID: 1, Number: "5724"})
+	suite.ErrorIs(err, order.ErrInvalidNumberFormat)
+
+	v, err := order.NewValidator(order.SchemeLuhn, order.SchemeRule{Prefix: "ACME-", Scheme: order.SchemeDamm})
+	suite.NoError(err)
+	svc.WithValidator(v)
+	suite.store.EXPECT().GetOrderByNumber(gomock.Any(), order.OrderNumber("ACME5724")).Return(nil, nil)
+	suite.store.EXPECT().NewOrder(gomock.Any(), gomock.Any()).Return(&order.Order{ID: 1, UserID: 1, Number: "ACME5724"}, nil)
+	_, err = svc.NewOrder(context.TODO(), order.Order{UserID: 1, Number: "ACME5724"})
+	suite.NoError(err)
+
+	items, err := svc.NewOrders(context.TODO(), 1, tenant.Default, []order.OrderNumber{"ACME5723", "5724"})
+	suite.NoError(err)
+	suite.Equal([]order.BatchItem{
+		{Number: "ACME5723", Result: order.UploadInvalid},
+		{Number: "5724", Result: order.UploadInvalid},
+	}, items)
+}
@@ -2,8 +2,11 @@ package account
 
 import (
 	"context"
+	"time"
 
 	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/promo"
+	"github.com/k1nky/gophermart/internal/entity/statement"
 	"github.com/k1nky/gophermart/internal/entity/user"
 	"github.com/k1nky/gophermart/internal/entity/withdraw"
 )
@@ -11,11 +14,17 @@ import (
 //go:generate mockgen -source=contract.go -destination=mock/storage.go -package=mock storage
 type storage interface {
 	NewOrder(ctx context.Context, newOrder order.Order) (*order.Order, error)
+	NewOrders(ctx context.Context, userID user.ID, numbers []order.OrderNumber) ([]*order.Order, error)
+	GetOrdersByNumbers(ctx context.Context, numbers []order.OrderNumber) ([]*order.Order, error)
 	GetOrderByNumber(ctx context.Context, number order.OrderNumber) (*order.Order, error)
 	GetOrdersByUserID(ctx context.Context, userID user.ID, maxRows uint) ([]*order.Order, error)
+	GetOrderEvents(ctx context.Context, id order.ID) ([]order.Event, error)
 	GetBalanceByUser(ctx context.Context, userID user.ID) (user.Balance, error)
+	GetBalanceByUserAt(ctx context.Context, userID user.ID, at time.Time) (user.Balance, error)
 	GetWithdrawalsByUserID(ctx context.Context, userID user.ID, maxRows uint) ([]*withdraw.Withdraw, error)
 	NewWithdraw(ctx context.Context, w withdraw.Withdraw) (*withdraw.Withdraw, error)
+	RedeemPromo(ctx context.Context, userID user.ID, code promo.Code) (*promo.Redemption, error)
+	WalkStatement(ctx context.Context, userID user.ID, from time.Time, to time.Time, fn func(e *statement.Entry) error) error
 }
 
 type logger interface {
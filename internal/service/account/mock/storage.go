@@ -7,9 +7,12 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	order "github.com/k1nky/gophermart/internal/entity/order"
+	promo "github.com/k1nky/gophermart/internal/entity/promo"
+	statement "github.com/k1nky/gophermart/internal/entity/statement"
 	user "github.com/k1nky/gophermart/internal/entity/user"
 	withdraw "github.com/k1nky/gophermart/internal/entity/withdraw"
 )
@@ -52,6 +55,21 @@ func (mr *MockstorageMockRecorder) GetBalanceByUser(ctx, userID interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceByUser", reflect.TypeOf((*Mockstorage)(nil).GetBalanceByUser), ctx, userID)
 }
 
+// GetBalanceByUserAt mocks base method.
+func (m *Mockstorage) GetBalanceByUserAt(ctx context.Context, userID user.ID, at time.Time) (user.Balance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceByUserAt", ctx, userID, at)
+	ret0, _ := ret[0].(user.Balance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceByUserAt indicates an expected call of GetBalanceByUserAt.
+func (mr *MockstorageMockRecorder) GetBalanceByUserAt(ctx, userID, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceByUserAt", reflect.TypeOf((*Mockstorage)(nil).GetBalanceByUserAt), ctx, userID, at)
+}
+
 // GetOrderByNumber mocks base method.
 func (m *Mockstorage) GetOrderByNumber(ctx context.Context, number order.OrderNumber) (*order.Order, error) {
 	m.ctrl.T.Helper()
@@ -67,6 +85,36 @@ func (mr *MockstorageMockRecorder) GetOrderByNumber(ctx, number interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByNumber", reflect.TypeOf((*Mockstorage)(nil).GetOrderByNumber), ctx, number)
 }
 
+// GetOrderEvents mocks base method.
+func (m *Mockstorage) GetOrderEvents(ctx context.Context, id order.ID) ([]order.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderEvents", ctx, id)
+	ret0, _ := ret[0].([]order.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderEvents indicates an expected call of GetOrderEvents.
+func (mr *MockstorageMockRecorder) GetOrderEvents(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderEvents", reflect.TypeOf((*Mockstorage)(nil).GetOrderEvents), ctx, id)
+}
+
+// GetOrdersByNumbers mocks base method.
+func (m *Mockstorage) GetOrdersByNumbers(ctx context.Context, numbers []order.OrderNumber) ([]*order.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersByNumbers", ctx, numbers)
+	ret0, _ := ret[0].([]*order.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersByNumbers indicates an expected call of GetOrdersByNumbers.
+func (mr *MockstorageMockRecorder) GetOrdersByNumbers(ctx, numbers interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByNumbers", reflect.TypeOf((*Mockstorage)(nil).GetOrdersByNumbers), ctx, numbers)
+}
+
 // GetOrdersByUserID mocks base method.
 func (m *Mockstorage) GetOrdersByUserID(ctx context.Context, userID user.ID, maxRows uint) ([]*order.Order, error) {
 	m.ctrl.T.Helper()
@@ -112,6 +160,21 @@ func (mr *MockstorageMockRecorder) NewOrder(ctx, newOrder interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewOrder", reflect.TypeOf((*Mockstorage)(nil).NewOrder), ctx, newOrder)
 }
 
+// NewOrders mocks base method.
+func (m *Mockstorage) NewOrders(ctx context.Context, userID user.ID, numbers []order.OrderNumber) ([]*order.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewOrders", ctx, userID, numbers)
+	ret0, _ := ret[0].([]*order.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewOrders indicates an expected call of NewOrders.
+func (mr *MockstorageMockRecorder) NewOrders(ctx, userID, numbers interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewOrders", reflect.TypeOf((*Mockstorage)(nil).NewOrders), ctx, userID, numbers)
+}
+
 // NewWithdraw mocks base method.
 func (m *Mockstorage) NewWithdraw(ctx context.Context, w withdraw.Withdraw) (*withdraw.Withdraw, error) {
 	m.ctrl.T.Helper()
@@ -127,6 +190,35 @@ func (mr *MockstorageMockRecorder) NewWithdraw(ctx, w interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewWithdraw", reflect.TypeOf((*Mockstorage)(nil).NewWithdraw), ctx, w)
 }
 
+// RedeemPromo mocks base method.
+func (m *Mockstorage) RedeemPromo(ctx context.Context, userID user.ID, code promo.Code) (*promo.Redemption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeemPromo", ctx, userID, code)
+	ret0, _ := ret[0].(*promo.Redemption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeemPromo indicates an expected call of RedeemPromo.
+func (mr *MockstorageMockRecorder) RedeemPromo(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemPromo", reflect.TypeOf((*Mockstorage)(nil).RedeemPromo), ctx, userID, code)
+}
+
+// WalkStatement mocks base method.
+func (m *Mockstorage) WalkStatement(ctx context.Context, userID user.ID, from, to time.Time, fn func(*statement.Entry) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalkStatement", ctx, userID, from, to, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WalkStatement indicates an expected call of WalkStatement.
+func (mr *MockstorageMockRecorder) WalkStatement(ctx, userID, from, to, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalkStatement", reflect.TypeOf((*Mockstorage)(nil).WalkStatement), ctx, userID, from, to, fn)
+}
+
 // Mocklogger is a mock of logger interface.
 type Mocklogger struct {
 	ctrl     *gomock.Controller
@@ -5,16 +5,20 @@ import (
 	"fmt"
 
 	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
 	"github.com/k1nky/gophermart/internal/entity/user"
 )
 
-// Регистрирует новый заказ
+// Регистрирует новый заказ. Номер заказа, не прошедший проверку валидатором сервиса, не регистрируется.
 func (s *Service) NewOrder(ctx context.Context, newOrder order.Order) (*order.Order, error) {
 	fail := func(err error) (*order.Order, error) {
 		wrapped := fmt.Errorf("account: new order: %w", err)
 		s.log.Errorf("%s", wrapped.Error())
 		return nil, wrapped
 	}
+	if !s.validator.IsValid(newOrder.Number) {
+		return nil, fmt.Errorf("%s %w", newOrder.Number, order.ErrInvalidNumberFormat)
+	}
 	o, err := s.store.GetOrderByNumber(ctx, newOrder.Number)
 	if err != nil {
 		return fail(err)
@@ -32,12 +36,108 @@ func (s *Service) NewOrder(ctx context.Context, newOrder order.Order) (*order.Or
 	return o, nil
 }
 
-// Возвращает спикок заказов пользователя
+// Регистрирует пакет номеров заказов пользователя программы лояльности tenantID. Корректные новые номера
+// регистрируются в рамках одной транзакции. Возвращает результат загрузки каждого номера в порядке номеров
+// в пакете, повторный номер в пакете получает тот же результат, что и его первое вхождение.
+func (s *Service) NewOrders(ctx context.Context, userID user.ID, tenantID tenant.ID, numbers []order.OrderNumber) ([]order.BatchItem, error) {
+	fail := func(err error) ([]order.BatchItem, error) {
+		wrapped := fmt.Errorf("account: new orders: %w", err)
+		s.log.Errorf("%s", wrapped.Error())
+		return nil, wrapped
+	}
+	if len(numbers) == 0 || len(numbers) > s.maxBatchSize(tenantID) {
+		return nil, fmt.Errorf("%d numbers %w", len(numbers), order.ErrInvalidBatch)
+	}
+	items := make([]order.BatchItem, len(numbers))
+	// первое вхождение каждого корректного номера в пакете
+	first := make(map[order.OrderNumber]int)
+	// повторные вхождения номеров в пакете
+	repeats := make([]int, 0)
+	valid := make([]order.OrderNumber, 0, len(numbers))
+	for i, number := range numbers {
+		items[i].Number = number
+		if !s.validator.IsValid(number) {
+			items[i].Result = order.UploadInvalid
+			continue
+		}
+		if _, ok := first[number]; ok {
+			repeats = append(repeats, i)
+			continue
+		}
+		first[number] = i
+		valid = append(valid, number)
+	}
+	if len(valid) == 0 {
+		return items, nil
+	}
+	created, err := s.store.NewOrders(ctx, userID, valid)
+	if err != nil {
+		return fail(err)
+	}
+	for _, o := range created {
+		items[first[o.Number]].Result = order.UploadAccepted
+	}
+	// оставшиеся номера уже были загружены ранее
+	existing := make([]order.OrderNumber, 0, len(valid)-len(created))
+	for _, number := range valid {
+		if len(items[first[number]].Result) == 0 {
+			existing = append(existing, number)
+		}
+	}
+	if len(existing) != 0 {
+		orders, err := s.store.GetOrdersByNumbers(ctx, existing)
+		if err != nil {
+			return fail(err)
+		}
+		for _, o := range orders {
+			if o.UserID == userID {
+				items[first[o.Number]].Result = order.UploadDuplicate
+			} else {
+				items[first[o.Number]].Result = order.UploadConflict
+			}
+		}
+	}
+	for _, i := range repeats {
+		items[i].Result = items[first[numbers[i]]].Result
+	}
+	return items, nil
+}
+
+// Возвращает спикок заказов пользователя. Внутренние статусы заказов заменяются статусами для пользователя.
 func (s *Service) GetUserOrders(ctx context.Context, userID user.ID) ([]*order.Order, error) {
 	orders, err := s.store.GetOrdersByUserID(ctx, userID, DefaultMaxRows)
 	if err != nil {
 		wrapped := fmt.Errorf("account: get new orders: %w", err)
 		s.log.Errorf("%s", wrapped)
 	}
+	for _, o := range orders {
+		o.Status = o.Status.Public()
+	}
 	return orders, err
 }
+
+// Возвращает заказ пользователя с историей изменения его статуса.
+// Заказ другого пользователя считается не найденным. Внутренние статусы заменяются статусами для пользователя.
+func (s *Service) GetUserOrderTimeline(ctx context.Context, userID user.ID, number order.OrderNumber) (*order.Timeline, error) {
+	fail := func(err error) (*order.Timeline, error) {
+		wrapped := fmt.Errorf("account: get order timeline: %w", err)
+		s.log.Errorf("%s", wrapped.Error())
+		return nil, wrapped
+	}
+	o, err := s.store.GetOrderByNumber(ctx, number)
+	if err != nil {
+		return fail(err)
+	}
+	if o == nil || o.UserID != userID {
+		return nil, fmt.Errorf("%s %w", number, order.ErrNotFound)
+	}
+	events, err := s.store.GetOrderEvents(ctx, o.ID)
+	if err != nil {
+		return fail(err)
+	}
+	o.Status = o.Status.Public()
+	for i := range events {
+		events[i].Status = events[i].Status.Public()
+	}
+	return &order.Timeline{Order: *o, Events: events}, nil
+}
@@ -0,0 +1,28 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/k1nky/gophermart/internal/entity/promo"
+	"github.com/k1nky/gophermart/internal/entity/user"
+)
+
+// Погашает промокод и начисляет баллы на счет пользователя
+func (s *Service) RedeemPromo(ctx context.Context, userID user.ID, code promo.Code) (*promo.Redemption, error) {
+	r, err := s.store.RedeemPromo(ctx, userID, code)
+	if err != nil && !isPromoRejected(err) {
+		err = fmt.Errorf("account: redeem promo: %w", err)
+		s.log.Errorf("%s", err)
+	}
+	return r, err
+}
+
+// Проверяет, что промокод не может быть погашен по бизнес-правилам, а не из-за внутренней ошибки
+func isPromoRejected(err error) bool {
+	return errors.Is(err, promo.ErrNotFound) ||
+		errors.Is(err, promo.ErrNotActive) ||
+		errors.Is(err, promo.ErrExhausted) ||
+		errors.Is(err, promo.ErrAlreadyRedeemed)
+}
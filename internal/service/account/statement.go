@@ -0,0 +1,27 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/statement"
+	"github.com/k1nky/gophermart/internal/entity/user"
+)
+
+// Передает в fn записи выписки пользователя за период [from, to): заказы, списания и транзакции
+func (s *Service) GetUserStatement(ctx context.Context, userID user.ID, from time.Time, to time.Time, fn func(e *statement.Entry) error) error {
+	err := s.store.WalkStatement(ctx, userID, from, to, func(e *statement.Entry) error {
+		if e.Kind == statement.KindOrder {
+			e.Status = string(order.OrderStatus(e.Status).Public())
+		}
+		return fn(e)
+	})
+	if err != nil {
+		err = fmt.Errorf("account: get user statement: %w", err)
+		s.log.Errorf("%s", err)
+		return err
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/k1nky/gophermart/internal/entity/user"
 	"github.com/k1nky/gophermart/internal/entity/withdraw"
@@ -19,6 +20,16 @@ func (s *Service) GetUserBalance(ctx context.Context, userID user.ID) (user.Bala
 	return b, err
 }
 
+// Возвращает баланс пользователя на момент времени at
+func (s *Service) GetUserBalanceAt(ctx context.Context, userID user.ID, at time.Time) (user.Balance, error) {
+	b, err := s.store.GetBalanceByUserAt(ctx, userID, at)
+	if err != nil {
+		err = fmt.Errorf("account: get user balance at %s: %w", at.Format(time.RFC3339), err)
+		s.log.Errorf("%s", err)
+	}
+	return b, err
+}
+
 // Возвращает списания пользователя
 func (s *Service) GetUserWithdrawals(ctx context.Context, userID user.ID) ([]*withdraw.Withdraw, error) {
 	withdrawals, err := s.store.GetWithdrawalsByUserID(ctx, userID, DefaultMaxRows)
@@ -2,53 +2,306 @@ package accural
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
 	"time"
 
+	"github.com/k1nky/gophermart/internal/entity/accrual"
 	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
 )
 
 const (
 	// обрабатываем за раз не более DefaultMaxRows заказов
 	DefaultMaxRows = 100
-	// максимальный размер очереди заказов на проверку начислений
+	// максимальный размер очереди заказов на проверку начислений в расчете на один обработчик
 	DefaultMaxOrderQueueSize = 2
 	// интервал обновления заказов
 	DefaultUpdateInterval = 5 * time.Second
+	// количество обработчиков запросов к системе начислений
+	DefaultWorkers = 1
+	// начальная задержка повторной проверки заказа
+	DefaultMinBackoff = 5 * time.Second
+	// максимальная задержка повторной проверки заказа
+	DefaultMaxBackoff = 30 * time.Minute
+	// время аренды заказа экземпляром сервиса
+	DefaultLeaseDuration = 5 * time.Minute
+	// количество некорректных ответов системы начислений по заказу, после которого проверка заказа прекращается
+	DefaultMaxFailures = 10
+	// префикс имени собственной системы начислений программы лояльности в состояниях и счетчиках систем
+	TenantProviderPrefix = "tenant:"
 )
 
+// Статусы заказов, начисления по которым еще могут измениться
+var pendingStatuses = []order.OrderStatus{order.StatusNew, order.StatusRegistered, order.StatusProcessing}
+
+// Настройки сервиса начислений
+type Options struct {
+	// количество параллельных обработчиков запросов к системе начислений, по умолчанию DefaultWorkers
+	Workers uint
+	// интервал обновления заказов, по умолчанию DefaultUpdateInterval
+	UpdateInterval time.Duration
+	// начальная задержка повторной проверки заказа, по умолчанию DefaultMinBackoff.
+	// С каждой проверкой задержка удваивается, но не превышает MaxBackoff.
+	MinBackoff time.Duration
+	// максимальная задержка повторной проверки заказа, по умолчанию DefaultMaxBackoff
+	MaxBackoff time.Duration
+	// время с момента загрузки, после которого необработанный заказ помечается как INVALID, 0 - без ограничений
+	GiveUpAfter time.Duration
+	// идентификатор экземпляра сервиса, по умолчанию <имя хоста>-<pid>
+	InstanceID string
+	// время аренды заказа, по умолчанию DefaultLeaseDuration. Если экземпляр сервиса не обработал
+	// заказ за это время (например, завершился аварийно), заказ может взять другой экземпляр.
+	LeaseDuration time.Duration
+	// количество некорректных ответов подряд системы начислений по заказу (ошибка сервера, некорректный ответ,
+	// неизвестный статус), после которого проверка заказа прекращается до вмешательства администратора,
+	// по умолчанию DefaultMaxFailures
+	MaxFailures uint
+}
+
+// Настройки проверки заказов программы лояльности
+type tenantOptions struct {
+	// система начислений программы, nil - общая система начислений сервиса
+	orderAccrual orderAccrual
+	// время с момента загрузки, после которого необработанный заказ помечается как INVALID, 0 - Options.GiveUpAfter
+	giveUpAfter time.Duration
+}
+
 type Service struct {
 	store        store
 	orderAccrual orderAccrual
 	log          logger
+	opts         Options
+	// настройки программ лояльности, заказы остальных программ проверяются с общими настройками
+	tenants map[tenant.ID]tenantOptions
+	// заказы, которые взяты в обработку и еще не обработаны
+	inflight   map[order.ID]struct{}
+	inflightMu sync.Mutex
+	// контекст запросов к системе начислений и записи результатов, отменяется,
+	// если обработка заказов не завершилась за время, отведенное на остановку сервиса
+	workCtx context.Context
+	abort   context.CancelFunc
+	// закрывается при остановке сервиса, после этого заказы в обработку не берутся
+	stop    chan struct{}
+	stopped bool
+	stopMu  sync.Mutex
+	// запущенные обработки заказов
+	runs sync.WaitGroup
 }
 
-func New(store store, orderAccrual orderAccrual, l logger) *Service {
+func New(store store, orderAccrual orderAccrual, opts Options, l logger) *Service {
+	if opts.Workers == 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.UpdateInterval == 0 {
+		opts.UpdateInterval = DefaultUpdateInterval
+	}
+	if opts.MinBackoff == 0 {
+		opts.MinBackoff = DefaultMinBackoff
+	}
+	if opts.MaxBackoff == 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+	if len(opts.InstanceID) == 0 {
+		opts.InstanceID = defaultInstanceID()
+	}
+	if opts.LeaseDuration == 0 {
+		opts.LeaseDuration = DefaultLeaseDuration
+	}
+	if opts.MaxFailures == 0 {
+		opts.MaxFailures = DefaultMaxFailures
+	}
+	workCtx, abort := context.WithCancel(context.Background())
 	return &Service{
 		log:          l,
 		orderAccrual: orderAccrual,
 		store:        store,
+		opts:         opts,
+		tenants:      make(map[tenant.ID]tenantOptions),
+		inflight:     make(map[order.ID]struct{}),
+		workCtx:      workCtx,
+		abort:        abort,
+		stop:         make(chan struct{}),
+	}
+}
+
+// Задает для заказов программы лояльности id собственную систему начислений orderAccrual и время giveUpAfter,
+// после которого необработанный заказ помечается как INVALID. Нулевые значения заменяются общими настройками
+// сервиса. Вызывается до запуска обработки заказов.
+func (s *Service) WithTenant(id tenant.ID, orderAccrual orderAccrual, giveUpAfter time.Duration) *Service {
+	s.tenants[id] = tenantOptions{orderAccrual: orderAccrual, giveUpAfter: giveUpAfter}
+	return s
+}
+
+// Возвращает систему начислений и время прекращения проверки для заказа o с учетом его программы лояльности
+func (s *Service) tenantOptions(o *order.Order) (orderAccrual, time.Duration) {
+	a, giveUpAfter := s.orderAccrual, s.opts.GiveUpAfter
+	if t, ok := s.tenants[o.TenantID.OrDefault()]; ok {
+		if t.orderAccrual != nil {
+			a = t.orderAccrual
+		}
+		if t.giveUpAfter > 0 {
+			giveUpAfter = t.giveUpAfter
+		}
+	}
+	return a, giveUpAfter
+}
+
+// Возвращает отбор программ лояльности, заказы которых можно проверить: программы, система начислений
+// которых недоступна, исключаются. Возвращает ложь, если недоступны системы начислений всех программ.
+func (s *Service) availableTenants() (tenant.Filter, bool) {
+	f := tenant.Filter{}
+	for id, t := range s.tenants {
+		if t.orderAccrual == nil {
+			continue
+		}
+		if t.orderAccrual.Available() {
+			f.Include = append(f.Include, id)
+		} else {
+			f.Exclude = append(f.Exclude, id)
+		}
+	}
+	// заказы остальных программ проверяются общей системой начислений
+	if s.orderAccrual.Available() {
+		return tenant.Filter{Exclude: f.Exclude}, true
+	}
+	return tenant.Filter{Include: f.Include}, len(f.Include) != 0
+}
+
+// Возвращает состояния автоматов защиты систем расчёта начислений по их именам, включая собственные
+// системы программ лояльности с именами вида TenantProviderPrefix<идентификатор программы>
+func (s *Service) CircuitStates() map[string]accrual.CircuitState {
+	states := make(map[string]accrual.CircuitState)
+	switch m := s.orderAccrual.(type) {
+	case accrualMonitor:
+		for name, state := range m.CircuitStates() {
+			states[name] = state
+		}
+	case circuitMonitor:
+		states[DefaultProvider] = m.CircuitState()
+	}
+	for id, t := range s.tenants {
+		if m, ok := t.orderAccrual.(circuitMonitor); ok {
+			states[TenantProviderPrefix+string(id)] = m.CircuitState()
+		}
+	}
+	return states
+}
+
+// Возвращает счетчики результатов запросов к системам расчёта начислений по их именам, включая собственные
+// системы программ лояльности, см. CircuitStates
+func (s *Service) FetchCounters() map[string]map[string]uint64 {
+	counters := make(map[string]map[string]uint64)
+	switch m := s.orderAccrual.(type) {
+	case accrualMonitor:
+		for name, c := range m.FetchCounters() {
+			counters[name] = c
+		}
+	case circuitMonitor:
+		counters[DefaultProvider] = m.FetchCounters()
+	}
+	for id, t := range s.tenants {
+		if m, ok := t.orderAccrual.(circuitMonitor); ok {
+			counters[TenantProviderPrefix+string(id)] = m.FetchCounters()
+		}
+	}
+	return counters
+}
+
+// Возвращает ложь, если система начислений orderAccrual не может проверить заказ o
+func available(orderAccrual orderAccrual, o *order.Order) bool {
+	if a, ok := orderAccrual.(orderAvailability); ok {
+		return a.AvailableFor(*o)
+	}
+	return orderAccrual.Available()
+}
+
+// Возвращает идентификатор экземпляра сервиса вида <имя хоста>-<pid>
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// Отмечает заказ как взятый в обработку. Возвращает ложь, если заказ уже в обработке.
+func (s *Service) acquire(id order.ID) bool {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+	if _, ok := s.inflight[id]; ok {
+		return false
 	}
+	s.inflight[id] = struct{}{}
+	return true
+}
+
+// Снимает с заказа отметку об обработке
+func (s *Service) release(id order.ID) {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+	delete(s.inflight, id)
+}
+
+// Возвращает задержку до следующей проверки заказа после attempts выполненных проверок
+func (s *Service) backoff(attempts uint) time.Duration {
+	d := s.opts.MinBackoff
+	for i := uint(0); i < attempts && d < s.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > s.opts.MaxBackoff {
+		d = s.opts.MaxBackoff
+	}
+	return d
 }
 
 func (s *Service) getNewOrders(ctx context.Context) <-chan *order.Order {
-	ordersCh := make(chan *order.Order, DefaultMaxOrderQueueSize)
+	ordersCh := make(chan *order.Order, s.opts.Workers*DefaultMaxOrderQueueSize)
+	// арендуем не больше заказов, чем успеют обработать обработчики, чтобы аренда не истекла в очереди
+	// и оставшиеся заказы достались другим экземплярам сервиса
+	claimSize := s.opts.Workers * (DefaultMaxOrderQueueSize + 1)
+	if claimSize > DefaultMaxRows {
+		claimSize = DefaultMaxRows
+	}
+	newOrdersCh := s.listenNewOrders(ctx)
 	go func() {
-		t := time.NewTicker(DefaultUpdateInterval)
+		defer close(ordersCh)
+		// новые заказы берутся в обработку сразу после загрузки, а периодическая проверка
+		// подбирает остальные заказы и новые заказы, уведомления о которых были пропущены
+		t := time.NewTicker(s.opts.UpdateInterval)
 		defer t.Stop()
 		for {
 			select {
+			case id := <-newOrdersCh:
+				// если система начислений программы заказа недоступна, то новый заказ будет взят
+				// при периодической проверке
+				tenants, ok := s.availableTenants()
+				if !ok {
+					continue
+				}
+				if !s.claimOrder(ctx, ordersCh, id, tenants) {
+					return
+				}
 			case <-t.C:
-				orders, err := s.store.GetOrdersByStatus(ctx, []order.OrderStatus{order.StatusNew, order.StatusProcessing}, DefaultMaxRows)
-				s.log.Debugf("accrual: got %d new orders", len(orders))
-				if err != nil {
-					s.log.Errorf("accrual: %v", err)
+				// не берем в обработку заказы программ, система начислений которых недоступна
+				tenants, ok := s.availableTenants()
+				if !ok {
+					s.log.Debugf("accrual: accrual systems are unavailable, polling is suspended")
 					continue
 				}
-				for _, o := range orders {
-					ordersCh <- o
+				// пока выборка заполняется целиком, продолжаем арендовать заказы не дожидаясь следующего интервала
+				for {
+					n, ok := s.claimOrders(ctx, ordersCh, tenants, claimSize)
+					if !ok {
+						return
+					}
+					if n < claimSize {
+						break
+					}
 				}
 			case <-ctx.Done():
-				close(ordersCh)
 				return
 			}
 		}
@@ -56,51 +309,335 @@ func (s *Service) getNewOrders(ctx context.Context) <-chan *order.Order {
 	return ordersCh
 }
 
-func (s *Service) updateOrder(ctx context.Context, orders <-chan *order.Order) <-chan *order.Order {
-	ordersCh := make(chan *order.Order, DefaultMaxOrderQueueSize)
+// Подписывается на уведомления о новых заказах и возвращает канал идентификаторов новых заказов.
+// При потере подписки повторяет попытку через UpdateInterval.
+func (s *Service) listenNewOrders(ctx context.Context) <-chan order.ID {
+	idsCh := make(chan order.ID, DefaultMaxRows)
 	go func() {
 		for {
+			err := s.store.ListenNewOrders(ctx, func(id order.ID) {
+				select {
+				case idsCh <- id:
+				default:
+					// очередь переполнена, заказ будет взят при периодической проверке
+				}
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			s.log.Errorf("accrual: listen new orders: %v", err)
 			select {
+			case <-time.After(s.opts.UpdateInterval):
 			case <-ctx.Done():
-				close(ordersCh)
 				return
-			case o := <-orders:
-				s.log.Debugf("accrual: fetch order #%s", o.Number)
-				got, err := s.orderAccrual.FetchOrder(ctx, o.Number)
-				if err != nil {
-					s.log.Errorf("accrual: failed fetching order #%s: %v", o.Number, err)
-					continue
-				}
-				if got == nil {
-					// заказ не зарегистрирован в системе начислений
-					continue
-				}
-				if got.Status == order.StatusRegistered {
-					got.Status = order.StatusProcessing
-				}
-				if o.Status != got.Status {
-					o.Status = got.Status
-					if o.Status == order.StatusProcessed {
-						o.Accrual = got.Accrual
-					}
-					ordersCh <- o
-				}
 			}
 		}
 	}()
-	return ordersCh
+	return idsCh
+}
+
+// Арендует заказ id программы лояльности, подходящей под отбор tenants, и отправляет его в очередь ordersCh.
+// Возвращает ложь, если контекст отменен.
+func (s *Service) claimOrder(ctx context.Context, ordersCh chan<- *order.Order, id order.ID, tenants tenant.Filter) bool {
+	o, err := s.store.ClaimOrder(ctx, s.opts.InstanceID, id, pendingStatuses, tenants, s.opts.LeaseDuration)
+	if err != nil {
+		s.log.Errorf("accrual: %v", err)
+		return ctx.Err() == nil
+	}
+	// заказ уже обработан или арендован
+	if o == nil || !s.acquire(o.ID) {
+		return true
+	}
+	select {
+	case ordersCh <- o:
+	case <-ctx.Done():
+		s.release(o.ID)
+		return false
+	}
+	return true
+}
+
+// Арендует не более maxRows заказов программ лояльности, подходящих под отбор tenants, и отправляет их
+// в очередь ordersCh. Возвращает количество арендованных заказов и ложь, если контекст отменен.
+func (s *Service) claimOrders(ctx context.Context, ordersCh chan<- *order.Order, tenants tenant.Filter, maxRows uint) (uint, bool) {
+	orders, err := s.store.ClaimOrders(ctx, s.opts.InstanceID, pendingStatuses, tenants, s.opts.LeaseDuration, maxRows)
+	s.log.Debugf("accrual: got %d new orders", len(orders))
+	if err != nil {
+		s.log.Errorf("accrual: %v", err)
+		return 0, ctx.Err() == nil
+	}
+	for _, o := range orders {
+		// заказ еще не обработан с предыдущей проверки
+		if !s.acquire(o.ID) {
+			continue
+		}
+		select {
+		case ordersCh <- o:
+		case <-ctx.Done():
+			s.release(o.ID)
+			return 0, false
+		}
+	}
+	return uint(len(orders)), true
+}
+
+// Результат проверки заказа в системе начислений
+type check struct {
+	order *order.Order
+	// статус заказа изменился
+	changed bool
+	// некорректный ответ системы начислений по заказу
+	err error
+}
+
+// Запускает обработчики, которые параллельно запрашивают начисления по заказам из orders.
+// Возвращает канал результатов проверки заказов.
+func (s *Service) updateOrders(ctx context.Context, orders <-chan *order.Order) <-chan check {
+	checksCh := make(chan check, s.opts.Workers*DefaultMaxOrderQueueSize)
+	wg := sync.WaitGroup{}
+	for i := uint(0); i < s.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for o := range orders {
+				changed, err := s.updateOrder(ctx, o)
+				select {
+				case checksCh <- check{order: o, changed: changed, err: err}:
+				case <-ctx.Done():
+					s.release(o.ID)
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(checksCh)
+	}()
+	return checksCh
+}
+
+// Запрашивает начисление по заказу и обновляет его. Возвращает истину, если статус заказа изменился, и
+// ошибку, если система начислений вернула по заказу некорректный ответ.
+func (s *Service) updateOrder(ctx context.Context, o *order.Order) (bool, error) {
+	orderAccrual, giveUpAfter := s.tenantOptions(o)
+	// система начислений, которая проверяет заказ, недоступна, заказ будет проверен позже
+	if !available(orderAccrual, o) {
+		return false, nil
+	}
+	// новый заказ со сведениями о покупке сначала регистрируется в системе начислений
+	if registrar, ok := orderAccrual.(orderRegistrar); ok && o.Status == order.StatusNew && o.Purchase != nil {
+		s.log.Debugf("accrual: register order #%s", o.Number)
+		if err := registrar.RegisterOrder(ctx, *o); err != nil {
+			s.log.Errorf("accrual: failed registering order #%s: %v", o.Number, err)
+			if errors.Is(err, order.ErrInvalidAccrualResponse) {
+				return false, err
+			}
+		}
+	}
+	s.log.Debugf("accrual: fetch order #%s", o.Number)
+	got, err := orderAccrual.FetchOrder(ctx, *o)
+	if err != nil {
+		s.log.Errorf("accrual: failed fetching order #%s: %v", o.Number, err)
+		got = nil
+		// недоступность системы начислений не учитывается как некорректный ответ по заказу
+		if !errors.Is(err, order.ErrInvalidAccrualResponse) {
+			err = nil
+		}
+	}
+	if got != nil {
+		if err = checkStatus(got.Status); err != nil {
+			s.log.Errorf("accrual: failed fetching order #%s: %v", o.Number, err)
+			got = nil
+		} else {
+			// проверка прекращается только после MaxFailures некорректных ответов подряд
+			o.Failures = 0
+		}
+	}
+	if got != nil && applyAccrual(o, got) {
+		return true, nil
+	}
+	// заказ не зарегистрирован в системе начислений или его статус не изменился
+	if giveUpAfter > 0 && time.Since(o.UploadedAt) > giveUpAfter {
+		s.log.Debugf("accrual: give up order #%s uploaded at %s", o.Number, o.UploadedAt)
+		o.Status = order.StatusInvalid
+		return true, nil
+	}
+	return false, err
+}
+
+// Возвращает ошибку, если status не является статусом расчёта начисления
+func checkStatus(status order.OrderStatus) error {
+	switch status {
+	case order.StatusRegistered, order.StatusProcessing, order.StatusInvalid, order.StatusProcessed:
+		return nil
+	}
+	return fmt.Errorf("%s %w", status, order.ErrUnknownStatus)
+}
+
+// Применяет к заказу o результат расчета начисления got. Возвращает истину, если статус заказа изменился.
+func applyAccrual(o *order.Order, got *order.Order) bool {
+	if o.Status == got.Status {
+		return false
+	}
+	o.Status = got.Status
+	if o.Status == order.StatusProcessed {
+		o.Accrual = got.Accrual
+	}
+	if len(got.Provider) != 0 {
+		o.Provider = got.Provider
+	}
+	o.Payload = got.Payload
+	return true
+}
+
+// Применяет результат расчета начисления, который система начислений передала по своей инициативе.
+// Заказ обновляется так же, как при опросе системы начислений.
+func (s *Service) ApplyAccrual(ctx context.Context, got order.Order) error {
+	if err := checkStatus(got.Status); err != nil {
+		return err
+	}
+	o, err := s.store.GetOrderByNumber(ctx, got.Number)
+	if err != nil {
+		s.log.Errorf("accrual: apply order #%s: %v", got.Number, err)
+		return fmt.Errorf("accrual: apply order #%s: %w", got.Number, err)
+	}
+	if o == nil {
+		return fmt.Errorf("%s %w", got.Number, order.ErrNotFound)
+	}
+	if o.Status.IsFinal() {
+		// повторное уведомление с тем же результатом не считаем ошибкой
+		if o.Status == got.Status {
+			return nil
+		}
+		return fmt.Errorf("%s %w", got.Number, order.ErrAlreadyProcessed)
+	}
+	if !applyAccrual(o, &got) {
+		return nil
+	}
+	if err := s.store.UpdateOrder(ctx, "", *o, order.SourceCallback); err != nil {
+		if !errors.Is(err, order.ErrAlreadyProcessed) {
+			s.log.Errorf("accrual: apply order #%s: %v", got.Number, err)
+		}
+		return fmt.Errorf("accrual: apply order #%s: %w", got.Number, err)
+	}
+	return nil
 }
 
-func (s *Service) Process(ctx context.Context) {
+// Запускает обработку заказов. При отмене контекста ctx или остановке сервиса новые заказы в обработку
+// не берутся, а уже взятые заказы обрабатываются до конца, см. Shutdown. Возвращает канал, который
+// закрывается, когда обработка взятых заказов завершена.
+func (s *Service) Process(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	s.stopMu.Lock()
+	defer s.stopMu.Unlock()
+	if s.stopped {
+		close(done)
+		return done
+	}
+	s.runs.Add(1)
+
+	intakeCtx, cancel := context.WithCancel(ctx)
 	go func() {
-		// У сервиса accrual есть ограничение по количеству запросов. Адаптер этого сервиса умеет
-		// повторять запрос с ожидаением Retry-After. В этом случае getNewOrders также будет ожидать и
+		select {
+		case <-s.stop:
+		case <-intakeCtx.Done():
+		}
+		cancel()
+	}()
+	go func() {
+		defer close(done)
+		defer s.runs.Done()
+		defer cancel()
+		ctx := s.workCtx
+		// У сервиса accrual есть ограничение по количеству запросов. Адаптер этого сервиса ограничивает
+		// частоту запросов всех обработчиков. В этом случае getNewOrders также будет ожидать и
 		// не добавлять в очередь новые запросы для проверки начислений.
-		for o := range s.updateOrder(ctx, s.getNewOrders(ctx)) {
-			if err := s.store.UpdateOrder(ctx, *o); err != nil {
-				s.log.Errorf("accrual: poll order #%s: %v", o.Number, err)
+		for c := range s.updateOrders(ctx, s.getNewOrders(intakeCtx)) {
+			o := c.order
+			// обработка прервана, аренда заказа истечет и его возьмет в обработку другой экземпляр
+			if ctx.Err() != nil {
+				s.release(o.ID)
 				continue
 			}
+			if c.err != nil {
+				s.failOrder(ctx, o, c.err)
+				s.release(o.ID)
+				continue
+			}
+			if c.changed {
+				if err := s.store.UpdateOrder(ctx, s.opts.InstanceID, *o, order.SourcePoller); err != nil {
+					// аренда перешла к другому экземпляру, результат проверки устарел
+					if errors.Is(err, order.ErrLeaseLost) {
+						s.log.Debugf("accrual: poll order #%s: %v", o.Number, err)
+						s.release(o.ID)
+						continue
+					}
+					s.log.Errorf("accrual: poll order #%s: %v", o.Number, err)
+				}
+			}
+			// откладываем следующую проверку заказа, статус которого еще может измениться
+			if !o.Status.IsFinal() {
+				if err := s.store.DeferOrder(ctx, s.opts.InstanceID, *o, s.backoff(o.Attempts)); err != nil {
+					s.logLeaseError("defer", o, err)
+				}
+			}
+			s.release(o.ID)
 		}
 	}()
+	return done
+}
+
+// Обрабатывает заказы до отмены контекста ctx или остановки сервиса и возвращает управление только после
+// того, как взятые заказы обработаны. Используется как задача лидера: блокировка лидерства освобождается,
+// только когда экземпляр больше не проверяет и не записывает заказы.
+func (s *Service) Run(ctx context.Context) {
+	<-s.Process(ctx)
+}
+
+// Записывает в журнал ошибку действия action с арендованным заказом o. Потеря аренды ожидаема, если
+// заказ не был обработан за время аренды, и записывается как отладочное сообщение.
+func (s *Service) logLeaseError(action string, o *order.Order, err error) {
+	if errors.Is(err, order.ErrLeaseLost) {
+		s.log.Debugf("accrual: %s order #%s: %v", action, o.Number, err)
+		return
+	}
+	s.log.Errorf("accrual: %s order #%s: %v", action, o.Number, err)
+}
+
+// Останавливает обработку заказов: новые заказы в обработку не берутся, а запросы по уже взятым заказам
+// завершаются и их результаты записываются. Если обработка не завершилась до отмены контекста ctx, запросы
+// к системе начислений и запись результатов прерываются и возвращается ошибка контекста.
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.stopMu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.stop)
+	}
+	s.stopMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.abort()
+		return ctx.Err()
+	}
+}
+
+// Учитывает некорректный ответ err системы начислений по заказу o и откладывает следующую проверку заказа.
+// После MaxFailures некорректных ответов проверка заказа прекращается.
+func (s *Service) failOrder(ctx context.Context, o *order.Order, err error) {
+	deadLettered, err := s.store.FailOrder(ctx, s.opts.InstanceID, o.ID, err.Error(), s.backoff(o.Attempts), s.opts.MaxFailures)
+	if err != nil {
+		s.logLeaseError("fail", o, err)
+		return
+	}
+	if deadLettered {
+		s.log.Errorf("accrual: order #%s is dead-lettered after %d invalid responses", o.Number, o.Failures+1)
+	}
 }
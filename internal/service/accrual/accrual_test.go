@@ -0,0 +1,941 @@
+package accural
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1nky/gophermart/internal/entity/accrual"
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+	log "github.com/k1nky/gophermart/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	mu        sync.Mutex
+	orders    map[order.ID]order.Order
+	updates   map[order.ID]int
+	nextCheck map[order.ID]time.Time
+	delays    map[order.ID][]time.Duration
+	leases    map[order.ID]time.Time
+	// экземпляры, арендовавшие заказы
+	owners map[order.ID]string
+	// источники изменений заказов
+	sources map[order.ID][]order.EventSource
+	// уведомления о новых заказах
+	notify chan order.ID
+}
+
+func newFakeStore(n int) *fakeStore {
+	s := &fakeStore{
+		orders:    make(map[order.ID]order.Order),
+		updates:   make(map[order.ID]int),
+		nextCheck: make(map[order.ID]time.Time),
+		delays:    make(map[order.ID][]time.Duration),
+		leases:    make(map[order.ID]time.Time),
+		owners:    make(map[order.ID]string),
+		sources:   make(map[order.ID][]order.EventSource),
+		notify:    make(chan order.ID),
+	}
+	for i := 1; i <= n; i++ {
+		s.orders[order.ID(i)] = order.Order{
+			ID:         order.ID(i),
+			Number:     order.OrderNumber(fmt.Sprint(i)),
+			Status:     order.StatusNew,
+			UploadedAt: time.Now(),
+		}
+	}
+	return s
+}
+
+func (s *fakeStore) ClaimOrders(ctx context.Context, owner string, statuses []order.OrderStatus, tenants tenant.Filter, lease time.Duration, maxRows uint) ([]*order.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orders := make([]*order.Order, 0)
+	for _, o := range s.orders {
+		if uint(len(orders)) == maxRows {
+			break
+		}
+		if s.nextCheck[o.ID].After(time.Now()) || s.leases[o.ID].After(time.Now()) || o.DeadLetteredAt != nil ||
+			!tenants.Match(o.TenantID.OrDefault()) {
+			continue
+		}
+		for _, status := range statuses {
+			if o.Status == status {
+				o := o
+				orders = append(orders, &o)
+				s.leases[o.ID] = time.Now().Add(lease)
+				s.owners[o.ID] = owner
+				break
+			}
+		}
+	}
+	return orders, nil
+}
+
+func (s *fakeStore) GetOrderByNumber(ctx context.Context, number order.OrderNumber) (*order.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, o := range s.orders {
+		if o.Number == number {
+			return &o, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeStore) ClaimOrder(ctx context.Context, owner string, id order.ID, statuses []order.OrderStatus, tenants tenant.Filter, lease time.Duration) (*order.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orders[id]
+	if !ok || s.nextCheck[id].After(time.Now()) || s.leases[id].After(time.Now()) || o.DeadLetteredAt != nil ||
+		!tenants.Match(o.TenantID.OrDefault()) {
+		return nil, nil
+	}
+	for _, status := range statuses {
+		if o.Status == status {
+			s.leases[id] = time.Now().Add(lease)
+			s.owners[id] = owner
+			return &o, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeStore) ListenNewOrders(ctx context.Context, fn func(id order.ID)) error {
+	for {
+		select {
+		case id := <-s.notify:
+			fn(id)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Возвращает ErrLeaseLost, если заказ id не арендован owner. Пустой owner - заказ изменяется без аренды.
+func (s *fakeStore) checkLease(owner string, id order.ID) error {
+	if len(owner) != 0 && s.owners[id] != owner {
+		return order.ErrLeaseLost
+	}
+	return nil
+}
+
+// Снимает аренду заказа id
+func (s *fakeStore) unlock(id order.ID) {
+	delete(s.leases, id)
+	delete(s.owners, id)
+}
+
+func (s *fakeStore) UpdateOrder(ctx context.Context, owner string, o order.Order, source order.EventSource) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkLease(owner, o.ID); err != nil {
+		return err
+	}
+	s.orders[o.ID] = o
+	s.sources[o.ID] = append(s.sources[o.ID], source)
+	s.updates[o.ID]++
+	if len(owner) == 0 || o.Status.IsFinal() {
+		s.unlock(o.ID)
+	}
+	return nil
+}
+
+func (s *fakeStore) DeferOrder(ctx context.Context, owner string, deferred order.Order, delay time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := deferred.ID
+	if err := s.checkLease(owner, id); err != nil {
+		return err
+	}
+	o := s.orders[id]
+	o.Attempts++
+	o.Failures = deferred.Failures
+	s.orders[id] = o
+	s.nextCheck[id] = time.Now().Add(delay)
+	s.delays[id] = append(s.delays[id], delay)
+	s.unlock(id)
+	return nil
+}
+
+func (s *fakeStore) FailOrder(ctx context.Context, owner string, id order.ID, reason string, delay time.Duration, maxFailures uint) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkLease(owner, id); err != nil {
+		return false, err
+	}
+	o := s.orders[id]
+	o.Attempts++
+	o.Failures++
+	o.LastError = reason
+	if maxFailures > 0 && o.Failures >= maxFailures {
+		now := time.Now()
+		o.DeadLetteredAt = &now
+	}
+	s.orders[id] = o
+	s.nextCheck[id] = time.Now().Add(delay)
+	s.delays[id] = append(s.delays[id], delay)
+	s.unlock(id)
+	return o.DeadLetteredAt != nil, nil
+}
+
+func (s *fakeStore) get(id order.ID) (order.Order, []time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.orders[id], append([]time.Duration{}, s.delays[id]...)
+}
+
+func (s *fakeStore) processed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, o := range s.orders {
+		if o.Status == order.StatusProcessed {
+			n++
+		}
+	}
+	return n
+}
+
+type fakeAccrual struct {
+	mu    sync.Mutex
+	delay time.Duration
+	// статус, возвращаемый системой начислений, по умолчанию PROCESSED
+	status order.OrderStatus
+	// ошибка, возвращаемая системой начислений
+	err         error
+	unavailable atomic.Bool
+	fetches     map[order.OrderNumber]int
+	active      int
+	maxParallel int
+}
+
+func (a *fakeAccrual) FetchOrder(ctx context.Context, o order.Order) (*order.Order, error) {
+	number := o.Number
+	a.mu.Lock()
+	a.fetches[number]++
+	a.active++
+	if a.active > a.maxParallel {
+		a.maxParallel = a.active
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-time.After(a.delay):
+	case <-ctx.Done():
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.active--
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if a.err != nil {
+		return nil, a.err
+	}
+	if len(a.status) != 0 {
+		return &order.Order{Number: number, Status: a.status}, nil
+	}
+	accrual := float32(100)
+	return &order.Order{Number: number, Status: order.StatusProcessed, Accrual: &accrual}, nil
+}
+
+func (a *fakeAccrual) fetched(number order.OrderNumber) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.fetches[number]
+}
+
+func (a *fakeAccrual) Available() bool {
+	return !a.unavailable.Load()
+}
+
+// Система начислений с автоматом защиты
+type fakeCircuitAccrual struct {
+	fakeAccrual
+	state accrual.CircuitState
+}
+
+func (a *fakeCircuitAccrual) CircuitState() accrual.CircuitState {
+	return a.state
+}
+
+func (a *fakeCircuitAccrual) FetchCounters() map[string]uint64 {
+	return map[string]uint64{"ok": 1}
+}
+
+func TestCircuitStates(t *testing.T) {
+	r := NewRouter(&fakeCircuitAccrual{state: accrual.CircuitClosed})
+	r.Add("partner", []string{"7"}, nil, &fakeCircuitAccrual{state: accrual.CircuitHalfOpen})
+	s := New(newFakeStore(0), r, Options{}, &log.Blackhole{})
+	s.WithTenant("bork", &fakeCircuitAccrual{state: accrual.CircuitOpen}, 0).
+		WithTenant("acme", &fakeAccrual{}, 0).
+		WithTenant("dodo", nil, time.Minute)
+
+	assert.Equal(t, map[string]accrual.CircuitState{
+		DefaultProvider: accrual.CircuitClosed,
+		"partner":       accrual.CircuitHalfOpen,
+		"tenant:bork":   accrual.CircuitOpen,
+	}, s.CircuitStates())
+	assert.Equal(t, map[string]map[string]uint64{
+		DefaultProvider: {"ok": 1},
+		"partner":       {"ok": 1},
+		"tenant:bork":   {"ok": 1},
+	}, s.FetchCounters())
+}
+
+func TestProcessWorkerPool(t *testing.T) {
+	const (
+		orders  = 50
+		workers = 5
+	)
+	store := newFakeStore(orders)
+	orderAccrual := &fakeAccrual{
+		delay:   20 * time.Millisecond,
+		fetches: make(map[order.OrderNumber]int),
+	}
+	// интервал обновления меньше времени запроса, поэтому заказы в обработке попадают в выборку повторно
+	s := New(store, orderAccrual, Options{Workers: workers, UpdateInterval: 5 * time.Millisecond}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	assert.Eventually(t, func() bool {
+		return store.processed() == orders
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+
+	orderAccrual.mu.Lock()
+	defer orderAccrual.mu.Unlock()
+	assert.Len(t, orderAccrual.fetches, orders)
+	for number, n := range orderAccrual.fetches {
+		assert.Equal(t, 1, n, "order #%s fetched more than once", number)
+	}
+	assert.Greater(t, orderAccrual.maxParallel, 1)
+	assert.LessOrEqual(t, orderAccrual.maxParallel, workers)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for id, n := range store.updates {
+		assert.Equal(t, 1, n, "order %d updated more than once", id)
+	}
+}
+
+func TestRun(t *testing.T) {
+	store := newFakeStore(1)
+	orderAccrual := &fakeAccrual{
+		delay:   100 * time.Millisecond,
+		fetches: make(map[order.OrderNumber]int),
+	}
+	s := New(store, orderAccrual, Options{UpdateInterval: 5 * time.Millisecond}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return orderAccrual.fetched("1") == 1
+	}, 5*time.Second, time.Millisecond)
+	cancel()
+	<-done
+	// Run возвращает управление только после завершения запроса, начатого до отмены контекста
+	orderAccrual.mu.Lock()
+	defer orderAccrual.mu.Unlock()
+	assert.Zero(t, orderAccrual.active)
+}
+
+func TestProcessMultipleInstances(t *testing.T) {
+	const orders = 30
+	store := newFakeStore(orders)
+	orderAccrual := &fakeAccrual{
+		delay:   5 * time.Millisecond,
+		fetches: make(map[order.OrderNumber]int),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, id := range []string{"a", "b", "c"} {
+		s := New(store, orderAccrual, Options{
+			Workers:        2,
+			UpdateInterval: 5 * time.Millisecond,
+			InstanceID:     id,
+			LeaseDuration:  time.Minute,
+		}, &log.Blackhole{})
+		s.Process(ctx)
+	}
+
+	assert.Eventually(t, func() bool {
+		return store.processed() == orders
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+
+	orderAccrual.mu.Lock()
+	defer orderAccrual.mu.Unlock()
+	assert.Len(t, orderAccrual.fetches, orders)
+	for number, n := range orderAccrual.fetches {
+		assert.Equal(t, 1, n, "order #%s fetched more than once", number)
+	}
+}
+
+func TestProcessExpiredLease(t *testing.T) {
+	store := newFakeStore(1)
+	// заказ арендован экземпляром, который завершился аварийно
+	store.leases[1] = time.Now().Add(50 * time.Millisecond)
+	orderAccrual := &fakeAccrual{
+		fetches: make(map[order.OrderNumber]int),
+	}
+	s := New(store, orderAccrual, Options{UpdateInterval: 5 * time.Millisecond}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	assert.Eventually(t, func() bool {
+		return store.processed() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestProcessNewOrderNotification(t *testing.T) {
+	store := newFakeStore(2)
+	orderAccrual := &fakeAccrual{
+		fetches: make(map[order.OrderNumber]int),
+	}
+	// периодическая проверка не наступит за время теста
+	s := New(store, orderAccrual, Options{UpdateInterval: time.Hour}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	store.notify <- 2
+	assert.Eventually(t, func() bool {
+		return store.processed() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	o, _ := store.get(2)
+	assert.Equal(t, order.StatusProcessed, o.Status)
+	o, _ = store.get(1)
+	assert.Equal(t, order.StatusNew, o.Status)
+}
+
+func TestProcessSuspendedWhileUnavailable(t *testing.T) {
+	store := newFakeStore(3)
+	orderAccrual := &fakeAccrual{
+		fetches: make(map[order.OrderNumber]int),
+	}
+	orderAccrual.unavailable.Store(true)
+	s := New(store, orderAccrual, Options{UpdateInterval: 5 * time.Millisecond}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	time.Sleep(50 * time.Millisecond)
+	orderAccrual.mu.Lock()
+	assert.Empty(t, orderAccrual.fetches)
+	orderAccrual.mu.Unlock()
+
+	orderAccrual.unavailable.Store(false)
+	assert.Eventually(t, func() bool {
+		return store.processed() == 3
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestProcessBackoff(t *testing.T) {
+	store := newFakeStore(1)
+	orderAccrual := &fakeAccrual{
+		status:  order.StatusProcessing,
+		fetches: make(map[order.OrderNumber]int),
+	}
+	s := New(store, orderAccrual, Options{
+		UpdateInterval: 5 * time.Millisecond,
+		MinBackoff:     10 * time.Millisecond,
+		MaxBackoff:     40 * time.Millisecond,
+	}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	assert.Eventually(t, func() bool {
+		_, delays := store.get(1)
+		return len(delays) >= 4
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+
+	o, delays := store.get(1)
+	assert.Equal(t, order.StatusProcessing, o.Status)
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond}, delays[:4])
+}
+
+func TestProcessGiveUp(t *testing.T) {
+	store := newFakeStore(2)
+	store.orders[1] = order.Order{ID: 1, Number: "1", Status: order.StatusProcessing, UploadedAt: time.Now().Add(-time.Hour)}
+	orderAccrual := &fakeAccrual{
+		status:  order.StatusProcessing,
+		fetches: make(map[order.OrderNumber]int),
+	}
+	s := New(store, orderAccrual, Options{
+		UpdateInterval: 5 * time.Millisecond,
+		MinBackoff:     time.Hour,
+		GiveUpAfter:    time.Minute,
+	}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	assert.Eventually(t, func() bool {
+		o, _ := store.get(1)
+		return o.Status == order.StatusInvalid
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		_, delays := store.get(2)
+		return len(delays) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+
+	o, delays := store.get(1)
+	assert.Equal(t, order.StatusInvalid, o.Status)
+	assert.Empty(t, delays)
+	o, _ = store.get(2)
+	assert.Equal(t, order.StatusProcessing, o.Status)
+}
+
+func TestProcessResetsFailures(t *testing.T) {
+	store := newFakeStore(1)
+	store.orders[1] = order.Order{ID: 1, Number: "1", Status: order.StatusNew, UploadedAt: time.Now(), Failures: 2}
+	orderAccrual := &fakeAccrual{
+		status:  order.StatusProcessing,
+		fetches: make(map[order.OrderNumber]int),
+	}
+	s := New(store, orderAccrual, Options{
+		UpdateInterval: 5 * time.Millisecond,
+		MinBackoff:     time.Hour,
+		MaxFailures:    3,
+	}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	assert.Eventually(t, func() bool {
+		_, delays := store.get(1)
+		return len(delays) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+
+	o, _ := store.get(1)
+	assert.Equal(t, order.StatusProcessing, o.Status)
+	assert.Zero(t, o.Failures)
+}
+
+func TestProcessTenants(t *testing.T) {
+	store := newFakeStore(3)
+	store.orders[2] = order.Order{ID: 2, Number: "2", Status: order.StatusNew, UploadedAt: time.Now(), TenantID: "bork"}
+	store.orders[3] = order.Order{ID: 3, Number: "3", Status: order.StatusProcessing, UploadedAt: time.Now().Add(-time.Hour), TenantID: "acme"}
+	defaultAccrual := &fakeAccrual{
+		status:  order.StatusProcessing,
+		fetches: make(map[order.OrderNumber]int),
+	}
+	borkAccrual := &fakeAccrual{fetches: make(map[order.OrderNumber]int)}
+	s := New(store, defaultAccrual, Options{
+		UpdateInterval: 5 * time.Millisecond,
+		MinBackoff:     time.Hour,
+	}, &log.Blackhole{})
+	s.WithTenant("bork", borkAccrual, 0).WithTenant("acme", nil, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	assert.Eventually(t, func() bool {
+		o2, _ := store.get(2)
+		o3, _ := store.get(3)
+		_, delays := store.get(1)
+		return o2.Status == order.StatusProcessed && o3.Status == order.StatusInvalid && len(delays) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+
+	o, _ := store.get(1)
+	assert.Equal(t, order.StatusProcessing, o.Status)
+	assert.Equal(t, 1, defaultAccrual.fetched("1"))
+	assert.Equal(t, 1, defaultAccrual.fetched("3"))
+	assert.Equal(t, 0, defaultAccrual.fetched("2"))
+	assert.Equal(t, 1, borkAccrual.fetched("2"))
+}
+
+func TestProcessDeadLetter(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  order.OrderStatus
+		err     error
+		failing bool
+	}{
+		{name: "Invalid response", err: fmt.Errorf("500: %w", order.ErrInvalidAccrualResponse), failing: true},
+		{name: "Unknown status", status: "UNKNOWN", failing: true},
+		{name: "Unavailable", err: fmt.Errorf("connection refused")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore(1)
+			orderAccrual := &fakeAccrual{
+				status:  tt.status,
+				err:     tt.err,
+				fetches: make(map[order.OrderNumber]int),
+			}
+			s := New(store, orderAccrual, Options{
+				UpdateInterval: 5 * time.Millisecond,
+				MinBackoff:     time.Millisecond,
+				MaxBackoff:     time.Millisecond,
+				MaxFailures:    3,
+			}, &log.Blackhole{})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s.Process(ctx)
+
+			assert.Eventually(t, func() bool {
+				_, delays := store.get(1)
+				return len(delays) >= 5 || (tt.failing && len(delays) == 3)
+			}, 5*time.Second, 10*time.Millisecond)
+			// заказ, проверка которого прекращена, больше не запрашивается
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+
+			o, delays := store.get(1)
+			assert.Equal(t, order.StatusNew, o.Status)
+			if tt.failing {
+				assert.Len(t, delays, 3)
+				assert.Equal(t, uint(3), o.Failures)
+				assert.NotEmpty(t, o.LastError)
+				assert.NotNil(t, o.DeadLetteredAt)
+			} else {
+				assert.Zero(t, o.Failures)
+				assert.Nil(t, o.DeadLetteredAt)
+			}
+		})
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	store := newFakeStore(1)
+	orderAccrual := &fakeAccrual{
+		delay:   100 * time.Millisecond,
+		fetches: make(map[order.OrderNumber]int),
+	}
+	s := New(store, orderAccrual, Options{UpdateInterval: 5 * time.Millisecond}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	assert.Eventually(t, func() bool {
+		return orderAccrual.fetched("1") == 1
+	}, 5*time.Second, time.Millisecond)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	assert.NoError(t, s.Shutdown(shutdownCtx))
+	// результат запроса, начатого до остановки, записан
+	o, _ := store.get(1)
+	assert.Equal(t, order.StatusProcessed, o.Status)
+
+	// после остановки заказы в обработку не берутся
+	store.mu.Lock()
+	store.orders[2] = order.Order{ID: 2, Number: "2", Status: order.StatusNew, UploadedAt: time.Now()}
+	store.mu.Unlock()
+	s.Process(ctx)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, orderAccrual.fetched("2"))
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	store := newFakeStore(1)
+	orderAccrual := &fakeAccrual{
+		delay:   time.Hour,
+		fetches: make(map[order.OrderNumber]int),
+	}
+	s := New(store, orderAccrual, Options{UpdateInterval: 5 * time.Millisecond}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	assert.Eventually(t, func() bool {
+		return orderAccrual.fetched("1") == 1
+	}, 5*time.Second, time.Millisecond)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer shutdownCancel()
+	assert.ErrorIs(t, s.Shutdown(shutdownCtx), context.DeadlineExceeded)
+	// прерванный запрос не записывается
+	time.Sleep(20 * time.Millisecond)
+	o, delays := store.get(1)
+	assert.Equal(t, order.StatusNew, o.Status)
+	assert.Empty(t, delays)
+}
+
+func TestApplyAccrual(t *testing.T) {
+	accrual := float32(50)
+	tests := []struct {
+		name       string
+		got        order.Order
+		wantErr    error
+		wantStatus order.OrderStatus
+		wantUpdate bool
+	}{
+		{
+			name:       "Processed",
+			got:        order.Order{Number: "1", Status: order.StatusProcessed, Accrual: &accrual},
+			wantStatus: order.StatusProcessed,
+			wantUpdate: true,
+		},
+		{
+			name:       "Registered",
+			got:        order.Order{Number: "1", Status: order.StatusRegistered},
+			wantStatus: order.StatusRegistered,
+			wantUpdate: true,
+		},
+		{
+			name:       "Unknown status",
+			got:        order.Order{Number: "1", Status: "DONE"},
+			wantErr:    order.ErrUnknownStatus,
+			wantStatus: order.StatusNew,
+		},
+		{
+			name:    "Not found",
+			got:     order.Order{Number: "100", Status: order.StatusProcessed},
+			wantErr: order.ErrNotFound,
+		},
+		{
+			name:       "Repeated",
+			got:        order.Order{Number: "2", Status: order.StatusInvalid},
+			wantStatus: order.StatusInvalid,
+		},
+		{
+			name:       "Already processed",
+			got:        order.Order{Number: "2", Status: order.StatusProcessed},
+			wantErr:    order.ErrAlreadyProcessed,
+			wantStatus: order.StatusInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore(2)
+			store.orders[2] = order.Order{ID: 2, Number: "2", Status: order.StatusInvalid}
+			s := New(store, &fakeAccrual{}, Options{}, &log.Blackhole{})
+			err := s.ApplyAccrual(context.TODO(), tt.got)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			o, err := store.GetOrderByNumber(context.TODO(), tt.got.Number)
+			assert.NoError(t, err)
+			if o != nil {
+				assert.Equal(t, tt.wantStatus, o.Status)
+			}
+			store.mu.Lock()
+			defer store.mu.Unlock()
+			assert.Equal(t, tt.wantUpdate, store.updates[order.ID(1)] == 1)
+			if tt.wantUpdate {
+				assert.Equal(t, []order.EventSource{order.SourceCallback}, store.sources[order.ID(1)])
+			}
+		})
+	}
+}
+
+func TestRouter(t *testing.T) {
+	fallback := &fakeAccrual{fetches: make(map[order.OrderNumber]int)}
+	partner := &fakeAccrual{fetches: make(map[order.OrderNumber]int)}
+	acme := &fakeAccrual{fetches: make(map[order.OrderNumber]int)}
+	bork := &fakeAccrual{fetches: make(map[order.OrderNumber]int)}
+	r := NewRouter(fallback)
+	r.Add("partner", []string{"7"}, nil, partner)
+	r.Add("acme", []string{"77", "5"}, nil, acme)
+	r.Add("bork", nil, []string{"Bork"}, bork)
+	r.Add("bork-partner", []string{"7"}, []string{"bork"}, acme)
+
+	purchase := func(merchant string) *order.Purchase {
+		return &order.Purchase{Total: 100, Currency: "RUB", Merchant: merchant}
+	}
+	tests := []struct {
+		o    order.Order
+		want string
+	}{
+		{o: order.Order{Number: "12345678903"}, want: DefaultProvider},
+		{o: order.Order{Number: "79927398713"}, want: "partner"},
+		// выбирается самый длинный совпавший префикс
+		{o: order.Order{Number: "77927398713"}, want: "acme"},
+		{o: order.Order{Number: "5062821234567892"}, want: "acme"},
+		// продавец сравнивается без учета регистра
+		{o: order.Order{Number: "4561261212345467", Purchase: purchase("BORK")}, want: "bork"},
+		{o: order.Order{Number: "4561261212345467", Purchase: purchase("Tefal")}, want: DefaultProvider},
+		// при равной длине префикса выбирается система, выбранная по продавцу
+		{o: order.Order{Number: "79927398713", Purchase: purchase("Bork")}, want: "bork-partner"},
+		{o: order.Order{Number: "77927398713", Purchase: purchase("Bork")}, want: "acme"},
+	}
+	for _, tt := range tests {
+		o, err := r.FetchOrder(context.TODO(), tt.o)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, o.Provider, tt.o.Number)
+	}
+	assert.Equal(t, map[order.OrderNumber]int{"12345678903": 1, "4561261212345467": 1}, fallback.fetches)
+	assert.Equal(t, map[order.OrderNumber]int{"79927398713": 1}, partner.fetches)
+	assert.Equal(t, map[order.OrderNumber]int{"77927398713": 2, "5062821234567892": 1, "79927398713": 1}, acme.fetches)
+	assert.Equal(t, map[order.OrderNumber]int{"4561261212345467": 1}, bork.fetches)
+
+	// система недоступна, только если недоступны все системы
+	fallback.unavailable.Store(true)
+	partner.unavailable.Store(true)
+	assert.True(t, r.Available())
+	// доступность для заказа определяется системой, выбранной для заказа
+	assert.False(t, r.AvailableFor(order.Order{Number: "79927398713"}))
+	assert.True(t, r.AvailableFor(order.Order{Number: "77927398713"}))
+	acme.unavailable.Store(true)
+	bork.unavailable.Store(true)
+	assert.False(t, r.Available())
+}
+
+func TestProcessRecordsProvider(t *testing.T) {
+	store := newFakeStore(1)
+	r := NewRouter(&fakeAccrual{fetches: make(map[order.OrderNumber]int)})
+	s := New(store, r, Options{UpdateInterval: 5 * time.Millisecond}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	assert.Eventually(t, func() bool {
+		return store.processed() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	o, _ := store.get(1)
+	assert.Equal(t, DefaultProvider, o.Provider)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	assert.Equal(t, []order.EventSource{order.SourcePoller}, store.sources[order.ID(1)])
+}
+
+// Система начислений, поддерживающая регистрацию заказов
+type fakeRegistrar struct {
+	*fakeAccrual
+	err        error
+	registered []order.OrderNumber
+}
+
+func (a *fakeRegistrar) RegisterOrder(ctx context.Context, o order.Order) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	// заказ регистрируется до запроса начисления по нему
+	if a.fetches[o.Number] == 0 {
+		a.registered = append(a.registered, o.Number)
+	}
+	return nil
+}
+
+func TestProcessRegistersOrder(t *testing.T) {
+	store := newFakeStore(2)
+	o, _ := store.get(1)
+	o.Purchase = &order.Purchase{Total: 7000, Currency: "RUB", Items: []order.Item{{Description: "Чайник Bork", Price: 7000}}}
+	store.orders[o.ID] = o
+	registrar := &fakeRegistrar{fakeAccrual: &fakeAccrual{fetches: make(map[order.OrderNumber]int)}}
+	r := NewRouter(&fakeAccrual{fetches: make(map[order.OrderNumber]int)})
+	r.Add("partner", []string{"1"}, nil, registrar)
+	s := New(store, r, Options{UpdateInterval: 5 * time.Millisecond}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	assert.Eventually(t, func() bool {
+		return store.processed() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	registrar.mu.Lock()
+	defer registrar.mu.Unlock()
+	// заказ без сведений о покупке не регистрируется
+	assert.Equal(t, []order.OrderNumber{"1"}, registrar.registered)
+}
+
+func TestProcessRegisterOrderRejected(t *testing.T) {
+	store := newFakeStore(1)
+	o, _ := store.get(1)
+	o.Purchase = &order.Purchase{Total: 7000, Currency: "RUB"}
+	store.orders[o.ID] = o
+	registrar := &fakeRegistrar{
+		fakeAccrual: &fakeAccrual{fetches: make(map[order.OrderNumber]int)},
+		err:         fmt.Errorf("rejected: %w", order.ErrInvalidAccrualResponse),
+	}
+	s := New(store, registrar, Options{
+		UpdateInterval: 5 * time.Millisecond,
+		MinBackoff:     time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		MaxFailures:    2,
+	}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	// отказ в регистрации учитывается как некорректный ответ, начисление по заказу не запрашивается
+	assert.Eventually(t, func() bool {
+		o, _ := store.get(1)
+		return o.DeadLetteredAt != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, registrar.fetched("1"))
+}
+
+func TestProcessTenantsAvailability(t *testing.T) {
+	store := newFakeStore(3)
+	store.orders[2] = order.Order{ID: 2, Number: "2", Status: order.StatusNew, UploadedAt: time.Now(), TenantID: "bork"}
+	store.orders[3] = order.Order{ID: 3, Number: "3", Status: order.StatusNew, UploadedAt: time.Now(), TenantID: "acme"}
+	defaultAccrual := &fakeAccrual{fetches: make(map[order.OrderNumber]int)}
+	defaultAccrual.unavailable.Store(true)
+	borkAccrual := &fakeAccrual{fetches: make(map[order.OrderNumber]int)}
+	acmeAccrual := &fakeAccrual{fetches: make(map[order.OrderNumber]int)}
+	acmeAccrual.unavailable.Store(true)
+	s := New(store, defaultAccrual, Options{UpdateInterval: 5 * time.Millisecond}, &log.Blackhole{})
+	s.WithTenant("bork", borkAccrual, 0).WithTenant("acme", acmeAccrual, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	// заказы программы с доступной системой начислений проверяются, остальные не берутся в обработку
+	assert.Eventually(t, func() bool {
+		return store.processed() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	o, _ := store.get(2)
+	assert.Equal(t, order.StatusProcessed, o.Status)
+	for _, id := range []order.ID{1, 3} {
+		o, delays := store.get(id)
+		assert.Equal(t, order.StatusNew, o.Status)
+		assert.Empty(t, delays)
+	}
+
+	// общая система начислений доступна, заказы программы acme по-прежнему не берутся в обработку
+	defaultAccrual.unavailable.Store(false)
+	assert.Eventually(t, func() bool {
+		return store.processed() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, acmeAccrual.fetched("3"))
+	_, delays := store.get(3)
+	assert.Empty(t, delays)
+}
+
+func TestProcessProviderUnavailable(t *testing.T) {
+	store := newFakeStore(1)
+	store.orders[2] = order.Order{ID: 2, Number: "79927398713", Status: order.StatusNew, UploadedAt: time.Now()}
+	partner := &fakeAccrual{fetches: make(map[order.OrderNumber]int)}
+	partner.unavailable.Store(true)
+	r := NewRouter(&fakeAccrual{fetches: make(map[order.OrderNumber]int)})
+	r.Add("partner", []string{"7"}, nil, partner)
+	s := New(store, r, Options{UpdateInterval: 5 * time.Millisecond, MinBackoff: time.Hour}, &log.Blackhole{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Process(ctx)
+
+	// заказ системы, которая недоступна, откладывается без запроса к ней
+	assert.Eventually(t, func() bool {
+		_, delays := store.get(2)
+		return store.processed() == 1 && len(delays) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	o, _ := store.get(2)
+	assert.Equal(t, order.StatusNew, o.Status)
+	assert.Equal(t, 0, partner.fetched("79927398713"))
+}
@@ -2,8 +2,11 @@ package accural
 
 import (
 	"context"
+	"time"
 
+	"github.com/k1nky/gophermart/internal/entity/accrual"
 	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
 )
 
 type logger interface {
@@ -12,10 +15,35 @@ type logger interface {
 }
 
 type store interface {
-	GetOrdersByStatus(ctx context.Context, statuses []order.OrderStatus, maxRows uint) ([]*order.Order, error)
-	UpdateOrder(ctx context.Context, o order.Order) error
+	GetOrderByNumber(ctx context.Context, number order.OrderNumber) (*order.Order, error)
+	ClaimOrders(ctx context.Context, owner string, statuses []order.OrderStatus, tenants tenant.Filter, lease time.Duration, maxRows uint) ([]*order.Order, error)
+	ClaimOrder(ctx context.Context, owner string, id order.ID, statuses []order.OrderStatus, tenants tenant.Filter, lease time.Duration) (*order.Order, error)
+	ListenNewOrders(ctx context.Context, fn func(id order.ID)) error
+	DeferOrder(ctx context.Context, owner string, o order.Order, delay time.Duration) error
+	FailOrder(ctx context.Context, owner string, id order.ID, reason string, delay time.Duration, maxFailures uint) (bool, error)
+	UpdateOrder(ctx context.Context, owner string, o order.Order, source order.EventSource) error
 }
 
 type orderAccrual interface {
-	FetchOrder(ctx context.Context, number order.OrderNumber) (*order.Order, error)
+	// возвращает информацию о начислении по заказу o
+	FetchOrder(ctx context.Context, o order.Order) (*order.Order, error)
+	// возвращает ложь, если система начислений недоступна и проверять заказы не имеет смысла
+	Available() bool
+}
+
+// Система начислений из нескольких систем, доступность которой зависит от заказа
+type orderAvailability interface {
+	// возвращает ложь, если система, которая проверяет заказ o, недоступна
+	AvailableFor(o order.Order) bool
+}
+
+// Система начислений, в которой заказ со сведениями о покупке нужно зарегистрировать до запроса начисления
+type orderRegistrar interface {
+	RegisterOrder(ctx context.Context, o order.Order) error
+}
+
+// Система начислений из нескольких систем с автоматами защиты
+type accrualMonitor interface {
+	CircuitStates() map[string]accrual.CircuitState
+	FetchCounters() map[string]map[string]uint64
 }
@@ -0,0 +1,166 @@
+package accural
+
+import (
+	"context"
+	"strings"
+
+	"github.com/k1nky/gophermart/internal/entity/accrual"
+	"github.com/k1nky/gophermart/internal/entity/order"
+)
+
+// Имя системы расчёта начислений, в которую направляются заказы, не подходящие ни под одно правило
+const DefaultProvider = "default"
+
+// Система расчёта начислений и правила выбора заказов для нее
+type provider struct {
+	name string
+	// префиксы номеров заказов, начисления по которым рассчитывает система
+	prefixes []string
+	// продавцы, начисления по покупкам у которых рассчитывает система
+	merchants []string
+	accrual   orderAccrual
+}
+
+// Возвращает истину, если заказ o подходит под правила выбора заказов системы, длину совпавшего префикса
+// и истину, если система выбрана по продавцу. Пустой список префиксов или продавцов не ограничивает выбор,
+// система без правил не выбирается.
+func (p provider) match(o order.Order) (ok bool, prefix int, byMerchant bool) {
+	if len(p.prefixes) == 0 && len(p.merchants) == 0 {
+		return false, 0, false
+	}
+	if len(p.prefixes) != 0 {
+		prefix = -1
+		for _, v := range p.prefixes {
+			if len(v) > prefix && strings.HasPrefix(string(o.Number), v) {
+				prefix = len(v)
+			}
+		}
+		if prefix < 0 {
+			return false, 0, false
+		}
+	}
+	if len(p.merchants) != 0 {
+		if o.Purchase == nil {
+			return false, 0, false
+		}
+		for _, v := range p.merchants {
+			if strings.EqualFold(o.Purchase.Merchant, v) {
+				return true, prefix, true
+			}
+		}
+		return false, 0, false
+	}
+	return true, prefix, false
+}
+
+// Состояние автомата защиты системы расчёта начислений
+type circuitMonitor interface {
+	CircuitState() accrual.CircuitState
+	FetchCounters() map[string]uint64
+}
+
+// Router направляет запросы информации о начислении в одну из нескольких систем расчёта начислений
+// по правилам выбора заказов по номеру и продавцу и реализует интерфейс orderAccrual. Заказы, не подходящие
+// ни под одно правило, направляются в систему по умолчанию. Правила добавляются до начала использования, после этого
+// Router безопасен для конкурентного использования.
+type Router struct {
+	fallback  provider
+	providers []provider
+}
+
+// Создает маршрутизатор с системой расчёта начислений по умолчанию fallback
+func NewRouter(fallback orderAccrual) *Router {
+	return &Router{
+		fallback: provider{name: DefaultProvider, accrual: fallback},
+	}
+}
+
+// Добавляет систему расчёта начислений name, в которую направляются заказы с номерами,
+// начинающимися с одного из префиксов prefixes, по покупкам у одного из продавцов merchants.
+// Продавец сравнивается без учета регистра, заказ без сведений о покупке не подходит под систему
+// с продавцами. Пустой список prefixes или merchants не ограничивает выбор заказов.
+// Если заказ подходит под несколько систем, выбирается система с самым длинным совпавшим префиксом,
+// при равной длине - система, выбранная по продавцу, затем - система, добавленная раньше.
+func (r *Router) Add(name string, prefixes []string, merchants []string, accrual orderAccrual) {
+	r.providers = append(r.providers, provider{name: name, prefixes: prefixes, merchants: merchants, accrual: accrual})
+}
+
+// Возвращает систему расчёта начислений для заказа o
+func (r *Router) route(o order.Order) provider {
+	found := r.fallback
+	longest, byMerchant := -1, false
+	for _, p := range r.providers {
+		ok, prefix, merchant := p.match(o)
+		if !ok {
+			continue
+		}
+		if prefix > longest || (prefix == longest && merchant && !byMerchant) {
+			found = p
+			longest, byMerchant = prefix, merchant
+		}
+	}
+	return found
+}
+
+// Запрашивает информацию о начислении у системы, выбранной для заказа, и
+// указывает ее в заказе как систему, рассчитавшую начисление
+func (r *Router) FetchOrder(ctx context.Context, o order.Order) (*order.Order, error) {
+	p := r.route(o)
+	got, err := p.accrual.FetchOrder(ctx, o)
+	if got != nil {
+		got.Provider = p.name
+	}
+	return got, err
+}
+
+// Регистрирует заказ в системе, выбранной для заказа, если система поддерживает регистрацию заказов
+func (r *Router) RegisterOrder(ctx context.Context, o order.Order) error {
+	if registrar, ok := r.route(o).accrual.(orderRegistrar); ok {
+		return registrar.RegisterOrder(ctx, o)
+	}
+	return nil
+}
+
+// Возвращает ложь, если недоступна система расчёта начислений, выбранная для заказа o
+func (r *Router) AvailableFor(o order.Order) bool {
+	return r.route(o).accrual.Available()
+}
+
+// Возвращает ложь, если недоступны все системы расчёта начислений
+func (r *Router) Available() bool {
+	if r.fallback.accrual.Available() {
+		return true
+	}
+	for _, p := range r.providers {
+		if p.accrual.Available() {
+			return true
+		}
+	}
+	return false
+}
+
+// Возвращает состояния автоматов защиты систем расчёта начислений по их именам
+func (r *Router) CircuitStates() map[string]accrual.CircuitState {
+	states := make(map[string]accrual.CircuitState)
+	for _, p := range r.all() {
+		if m, ok := p.accrual.(circuitMonitor); ok {
+			states[p.name] = m.CircuitState()
+		}
+	}
+	return states
+}
+
+// Возвращает счетчики результатов запросов к системам расчёта начислений по их именам
+func (r *Router) FetchCounters() map[string]map[string]uint64 {
+	counters := make(map[string]map[string]uint64)
+	for _, p := range r.all() {
+		if m, ok := p.accrual.(circuitMonitor); ok {
+			counters[p.name] = m.FetchCounters()
+		}
+	}
+	return counters
+}
+
+func (r *Router) all() []provider {
+	return append([]provider{r.fallback}, r.providers...)
+}
@@ -0,0 +1,225 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/k1nky/gophermart/internal/entity/admin"
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/promo"
+	"github.com/k1nky/gophermart/internal/entity/reward"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+	"github.com/k1nky/gophermart/internal/entity/user"
+	"github.com/k1nky/gophermart/internal/entity/withdraw"
+)
+
+const (
+	DefaultMaxRows = 1000
+)
+
+// Service выполняет действия администраторов над заказами, балансами пользователей и промокодами.
+// Каждое действие требует указания причины и записывается в журнал действий администраторов.
+type Service struct {
+	store storage
+	log   logger
+}
+
+func New(store storage, log logger) *Service {
+	return &Service{
+		store: store,
+		log:   log,
+	}
+}
+
+// Возвращает заказ программы лояльности tenantID в очередь на проверку начислений, в том числе заказ
+// в окончательном статусе.
+// Уже проведенное начисление по заказу списывается и будет проведено заново по результату проверки.
+func (s *Service) RequeueOrder(ctx context.Context, tenantID tenant.ID, number order.OrderNumber, by string, reason string) (*order.Order, error) {
+	r, err := newAuditRecord(by, reason)
+	if err != nil {
+		return nil, err
+	}
+	o, err := s.store.RequeueOrder(ctx, tenantID, number, r)
+	if err != nil {
+		return nil, s.fail("requeue order", number, err)
+	}
+	s.log.Infof("admin: %s requeued order #%s in %s: %s", by, number, tenantID, reason)
+	return o, nil
+}
+
+// Принудительно устанавливает статус и начисление заказа программы лояльности tenantID. Начисление допускается только для статуса PROCESSED.
+func (s *Service) ForceOrderStatus(ctx context.Context, tenantID tenant.ID, number order.OrderNumber, status order.OrderStatus, accrual *float32, by string, reason string) (*order.Order, error) {
+	r, err := newAuditRecord(by, reason)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case order.StatusNew, order.StatusProcessing, order.StatusInvalid, order.StatusProcessed:
+	default:
+		return nil, fmt.Errorf("%s %w", status, order.ErrUnknownStatus)
+	}
+	if accrual != nil && (status != order.StatusProcessed || *accrual < 0) {
+		return nil, fmt.Errorf("accrual %v for status %s: %w", *accrual, status, admin.ErrInvalidAmount)
+	}
+	o, err := s.store.ForceOrderStatus(ctx, tenantID, number, status, accrual, r)
+	if err != nil {
+		return nil, s.fail("force order status", number, err)
+	}
+	s.log.Infof("admin: %s set status %s for order #%s in %s: %s", by, status, number, tenantID, reason)
+	return o, nil
+}
+
+// Проводит корректировку баланса пользователя программы лояльности tenantID с логином login на amount,
+// amount может быть отрицательным
+func (s *Service) NewAdjustment(ctx context.Context, tenantID tenant.ID, login string, amount float32, by string, reason string) (*admin.Adjustment, error) {
+	r, err := newAuditRecord(by, reason)
+	if err != nil {
+		return nil, err
+	}
+	if amount == 0 {
+		return nil, fmt.Errorf("%v %w", amount, admin.ErrInvalidAmount)
+	}
+	u, err := s.store.GetUserByLogin(ctx, tenantID, login)
+	if err != nil {
+		return nil, s.fail("new adjustment", login, err)
+	}
+	if u == nil {
+		return nil, fmt.Errorf("%s %w", login, user.ErrNotFound)
+	}
+	adj, err := s.store.NewAdjustment(ctx, admin.Adjustment{
+		UserID: u.ID,
+		Amount: amount,
+		Reason: r.Reason,
+		Admin:  r.Admin,
+	})
+	if err != nil {
+		return nil, s.fail("new adjustment", login, err)
+	}
+	s.log.Infof("admin: %s adjusted balance of %s in %s by %v: %s", by, login, tenantID, amount, reason)
+	return adj, nil
+}
+
+// Создает промокод программы лояльности tenantID. Промокод погашается только пользователями программы.
+func (s *Service) NewPromo(ctx context.Context, tenantID tenant.ID, p promo.Promo, by string, reason string) (*promo.Promo, error) {
+	r, err := newAuditRecord(by, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	created, err := s.store.NewPromo(ctx, tenantID, p, r)
+	if err != nil {
+		return nil, s.fail("new promo", p.Code, err)
+	}
+	s.log.Infof("admin: %s created promo %s in %s: %s", by, created.Code, tenantID, reason)
+	return created, nil
+}
+
+// Возвращает заказы программы лояльности tenantID, проверка которых прекращена из-за некорректных ответов
+// системы начислений
+func (s *Service) GetDeadLetterOrders(ctx context.Context, tenantID tenant.ID) ([]*order.Order, error) {
+	orders, err := s.store.GetDeadLetterOrders(ctx, tenantID, DefaultMaxRows)
+	if err != nil {
+		err = fmt.Errorf("admin: get dead-letter orders: %w", err)
+		s.log.Errorf("%s", err)
+		return nil, err
+	}
+	return orders, nil
+}
+
+// Возобновляет проверку заказов программы лояльности tenantID с номерами numbers, проверка которых была
+// прекращена, пустой numbers - всех таких заказов программы. Возвращает возобновленные заказы, заказы не в состоянии прекращенной проверки пропускаются.
+func (s *Service) RetryDeadLetterOrders(ctx context.Context, tenantID tenant.ID, numbers []order.OrderNumber, by string, reason string) ([]*order.Order, error) {
+	r, err := newAuditRecord(by, reason)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.store.RetryDeadLetterOrders(ctx, tenantID, numbers, r)
+	if err != nil {
+		return nil, s.fail("retry dead-letter orders", numbers, err)
+	}
+	s.log.Infof("admin: %s retried %d dead-letter orders in %s: %s", by, len(orders), tenantID, reason)
+	return orders, nil
+}
+
+// Возвращает все правила расчёта вознаграждений собственной системы расчёта начислений
+// для программы лояльности tenantID
+func (s *Service) GetRewardRules(ctx context.Context, tenantID tenant.ID) ([]*reward.Rule, error) {
+	rules, err := s.store.GetRewardRules(ctx, tenantID)
+	if err != nil {
+		err = fmt.Errorf("admin: get reward rules: %w", err)
+		s.log.Errorf("%s", err)
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Создает правило расчёта вознаграждений программы лояльности tenantID. Правило применяется к заказам программы,
+// начисление по которым еще не рассчитано.
+func (s *Service) NewRewardRule(ctx context.Context, tenantID tenant.ID, rule reward.Rule, by string, reason string) (*reward.Rule, error) {
+	r, err := newAuditRecord(by, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	created, err := s.store.NewRewardRule(ctx, tenantID, rule, r)
+	if err != nil {
+		return nil, s.fail("new reward rule", rule.Name, err)
+	}
+	s.log.Infof("admin: %s created reward rule %d %q in %s: %s", by, created.ID, created.Name, tenantID, reason)
+	return created, nil
+}
+
+// Изменяет правило расчёта вознаграждений программы лояльности tenantID с идентификатором rule.ID. Уже рассчитанные начисления не пересчитываются.
+func (s *Service) UpdateRewardRule(ctx context.Context, tenantID tenant.ID, rule reward.Rule, by string, reason string) (*reward.Rule, error) {
+	r, err := newAuditRecord(by, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	updated, err := s.store.UpdateRewardRule(ctx, tenantID, rule, r)
+	if err != nil {
+		return nil, s.fail("update reward rule", rule.ID, err)
+	}
+	s.log.Infof("admin: %s updated reward rule %d in %s: %s", by, rule.ID, tenantID, reason)
+	return updated, nil
+}
+
+// Удаляет правило расчёта вознаграждений программы лояльности tenantID с идентификатором id
+func (s *Service) DeleteRewardRule(ctx context.Context, tenantID tenant.ID, id reward.ID, by string, reason string) error {
+	r, err := newAuditRecord(by, reason)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteRewardRule(ctx, tenantID, id, r); err != nil {
+		return s.fail("delete reward rule", id, err)
+	}
+	s.log.Infof("admin: %s deleted reward rule %d in %s: %s", by, id, tenantID, reason)
+	return nil
+}
+
+func (s *Service) fail(action string, subject interface{}, err error) error {
+	// отказ по бизнес-правилам не является внутренней ошибкой
+	if errors.Is(err, order.ErrNotFound) || errors.Is(err, withdraw.ErrInsufficientBalance) || errors.Is(err, promo.ErrDuplicated) ||
+		errors.Is(err, reward.ErrNotFound) {
+		return err
+	}
+	wrapped := fmt.Errorf("admin: %s %v: %w", action, subject, err)
+	s.log.Errorf("%s", wrapped)
+	return wrapped
+}
+
+func newAuditRecord(by string, reason string) (admin.AuditRecord, error) {
+	reason = strings.TrimSpace(reason)
+	if len(reason) == 0 {
+		return admin.AuditRecord{}, admin.ErrReasonRequired
+	}
+	return admin.AuditRecord{Admin: by, Reason: reason}, nil
+}
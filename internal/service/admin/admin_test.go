@@ -0,0 +1,156 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/k1nky/gophermart/internal/entity/admin"
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/promo"
+	"github.com/k1nky/gophermart/internal/entity/reward"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+	"github.com/k1nky/gophermart/internal/entity/user"
+	"github.com/k1nky/gophermart/internal/entity/withdraw"
+	log "github.com/k1nky/gophermart/internal/logger"
+	"github.com/k1nky/gophermart/internal/service/admin/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type adminServiceTestSuite struct {
+	suite.Suite
+	store *mock.Mockstorage
+	svc   *Service
+}
+
+func TestAdminService(t *testing.T) {
+	suite.Run(t, new(adminServiceTestSuite))
+}
+
+func (suite *adminServiceTestSuite) SetupTest() {
+	ctrl := gomock.NewController(suite.T())
+	suite.store = mock.NewMockstorage(ctrl)
+	suite.svc = New(suite.store, &log.Blackhole{})
+}
+
+func amount(v float32) *float32 {
+	return &v
+}
+
+func (suite *adminServiceTestSuite) TestRequeueOrder() {
+	r := admin.AuditRecord{Admin: "support", Reason: "accrual system fixed"}
+	suite.store.EXPECT().RequeueOrder(gomock.Any(), tenant.ID("bork"), order.OrderNumber("100"), r).Return(&order.Order{Number: "100", Status: order.StatusNew}, nil)
+	o, err := suite.svc.RequeueOrder(context.TODO(), "bork", "100", "support", " accrual system fixed ")
+	suite.NoError(err)
+	suite.Equal(order.StatusNew, o.Status)
+
+	_, err = suite.svc.RequeueOrder(context.TODO(), "bork", "100", "support", " ")
+	suite.ErrorIs(err, admin.ErrReasonRequired)
+
+	suite.store.EXPECT().RequeueOrder(gomock.Any(), tenant.ID("bork"), order.OrderNumber("200"), r).Return(nil, order.ErrNotFound)
+	_, err = suite.svc.RequeueOrder(context.TODO(), "bork", "200", "support", "accrual system fixed")
+	suite.ErrorIs(err, order.ErrNotFound)
+}
+
+func (suite *adminServiceTestSuite) TestForceOrderStatus() {
+	r := admin.AuditRecord{Admin: "support", Reason: "confirmed by partner"}
+	suite.store.EXPECT().ForceOrderStatus(gomock.Any(), tenant.ID("bork"), order.OrderNumber("100"), order.StatusProcessed, amount(50), r).
+		Return(&order.Order{Number: "100", Status: order.StatusProcessed, Accrual: amount(50)}, nil)
+	_, err := suite.svc.ForceOrderStatus(context.TODO(), "bork", "100", order.StatusProcessed, amount(50), "support", "confirmed by partner")
+	suite.NoError(err)
+
+	_, err = suite.svc.ForceOrderStatus(context.TODO(), "bork", "100", "DONE", nil, "support", "confirmed by partner")
+	suite.ErrorIs(err, order.ErrUnknownStatus)
+	_, err = suite.svc.ForceOrderStatus(context.TODO(), "bork", "100", order.StatusInvalid, amount(50), "support", "confirmed by partner")
+	suite.ErrorIs(err, admin.ErrInvalidAmount)
+	_, err = suite.svc.ForceOrderStatus(context.TODO(), "bork", "100", order.StatusProcessed, amount(-1), "support", "confirmed by partner")
+	suite.ErrorIs(err, admin.ErrInvalidAmount)
+	_, err = suite.svc.ForceOrderStatus(context.TODO(), "bork", "100", order.StatusInvalid, nil, "support", "")
+	suite.ErrorIs(err, admin.ErrReasonRequired)
+}
+
+func (suite *adminServiceTestSuite) TestNewAdjustment() {
+	suite.store.EXPECT().GetUserByLogin(gomock.Any(), tenant.ID("bork"), "u1").Return(&user.User{ID: 1, Login: "u1"}, nil).Times(2)
+	suite.store.EXPECT().NewAdjustment(gomock.Any(), admin.Adjustment{UserID: 1, Amount: -30, Reason: "compensation", Admin: "support"}).
+		Return(&admin.Adjustment{ID: 1, UserID: 1, Amount: -30, Balance: 70}, nil)
+	adj, err := suite.svc.NewAdjustment(context.TODO(), "bork", "u1", -30, "support", "compensation")
+	suite.NoError(err)
+	suite.Equal(float32(70), adj.Balance)
+
+	suite.store.EXPECT().NewAdjustment(gomock.Any(), gomock.Any()).Return(nil, withdraw.ErrInsufficientBalance)
+	_, err = suite.svc.NewAdjustment(context.TODO(), "bork", "u1", -300, "support", "compensation")
+	suite.ErrorIs(err, withdraw.ErrInsufficientBalance)
+
+	suite.store.EXPECT().GetUserByLogin(gomock.Any(), tenant.ID("bork"), "u2").Return(nil, nil)
+	_, err = suite.svc.NewAdjustment(context.TODO(), "bork", "u2", 30, "support", "compensation")
+	suite.ErrorIs(err, user.ErrNotFound)
+
+	_, err = suite.svc.NewAdjustment(context.TODO(), "bork", "u1", 0, "support", "compensation")
+	suite.ErrorIs(err, admin.ErrInvalidAmount)
+}
+
+func (suite *adminServiceTestSuite) TestNewPromo() {
+	r := admin.AuditRecord{Admin: "support", Reason: "black friday"}
+	p := promo.Promo{Code: "FRIDAY", Amount: 100, MaxRedemptions: 10}
+	suite.store.EXPECT().NewPromo(gomock.Any(), tenant.ID("bork"), p, r).Return(&promo.Promo{ID: 1, Code: p.Code}, nil)
+	created, err := suite.svc.NewPromo(context.TODO(), "bork", p, "support", "black friday")
+	suite.NoError(err)
+	suite.Equal(promo.ID(1), created.ID)
+
+	suite.store.EXPECT().NewPromo(gomock.Any(), tenant.ID("bork"), p, r).Return(nil, promo.ErrDuplicated)
+	_, err = suite.svc.NewPromo(context.TODO(), "bork", p, "support", "black friday")
+	suite.ErrorIs(err, promo.ErrDuplicated)
+
+	suite.store.EXPECT().NewPromo(gomock.Any(), tenant.ID("bork"), p, r).Return(nil, errors.New("connection refused"))
+	_, err = suite.svc.NewPromo(context.TODO(), "bork", p, "support", "black friday")
+	suite.Error(err)
+
+	_, err = suite.svc.NewPromo(context.TODO(), "bork", p, "support", " ")
+	suite.ErrorIs(err, admin.ErrReasonRequired)
+	invalid := p
+	invalid.Amount = 0
+	_, err = suite.svc.NewPromo(context.TODO(), "bork", invalid, "support", "black friday")
+	suite.ErrorIs(err, promo.ErrInvalidPromo)
+}
+
+func (suite *adminServiceTestSuite) TestRetryDeadLetterOrders() {
+	r := admin.AuditRecord{Admin: "support", Reason: "accrual system fixed"}
+	numbers := []order.OrderNumber{"100", "200"}
+	suite.store.EXPECT().RetryDeadLetterOrders(gomock.Any(), tenant.ID("bork"), numbers, r).Return([]*order.Order{{Number: "100"}}, nil)
+	orders, err := suite.svc.RetryDeadLetterOrders(context.TODO(), "bork", numbers, "support", "accrual system fixed")
+	suite.NoError(err)
+	suite.Len(orders, 1)
+
+	suite.store.EXPECT().RetryDeadLetterOrders(gomock.Any(), tenant.ID("bork"), nil, r).Return(nil, errors.New("unexpected error"))
+	_, err = suite.svc.RetryDeadLetterOrders(context.TODO(), "bork", nil, "support", "accrual system fixed")
+	suite.Error(err)
+
+	_, err = suite.svc.RetryDeadLetterOrders(context.TODO(), "bork", numbers, "support", "")
+	suite.ErrorIs(err, admin.ErrReasonRequired)
+}
+
+func (suite *adminServiceTestSuite) TestRewardRules() {
+	r := admin.AuditRecord{Admin: "support", Reason: "new partner"}
+	rule := reward.Rule{Name: "Чайники Bork", Match: "чайник", Reward: 10, RewardType: reward.TypePercent}
+	suite.store.EXPECT().NewRewardRule(gomock.Any(), tenant.ID("bork"), rule, r).Return(&reward.Rule{ID: 1, Name: rule.Name}, nil)
+	created, err := suite.svc.NewRewardRule(context.TODO(), "bork", rule, "support", "new partner")
+	suite.NoError(err)
+	suite.Equal(reward.ID(1), created.ID)
+
+	_, err = suite.svc.NewRewardRule(context.TODO(), "bork", rule, "support", "")
+	suite.ErrorIs(err, admin.ErrReasonRequired)
+	invalid := rule
+	invalid.Reward = 150
+	_, err = suite.svc.NewRewardRule(context.TODO(), "bork", invalid, "support", "new partner")
+	suite.ErrorIs(err, reward.ErrInvalidRule)
+
+	rule.ID = 2
+	suite.store.EXPECT().UpdateRewardRule(gomock.Any(), tenant.ID("bork"), rule, r).Return(nil, reward.ErrNotFound)
+	_, err = suite.svc.UpdateRewardRule(context.TODO(), "bork", rule, "support", "new partner")
+	suite.ErrorIs(err, reward.ErrNotFound)
+
+	suite.store.EXPECT().DeleteRewardRule(gomock.Any(), tenant.ID("bork"), reward.ID(1), r).Return(errors.New("connection refused"))
+	err = suite.svc.DeleteRewardRule(context.TODO(), "bork", 1, "support", "new partner")
+	suite.Error(err)
+}
@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/k1nky/gophermart/internal/entity/admin"
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/promo"
+	"github.com/k1nky/gophermart/internal/entity/reward"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+	"github.com/k1nky/gophermart/internal/entity/user"
+)
+
+//go:generate mockgen -source=contract.go -destination=mock/storage.go -package=mock storage
+type storage interface {
+	GetUserByLogin(ctx context.Context, tenantID tenant.ID, login string) (*user.User, error)
+	RequeueOrder(ctx context.Context, tenantID tenant.ID, number order.OrderNumber, r admin.AuditRecord) (*order.Order, error)
+	ForceOrderStatus(ctx context.Context, tenantID tenant.ID, number order.OrderNumber, status order.OrderStatus, accrual *float32, r admin.AuditRecord) (*order.Order, error)
+	NewAdjustment(ctx context.Context, adj admin.Adjustment) (*admin.Adjustment, error)
+	NewPromo(ctx context.Context, tenantID tenant.ID, p promo.Promo, r admin.AuditRecord) (*promo.Promo, error)
+	GetDeadLetterOrders(ctx context.Context, tenantID tenant.ID, maxRows uint) ([]*order.Order, error)
+	RetryDeadLetterOrders(ctx context.Context, tenantID tenant.ID, numbers []order.OrderNumber, r admin.AuditRecord) ([]*order.Order, error)
+	GetRewardRules(ctx context.Context, tenantID tenant.ID) ([]*reward.Rule, error)
+	NewRewardRule(ctx context.Context, tenantID tenant.ID, rule reward.Rule, r admin.AuditRecord) (*reward.Rule, error)
+	UpdateRewardRule(ctx context.Context, tenantID tenant.ID, rule reward.Rule, r admin.AuditRecord) (*reward.Rule, error)
+	DeleteRewardRule(ctx context.Context, tenantID tenant.ID, id reward.ID, r admin.AuditRecord) error
+}
+
+type logger interface {
+	Debugf(template string, args ...interface{})
+	Infof(template string, args ...interface{})
+	Errorf(template string, args ...interface{})
+}
@@ -0,0 +1,279 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: contract.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	admin "github.com/k1nky/gophermart/internal/entity/admin"
+	order "github.com/k1nky/gophermart/internal/entity/order"
+	promo "github.com/k1nky/gophermart/internal/entity/promo"
+	reward "github.com/k1nky/gophermart/internal/entity/reward"
+	tenant "github.com/k1nky/gophermart/internal/entity/tenant"
+	user "github.com/k1nky/gophermart/internal/entity/user"
+)
+
+// Mockstorage is a mock of storage interface.
+type Mockstorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockstorageMockRecorder
+}
+
+// MockstorageMockRecorder is the mock recorder for Mockstorage.
+type MockstorageMockRecorder struct {
+	mock *Mockstorage
+}
+
+// NewMockstorage creates a new mock instance.
+func NewMockstorage(ctrl *gomock.Controller) *Mockstorage {
+	mock := &Mockstorage{ctrl: ctrl}
+	mock.recorder = &MockstorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockstorage) EXPECT() *MockstorageMockRecorder {
+	return m.recorder
+}
+
+// DeleteRewardRule mocks base method.
+func (m *Mockstorage) DeleteRewardRule(ctx context.Context, tenantID tenant.ID, id reward.ID, r admin.AuditRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRewardRule", ctx, tenantID, id, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRewardRule indicates an expected call of DeleteRewardRule.
+func (mr *MockstorageMockRecorder) DeleteRewardRule(ctx, tenantID, id, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRewardRule", reflect.TypeOf((*Mockstorage)(nil).DeleteRewardRule), ctx, tenantID, id, r)
+}
+
+// ForceOrderStatus mocks base method.
+func (m *Mockstorage) ForceOrderStatus(ctx context.Context, tenantID tenant.ID, number order.OrderNumber, status order.OrderStatus, accrual *float32, r admin.AuditRecord) (*order.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceOrderStatus", ctx, tenantID, number, status, accrual, r)
+	ret0, _ := ret[0].(*order.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForceOrderStatus indicates an expected call of ForceOrderStatus.
+func (mr *MockstorageMockRecorder) ForceOrderStatus(ctx, tenantID, number, status, accrual, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceOrderStatus", reflect.TypeOf((*Mockstorage)(nil).ForceOrderStatus), ctx, tenantID, number, status, accrual, r)
+}
+
+// GetDeadLetterOrders mocks base method.
+func (m *Mockstorage) GetDeadLetterOrders(ctx context.Context, tenantID tenant.ID, maxRows uint) ([]*order.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadLetterOrders", ctx, tenantID, maxRows)
+	ret0, _ := ret[0].([]*order.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLetterOrders indicates an expected call of GetDeadLetterOrders.
+func (mr *MockstorageMockRecorder) GetDeadLetterOrders(ctx, tenantID, maxRows interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadLetterOrders", reflect.TypeOf((*Mockstorage)(nil).GetDeadLetterOrders), ctx, tenantID, maxRows)
+}
+
+// GetRewardRules mocks base method.
+func (m *Mockstorage) GetRewardRules(ctx context.Context, tenantID tenant.ID) ([]*reward.Rule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRewardRules", ctx, tenantID)
+	ret0, _ := ret[0].([]*reward.Rule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRewardRules indicates an expected call of GetRewardRules.
+func (mr *MockstorageMockRecorder) GetRewardRules(ctx, tenantID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRewardRules", reflect.TypeOf((*Mockstorage)(nil).GetRewardRules), ctx, tenantID)
+}
+
+// GetUserByLogin mocks base method.
+func (m *Mockstorage) GetUserByLogin(ctx context.Context, tenantID tenant.ID, login string) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByLogin", ctx, tenantID, login)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByLogin indicates an expected call of GetUserByLogin.
+func (mr *MockstorageMockRecorder) GetUserByLogin(ctx, tenantID, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByLogin", reflect.TypeOf((*Mockstorage)(nil).GetUserByLogin), ctx, tenantID, login)
+}
+
+// NewAdjustment mocks base method.
+func (m *Mockstorage) NewAdjustment(ctx context.Context, adj admin.Adjustment) (*admin.Adjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewAdjustment", ctx, adj)
+	ret0, _ := ret[0].(*admin.Adjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewAdjustment indicates an expected call of NewAdjustment.
+func (mr *MockstorageMockRecorder) NewAdjustment(ctx, adj interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewAdjustment", reflect.TypeOf((*Mockstorage)(nil).NewAdjustment), ctx, adj)
+}
+
+// NewPromo mocks base method.
+func (m *Mockstorage) NewPromo(ctx context.Context, tenantID tenant.ID, p promo.Promo, r admin.AuditRecord) (*promo.Promo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewPromo", ctx, tenantID, p, r)
+	ret0, _ := ret[0].(*promo.Promo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewPromo indicates an expected call of NewPromo.
+func (mr *MockstorageMockRecorder) NewPromo(ctx, tenantID, p, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewPromo", reflect.TypeOf((*Mockstorage)(nil).NewPromo), ctx, tenantID, p, r)
+}
+
+// NewRewardRule mocks base method.
+func (m *Mockstorage) NewRewardRule(ctx context.Context, tenantID tenant.ID, rule reward.Rule, r admin.AuditRecord) (*reward.Rule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewRewardRule", ctx, tenantID, rule, r)
+	ret0, _ := ret[0].(*reward.Rule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewRewardRule indicates an expected call of NewRewardRule.
+func (mr *MockstorageMockRecorder) NewRewardRule(ctx, tenantID, rule, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewRewardRule", reflect.TypeOf((*Mockstorage)(nil).NewRewardRule), ctx, tenantID, rule, r)
+}
+
+// RequeueOrder mocks base method.
+func (m *Mockstorage) RequeueOrder(ctx context.Context, tenantID tenant.ID, number order.OrderNumber, r admin.AuditRecord) (*order.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueOrder", ctx, tenantID, number, r)
+	ret0, _ := ret[0].(*order.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequeueOrder indicates an expected call of RequeueOrder.
+func (mr *MockstorageMockRecorder) RequeueOrder(ctx, tenantID, number, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueOrder", reflect.TypeOf((*Mockstorage)(nil).RequeueOrder), ctx, tenantID, number, r)
+}
+
+// RetryDeadLetterOrders mocks base method.
+func (m *Mockstorage) RetryDeadLetterOrders(ctx context.Context, tenantID tenant.ID, numbers []order.OrderNumber, r admin.AuditRecord) ([]*order.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryDeadLetterOrders", ctx, tenantID, numbers, r)
+	ret0, _ := ret[0].([]*order.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetryDeadLetterOrders indicates an expected call of RetryDeadLetterOrders.
+func (mr *MockstorageMockRecorder) RetryDeadLetterOrders(ctx, tenantID, numbers, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryDeadLetterOrders", reflect.TypeOf((*Mockstorage)(nil).RetryDeadLetterOrders), ctx, tenantID, numbers, r)
+}
+
+// UpdateRewardRule mocks base method.
+func (m *Mockstorage) UpdateRewardRule(ctx context.Context, tenantID tenant.ID, rule reward.Rule, r admin.AuditRecord) (*reward.Rule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRewardRule", ctx, tenantID, rule, r)
+	ret0, _ := ret[0].(*reward.Rule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRewardRule indicates an expected call of UpdateRewardRule.
+func (mr *MockstorageMockRecorder) UpdateRewardRule(ctx, tenantID, rule, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRewardRule", reflect.TypeOf((*Mockstorage)(nil).UpdateRewardRule), ctx, tenantID, rule, r)
+}
+
+// Mocklogger is a mock of logger interface.
+type Mocklogger struct {
+	ctrl     *gomock.Controller
+	recorder *MockloggerMockRecorder
+}
+
+// MockloggerMockRecorder is the mock recorder for Mocklogger.
+type MockloggerMockRecorder struct {
+	mock *Mocklogger
+}
+
+// NewMocklogger creates a new mock instance.
+func NewMocklogger(ctrl *gomock.Controller) *Mocklogger {
+	mock := &Mocklogger{ctrl: ctrl}
+	mock.recorder = &MockloggerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mocklogger) EXPECT() *MockloggerMockRecorder {
+	return m.recorder
+}
+
+// Debugf mocks base method.
+func (m *Mocklogger) Debugf(template string, args ...interface{}) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{template}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Debugf", varargs...)
+}
+
+// Debugf indicates an expected call of Debugf.
+func (mr *MockloggerMockRecorder) Debugf(template interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{template}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debugf", reflect.TypeOf((*Mocklogger)(nil).Debugf), varargs...)
+}
+
+// Errorf mocks base method.
+func (m *Mocklogger) Errorf(template string, args ...interface{}) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{template}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Errorf", varargs...)
+}
+
+// Errorf indicates an expected call of Errorf.
+func (mr *MockloggerMockRecorder) Errorf(template interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{template}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Errorf", reflect.TypeOf((*Mocklogger)(nil).Errorf), varargs...)
+}
+
+// Infof mocks base method.
+func (m *Mocklogger) Infof(template string, args ...interface{}) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{template}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Infof", varargs...)
+}
+
+// Infof indicates an expected call of Infof.
+func (mr *MockloggerMockRecorder) Infof(template interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{template}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Infof", reflect.TypeOf((*Mocklogger)(nil).Infof), varargs...)
+}
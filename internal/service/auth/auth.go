@@ -26,7 +26,7 @@ func New(secret string, tokenExpiration time.Duration, store storage, log logger
 	return s
 }
 
-// Регистрирует нового пользователя и возвращает jwt токен.
+// Регистрирует нового пользователя в программе лояльности newUser.TenantID и возвращает jwt токен.
 func (s *Service) Register(ctx context.Context, newUser user.User) (token string, err error) {
 	var u *user.User
 
@@ -49,7 +49,7 @@ func (s *Service) Register(ctx context.Context, newUser user.User) (token string
 	return token, nil
 }
 
-// Аутентифицирует пользователя пользователя и возвращает jwt токен в случае успеха.
+// Аутентифицирует пользователя программы лояльности credentials.TenantID и возвращает jwt токен в случае успеха.
 func (s *Service) Login(ctx context.Context, credentials user.User) (string, error) {
 	fail := func(err error) (string, error) {
 		wrapped := fmt.Errorf("auth: login failed for %s: %w", credentials.Login, err)
@@ -60,7 +60,7 @@ func (s *Service) Login(ctx context.Context, credentials user.User) (string, err
 		}
 		return "", wrapped
 	}
-	u, err := s.store.GetUserByLogin(ctx, credentials.Login)
+	u, err := s.store.GetUserByLogin(ctx, credentials.TenantID.OrDefault(), credentials.Login)
 	if err != nil {
 		return fail(err)
 	}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
 	"github.com/k1nky/gophermart/internal/entity/user"
 	log "github.com/k1nky/gophermart/internal/logger"
 	"github.com/k1nky/gophermart/internal/service/auth/mock"
@@ -88,13 +89,36 @@ func (suite *authServiceTestSuite) TestLoginCorrectCredentials() {
 	}
 	ctx := context.TODO()
 
-	suite.store.EXPECT().GetUserByLogin(gomock.Any(), "user").Return(&u, nil)
+	suite.store.EXPECT().GetUserByLogin(gomock.Any(), tenant.Default, "user").Return(&u, nil)
 
 	token, err := suite.svc.Login(ctx, credentials)
 	suite.NoError(err)
 	suite.NotEmpty(token)
 }
 
+func (suite *authServiceTestSuite) TestLoginTenant() {
+	credentials := user.User{
+		Login:    "user",
+		Password: "password",
+		TenantID: "bork",
+	}
+	password, _ := user.HashPassword("password")
+	u := user.User{
+		ID:       1,
+		Login:    "user",
+		Password: password,
+		TenantID: "bork",
+	}
+
+	suite.store.EXPECT().GetUserByLogin(gomock.Any(), tenant.ID("bork"), "user").Return(&u, nil)
+
+	token, err := suite.svc.Login(context.TODO(), credentials)
+	suite.NoError(err)
+	claims, err := suite.svc.Authorize(token)
+	suite.NoError(err)
+	suite.Equal(tenant.ID("bork"), claims.Tenant)
+}
+
 func (suite *authServiceTestSuite) TestLoginIncorrectPassword() {
 	credentials := user.User{
 		Login:    "user",
@@ -107,7 +131,7 @@ func (suite *authServiceTestSuite) TestLoginIncorrectPassword() {
 	}
 	ctx := context.TODO()
 
-	suite.store.EXPECT().GetUserByLogin(gomock.Any(), "user").Return(&u, nil)
+	suite.store.EXPECT().GetUserByLogin(gomock.Any(), tenant.Default, "user").Return(&u, nil)
 
 	token, err := suite.svc.Login(ctx, credentials)
 	suite.ErrorIs(err, user.ErrInvalidCredentials)
@@ -121,7 +145,7 @@ func (suite *authServiceTestSuite) TestLoginUserNotExists() {
 	}
 	ctx := context.TODO()
 
-	suite.store.EXPECT().GetUserByLogin(gomock.Any(), "user").Return(nil, nil)
+	suite.store.EXPECT().GetUserByLogin(gomock.Any(), tenant.Default, "user").Return(nil, nil)
 
 	token, err := suite.svc.Login(ctx, credentials)
 	suite.ErrorIs(err, user.ErrInvalidCredentials)
@@ -135,7 +159,7 @@ func (suite *authServiceTestSuite) TestLoginUnexpectedError() {
 	}
 	ctx := context.TODO()
 
-	suite.store.EXPECT().GetUserByLogin(gomock.Any(), "user").Return(nil, errors.New("unexpected error"))
+	suite.store.EXPECT().GetUserByLogin(gomock.Any(), tenant.Default, "user").Return(nil, errors.New("unexpected error"))
 
 	token, err := suite.svc.Login(ctx, credentials)
 	suite.Error(err)
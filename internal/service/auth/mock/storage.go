@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	tenant "github.com/k1nky/gophermart/internal/entity/tenant"
 	user "github.com/k1nky/gophermart/internal/entity/user"
 )
 
@@ -36,18 +37,18 @@ func (m *Mockstorage) EXPECT() *MockstorageMockRecorder {
 }
 
 // GetUserByLogin mocks base method.
-func (m *Mockstorage) GetUserByLogin(ctx context.Context, login string) (*user.User, error) {
+func (m *Mockstorage) GetUserByLogin(ctx context.Context, tenantID tenant.ID, login string) (*user.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByLogin", ctx, login)
+	ret := m.ctrl.Call(m, "GetUserByLogin", ctx, tenantID, login)
 	ret0, _ := ret[0].(*user.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserByLogin indicates an expected call of GetUserByLogin.
-func (mr *MockstorageMockRecorder) GetUserByLogin(ctx, login interface{}) *gomock.Call {
+func (mr *MockstorageMockRecorder) GetUserByLogin(ctx, tenantID, login interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByLogin", reflect.TypeOf((*Mockstorage)(nil).GetUserByLogin), ctx, login)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByLogin", reflect.TypeOf((*Mockstorage)(nil).GetUserByLogin), ctx, tenantID, login)
 }
 
 // NewUser mocks base method.
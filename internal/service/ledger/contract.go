@@ -0,0 +1,22 @@
+package ledger
+
+import (
+	"context"
+
+	"github.com/k1nky/gophermart/internal/entity/transaction"
+	"github.com/k1nky/gophermart/internal/entity/user"
+)
+
+//go:generate mockgen -source=contract.go -destination=mock/storage.go -package=mock storage
+type storage interface {
+	GetUserIDs(ctx context.Context) ([]user.ID, error)
+	GetTransactionsByUserID(ctx context.Context, userID user.ID) ([]*transaction.Transaction, error)
+	GetUnrecordedTransactionsByUserID(ctx context.Context, userID user.ID) ([]*transaction.Transaction, error)
+	GetBalanceByUser(ctx context.Context, userID user.ID) (user.Balance, error)
+	ReplaceTransactions(ctx context.Context, userID user.ID, lastSeq uint64, transactions []*transaction.Transaction) error
+}
+
+type logger interface {
+	Debugf(template string, args ...interface{})
+	Errorf(template string, args ...interface{})
+}
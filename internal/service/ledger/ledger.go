@@ -0,0 +1,219 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/k1nky/gophermart/internal/entity/transaction"
+	"github.com/k1nky/gophermart/internal/entity/user"
+)
+
+const (
+	// допустимая погрешность при сравнении балансов, которые хранятся в базе с одинарной точностью
+	BalanceTolerance = 0.01
+)
+
+// Результат проверки журнала транзакций
+type Report struct {
+	// количество проверенных пользователей
+	Users int
+	// найденные расхождения
+	Discrepancies []transaction.Discrepancy
+	// количество пользователей, в журнале которых найдены расхождения
+	AffectedUsers int
+	// количество пользователей, журнал которых был исправлен
+	RepairedUsers int
+	// количество пользователей, расхождения в журнале которых остались после проверки
+	UnresolvedUsers int
+}
+
+type Service struct {
+	store storage
+	log   logger
+}
+
+func New(store storage, log logger) *Service {
+	return &Service{
+		store: store,
+		log:   log,
+	}
+}
+
+// Проверяет журналы и текущие балансы всех пользователей. Если repair истина, то журналы с расхождениями
+// перестраиваются по источникам транзакций, а текущие балансы пересчитываются.
+func (s *Service) Verify(ctx context.Context, repair bool) (Report, error) {
+	report := Report{}
+	ids, err := s.store.GetUserIDs(ctx)
+	if err != nil {
+		return report, fmt.Errorf("ledger: verify: %w", err)
+	}
+	for _, id := range ids {
+		discrepancies, repaired, err := s.verifyUser(ctx, id, repair)
+		if err != nil {
+			return report, fmt.Errorf("ledger: verify user %d: %w", id, err)
+		}
+		report.Users++
+		if len(discrepancies) > 0 {
+			report.AffectedUsers++
+			report.Discrepancies = append(report.Discrepancies, discrepancies...)
+		}
+		if repaired {
+			report.RepairedUsers++
+		} else if len(discrepancies) > 0 {
+			report.UnresolvedUsers++
+		}
+	}
+	return report, nil
+}
+
+func (s *Service) verifyUser(ctx context.Context, userID user.ID, repair bool) ([]transaction.Discrepancy, bool, error) {
+	transactions, err := s.store.GetTransactionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	unrecorded, err := s.store.GetUnrecordedTransactionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	balance, err := s.store.GetBalanceByUser(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	discrepancies := checkChain(userID, transactions)
+	if d := checkBalance(userID, transactions, balance); d != nil {
+		discrepancies = append(discrepancies, *d)
+	}
+	for _, t := range unrecorded {
+		discrepancies = append(discrepancies, transaction.Discrepancy{
+			Kind:       transaction.DiscrepancyMissingTransaction,
+			UserID:     userID,
+			SourceID:   t.SourceID,
+			SourceType: t.SourceType,
+		})
+	}
+	if len(discrepancies) == 0 {
+		return nil, false, nil
+	}
+	s.log.Debugf("ledger: user %d has %d discrepancies", userID, len(discrepancies))
+	if !repair {
+		return discrepancies, false, nil
+	}
+	var lastSeq uint64
+	if n := len(transactions); n > 0 {
+		lastSeq = transactions[n-1].Seq
+	}
+	rebuilt := rebuildChain(transactions, unrecorded)
+	if n := len(rebuilt); n > 0 && rebuilt[n-1].Balance < 0 {
+		discrepancies = append(discrepancies, transaction.Discrepancy{
+			Kind:       transaction.DiscrepancyNegativeBalance,
+			UserID:     userID,
+			Seq:        rebuilt[n-1].Seq,
+			SourceID:   rebuilt[n-1].SourceID,
+			SourceType: rebuilt[n-1].SourceType,
+			Details:    fmt.Sprintf("balance %v after repair", rebuilt[n-1].Balance),
+		})
+		// журнал с отрицательным балансом не записываем, расхождение требует вмешательства
+		return discrepancies, false, nil
+	}
+	if err := s.store.ReplaceTransactions(ctx, userID, lastSeq, rebuilt); err != nil {
+		if errors.Is(err, transaction.ErrConcurrentUpdate) {
+			// у пользователя появились новые транзакции, исправим журнал при следующей проверке
+			s.log.Errorf("ledger: repair user %d: %v", userID, err)
+			return discrepancies, false, nil
+		}
+		return discrepancies, false, err
+	}
+	return discrepancies, true, nil
+}
+
+// Проверяет, что последовательные номера транзакций идут без пропусков, у каждой транзакции есть источник
+// и баланс транзакции равен балансу предыдущей транзакции с учетом изменения по источнику
+func checkChain(userID user.ID, transactions []*transaction.Transaction) []transaction.Discrepancy {
+	discrepancies := make([]transaction.Discrepancy, 0)
+	var (
+		expectedSeq uint64 = 1
+		prevBalance float32
+	)
+	for _, t := range transactions {
+		if t.Seq != expectedSeq {
+			discrepancies = append(discrepancies, transaction.Discrepancy{
+				Kind:       transaction.DiscrepancySequenceGap,
+				UserID:     userID,
+				Seq:        t.Seq,
+				SourceID:   t.SourceID,
+				SourceType: t.SourceType,
+				Details:    fmt.Sprintf("expected seq %d", expectedSeq),
+			})
+		}
+		expectedSeq = t.Seq + 1
+		if t.Amount == nil {
+			discrepancies = append(discrepancies, transaction.Discrepancy{
+				Kind:       transaction.DiscrepancyOrphanTransaction,
+				UserID:     userID,
+				Seq:        t.Seq,
+				SourceID:   t.SourceID,
+				SourceType: t.SourceType,
+			})
+		} else if expected := prevBalance + *t.Amount; math.Abs(float64(expected-t.Balance)) > BalanceTolerance {
+			discrepancies = append(discrepancies, transaction.Discrepancy{
+				Kind:       transaction.DiscrepancyBalanceMismatch,
+				UserID:     userID,
+				Seq:        t.Seq,
+				SourceID:   t.SourceID,
+				SourceType: t.SourceType,
+				Details:    fmt.Sprintf("expected balance %v, got %v", expected, t.Balance),
+			})
+		}
+		// расхождение не распространяем на последующие транзакции
+		prevBalance = t.Balance
+	}
+	return discrepancies
+}
+
+// Проверяет, что текущий баланс пользователя равен балансу последней транзакции,
+// а сумма списаний - сумме транзакций на списание
+func checkBalance(userID user.ID, transactions []*transaction.Transaction, balance user.Balance) *transaction.Discrepancy {
+	var current, withdrawn float32
+	if n := len(transactions); n > 0 {
+		current = transactions[n-1].Balance
+	}
+	for _, t := range transactions {
+		if t.SourceType == transaction.SourceWithdraw && t.Amount != nil {
+			withdrawn -= *t.Amount
+		}
+	}
+	if math.Abs(float64(current-balance.Current)) > BalanceTolerance || math.Abs(float64(withdrawn-balance.Withdrawn)) > BalanceTolerance {
+		return &transaction.Discrepancy{
+			Kind:    transaction.DiscrepancyBalanceSnapshot,
+			UserID:  userID,
+			Details: fmt.Sprintf("expected current %v and withdrawn %v, got %v and %v", current, withdrawn, balance.Current, balance.Withdrawn),
+		}
+	}
+	return nil
+}
+
+// Перестраивает журнал транзакций: исключает транзакции без источника, добавляет транзакции для источников
+// без транзакций, упорядочивает транзакции по времени создания, перенумеровывает их и пересчитывает балансы.
+// Транзакции с одинаковым временем создания сохраняют порядок журнала, добавленные - следуют за ними.
+func rebuildChain(transactions []*transaction.Transaction, unrecorded []*transaction.Transaction) []*transaction.Transaction {
+	rebuilt := make([]*transaction.Transaction, 0, len(transactions)+len(unrecorded))
+	for _, t := range transactions {
+		if t.Amount != nil {
+			rebuilt = append(rebuilt, t)
+		}
+	}
+	rebuilt = append(rebuilt, unrecorded...)
+	sort.SliceStable(rebuilt, func(i, j int) bool {
+		return rebuilt[i].CreatedAt.Before(rebuilt[j].CreatedAt)
+	})
+	var balance float32
+	for i, t := range rebuilt {
+		balance += *t.Amount
+		t.Seq = uint64(i + 1)
+		t.Balance = balance
+	}
+	return rebuilt
+}
@@ -0,0 +1,178 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/k1nky/gophermart/internal/entity/transaction"
+	"github.com/k1nky/gophermart/internal/entity/user"
+	log "github.com/k1nky/gophermart/internal/logger"
+	"github.com/k1nky/gophermart/internal/service/ledger/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ledgerServiceTestSuite struct {
+	suite.Suite
+	store *mock.Mockstorage
+	svc   *Service
+}
+
+func TestLedgerService(t *testing.T) {
+	suite.Run(t, new(ledgerServiceTestSuite))
+}
+
+func (suite *ledgerServiceTestSuite) SetupTest() {
+	ctrl := gomock.NewController(suite.T())
+	suite.store = mock.NewMockstorage(ctrl)
+	suite.svc = New(suite.store, &log.Blackhole{})
+}
+
+func amount(v float32) *float32 {
+	return &v
+}
+
+func (suite *ledgerServiceTestSuite) TestVerifyConsistent() {
+	suite.store.EXPECT().GetUserIDs(gomock.Any()).Return([]user.ID{1}, nil)
+	suite.store.EXPECT().GetTransactionsByUserID(gomock.Any(), user.ID(1)).Return([]*transaction.Transaction{
+		{Seq: 1, SourceID: 1, SourceType: transaction.SourceAccrual, Balance: 100, Amount: amount(100)},
+		{Seq: 2, SourceID: 1, SourceType: transaction.SourceWithdraw, Balance: 70, Amount: amount(-30)},
+	}, nil)
+	suite.store.EXPECT().GetUnrecordedTransactionsByUserID(gomock.Any(), user.ID(1)).Return([]*transaction.Transaction{}, nil)
+	suite.store.EXPECT().GetBalanceByUser(gomock.Any(), user.ID(1)).Return(user.Balance{Current: 70, Withdrawn: 30}, nil)
+
+	report, err := suite.svc.Verify(context.TODO(), true)
+	suite.NoError(err)
+	suite.Equal(1, report.Users)
+	suite.Empty(report.Discrepancies)
+	suite.Equal(0, report.RepairedUsers)
+	suite.Equal(0, report.UnresolvedUsers)
+}
+
+func (suite *ledgerServiceTestSuite) TestVerifyDiscrepancies() {
+	suite.store.EXPECT().GetUserIDs(gomock.Any()).Return([]user.ID{1}, nil)
+	suite.store.EXPECT().GetTransactionsByUserID(gomock.Any(), user.ID(1)).Return([]*transaction.Transaction{
+		{Seq: 1, SourceID: 1, SourceType: transaction.SourceAccrual, Balance: 100, Amount: amount(100)},
+		{Seq: 3, SourceID: 2, SourceType: transaction.SourceAccrual, Balance: 250, Amount: amount(50)},
+		{Seq: 4, SourceID: 3, SourceType: transaction.SourceAccrual, Balance: 300, Amount: nil},
+	}, nil)
+	suite.store.EXPECT().GetUnrecordedTransactionsByUserID(gomock.Any(), user.ID(1)).Return([]*transaction.Transaction{
+		{SourceID: 1, SourceType: transaction.SourceWithdraw, Amount: amount(-20)},
+	}, nil)
+	suite.store.EXPECT().GetBalanceByUser(gomock.Any(), user.ID(1)).Return(user.Balance{Current: 300}, nil)
+
+	report, err := suite.svc.Verify(context.TODO(), false)
+	suite.NoError(err)
+	kinds := make([]transaction.DiscrepancyKind, 0)
+	for _, d := range report.Discrepancies {
+		kinds = append(kinds, d.Kind)
+	}
+	suite.Equal([]transaction.DiscrepancyKind{
+		transaction.DiscrepancySequenceGap,
+		transaction.DiscrepancyBalanceMismatch,
+		transaction.DiscrepancyOrphanTransaction,
+		transaction.DiscrepancyMissingTransaction,
+	}, kinds)
+	suite.Equal(0, report.RepairedUsers)
+	suite.Equal(1, report.UnresolvedUsers)
+}
+
+func (suite *ledgerServiceTestSuite) TestVerifyRepair() {
+	suite.store.EXPECT().GetUserIDs(gomock.Any()).Return([]user.ID{1}, nil)
+	suite.store.EXPECT().GetTransactionsByUserID(gomock.Any(), user.ID(1)).Return([]*transaction.Transaction{
+		{Seq: 1, SourceID: 1, SourceType: transaction.SourceAccrual, Balance: 100, Amount: amount(100)},
+		{Seq: 3, SourceID: 2, SourceType: transaction.SourceAccrual, Balance: 250, Amount: amount(50)},
+		{Seq: 4, SourceID: 3, SourceType: transaction.SourceAccrual, Balance: 300, Amount: nil},
+	}, nil)
+	suite.store.EXPECT().GetUnrecordedTransactionsByUserID(gomock.Any(), user.ID(1)).Return([]*transaction.Transaction{
+		{SourceID: 1, SourceType: transaction.SourceWithdraw, Amount: amount(-20)},
+	}, nil)
+	suite.store.EXPECT().GetBalanceByUser(gomock.Any(), user.ID(1)).Return(user.Balance{Current: 300}, nil)
+	suite.store.EXPECT().ReplaceTransactions(gomock.Any(), user.ID(1), uint64(4), []*transaction.Transaction{
+		{Seq: 1, SourceID: 1, SourceType: transaction.SourceAccrual, Balance: 100, Amount: amount(100)},
+		{Seq: 2, SourceID: 2, SourceType: transaction.SourceAccrual, Balance: 150, Amount: amount(50)},
+		{Seq: 3, SourceID: 1, SourceType: transaction.SourceWithdraw, Balance: 130, Amount: amount(-20)},
+	}).Return(nil)
+
+	report, err := suite.svc.Verify(context.TODO(), true)
+	suite.NoError(err)
+	suite.Len(report.Discrepancies, 4)
+	suite.Equal(1, report.RepairedUsers)
+	suite.Equal(0, report.UnresolvedUsers)
+}
+
+func (suite *ledgerServiceTestSuite) TestVerifyRepairOrdersByCreatedAt() {
+	now := time.Now()
+	suite.store.EXPECT().GetUserIDs(gomock.Any()).Return([]user.ID{1}, nil)
+	suite.store.EXPECT().GetTransactionsByUserID(gomock.Any(), user.ID(1)).Return([]*transaction.Transaction{
+		{Seq: 1, SourceID: 1, SourceType: transaction.SourceAccrual, Balance: 100, Amount: amount(100), CreatedAt: now},
+		{Seq: 2, SourceID: 2, SourceType: transaction.SourceAccrual, Balance: 150, Amount: amount(50), CreatedAt: now.Add(2 * time.Hour)},
+	}, nil)
+	// списание выполнено между начислениями, его транзакция встает между ними
+	suite.store.EXPECT().GetUnrecordedTransactionsByUserID(gomock.Any(), user.ID(1)).Return([]*transaction.Transaction{
+		{SourceID: 1, SourceType: transaction.SourceWithdraw, Amount: amount(-80), CreatedAt: now.Add(time.Hour)},
+	}, nil)
+	suite.store.EXPECT().GetBalanceByUser(gomock.Any(), user.ID(1)).Return(user.Balance{Current: 150}, nil)
+	suite.store.EXPECT().ReplaceTransactions(gomock.Any(), user.ID(1), uint64(2), []*transaction.Transaction{
+		{Seq: 1, SourceID: 1, SourceType: transaction.SourceAccrual, Balance: 100, Amount: amount(100), CreatedAt: now},
+		{Seq: 2, SourceID: 1, SourceType: transaction.SourceWithdraw, Balance: 20, Amount: amount(-80), CreatedAt: now.Add(time.Hour)},
+		{Seq: 3, SourceID: 2, SourceType: transaction.SourceAccrual, Balance: 70, Amount: amount(50), CreatedAt: now.Add(2 * time.Hour)},
+	}).Return(nil)
+
+	report, err := suite.svc.Verify(context.TODO(), true)
+	suite.NoError(err)
+	suite.Equal(1, report.RepairedUsers)
+	suite.Equal(0, report.UnresolvedUsers)
+}
+
+func (suite *ledgerServiceTestSuite) TestVerifyRepairNegativeBalance() {
+	suite.store.EXPECT().GetUserIDs(gomock.Any()).Return([]user.ID{1}, nil)
+	suite.store.EXPECT().GetTransactionsByUserID(gomock.Any(), user.ID(1)).Return([]*transaction.Transaction{
+		{Seq: 1, SourceID: 1, SourceType: transaction.SourceAccrual, Balance: 100, Amount: nil},
+		{Seq: 2, SourceID: 1, SourceType: transaction.SourceWithdraw, Balance: 70, Amount: amount(-30)},
+	}, nil)
+	suite.store.EXPECT().GetUnrecordedTransactionsByUserID(gomock.Any(), user.ID(1)).Return([]*transaction.Transaction{}, nil)
+	suite.store.EXPECT().GetBalanceByUser(gomock.Any(), user.ID(1)).Return(user.Balance{Current: 70, Withdrawn: 30}, nil)
+
+	// журнал с отрицательным балансом не записывается, расхождение требует вмешательства
+	report, err := suite.svc.Verify(context.TODO(), true)
+	suite.NoError(err)
+	suite.Equal(0, report.RepairedUsers)
+	suite.Equal(1, report.UnresolvedUsers)
+	suite.Equal(transaction.DiscrepancyNegativeBalance, report.Discrepancies[len(report.Discrepancies)-1].Kind)
+}
+
+func (suite *ledgerServiceTestSuite) TestVerifyBalanceSnapshot() {
+	transactions := []*transaction.Transaction{
+		{Seq: 1, SourceID: 1, SourceType: transaction.SourceAccrual, Balance: 100, Amount: amount(100)},
+		{Seq: 2, SourceID: 1, SourceType: transaction.SourceWithdraw, Balance: 70, Amount: amount(-30)},
+	}
+	suite.store.EXPECT().GetUserIDs(gomock.Any()).Return([]user.ID{1}, nil)
+	suite.store.EXPECT().GetTransactionsByUserID(gomock.Any(), user.ID(1)).Return(transactions, nil)
+	suite.store.EXPECT().GetUnrecordedTransactionsByUserID(gomock.Any(), user.ID(1)).Return([]*transaction.Transaction{}, nil)
+	suite.store.EXPECT().GetBalanceByUser(gomock.Any(), user.ID(1)).Return(user.Balance{Current: 100, Withdrawn: 0}, nil)
+	suite.store.EXPECT().ReplaceTransactions(gomock.Any(), user.ID(1), uint64(2), transactions).Return(nil)
+
+	report, err := suite.svc.Verify(context.TODO(), true)
+	suite.NoError(err)
+	suite.Len(report.Discrepancies, 1)
+	suite.Equal(transaction.DiscrepancyBalanceSnapshot, report.Discrepancies[0].Kind)
+	suite.Equal(1, report.RepairedUsers)
+}
+
+func (suite *ledgerServiceTestSuite) TestVerifyRepairConcurrentUpdate() {
+	suite.store.EXPECT().GetUserIDs(gomock.Any()).Return([]user.ID{1}, nil)
+	suite.store.EXPECT().GetTransactionsByUserID(gomock.Any(), user.ID(1)).Return([]*transaction.Transaction{}, nil)
+	suite.store.EXPECT().GetUnrecordedTransactionsByUserID(gomock.Any(), user.ID(1)).Return([]*transaction.Transaction{
+		{SourceID: 1, SourceType: transaction.SourceAccrual, Amount: amount(100)},
+	}, nil)
+	suite.store.EXPECT().GetBalanceByUser(gomock.Any(), user.ID(1)).Return(user.Balance{}, nil)
+	suite.store.EXPECT().ReplaceTransactions(gomock.Any(), user.ID(1), uint64(0), gomock.Any()).Return(transaction.ErrConcurrentUpdate)
+
+	report, err := suite.svc.Verify(context.TODO(), true)
+	suite.NoError(err)
+	suite.Len(report.Discrepancies, 1)
+	suite.Equal(0, report.RepairedUsers)
+	suite.Equal(1, report.UnresolvedUsers)
+}
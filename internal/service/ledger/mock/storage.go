@@ -0,0 +1,168 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: contract.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	transaction "github.com/k1nky/gophermart/internal/entity/transaction"
+	user "github.com/k1nky/gophermart/internal/entity/user"
+)
+
+// Mockstorage is a mock of storage interface.
+type Mockstorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockstorageMockRecorder
+}
+
+// MockstorageMockRecorder is the mock recorder for Mockstorage.
+type MockstorageMockRecorder struct {
+	mock *Mockstorage
+}
+
+// NewMockstorage creates a new mock instance.
+func NewMockstorage(ctrl *gomock.Controller) *Mockstorage {
+	mock := &Mockstorage{ctrl: ctrl}
+	mock.recorder = &MockstorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockstorage) EXPECT() *MockstorageMockRecorder {
+	return m.recorder
+}
+
+// GetBalanceByUser mocks base method.
+func (m *Mockstorage) GetBalanceByUser(ctx context.Context, userID user.ID) (user.Balance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceByUser", ctx, userID)
+	ret0, _ := ret[0].(user.Balance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceByUser indicates an expected call of GetBalanceByUser.
+func (mr *MockstorageMockRecorder) GetBalanceByUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceByUser", reflect.TypeOf((*Mockstorage)(nil).GetBalanceByUser), ctx, userID)
+}
+
+// GetTransactionsByUserID mocks base method.
+func (m *Mockstorage) GetTransactionsByUserID(ctx context.Context, userID user.ID) ([]*transaction.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionsByUserID", ctx, userID)
+	ret0, _ := ret[0].([]*transaction.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionsByUserID indicates an expected call of GetTransactionsByUserID.
+func (mr *MockstorageMockRecorder) GetTransactionsByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionsByUserID", reflect.TypeOf((*Mockstorage)(nil).GetTransactionsByUserID), ctx, userID)
+}
+
+// GetUnrecordedTransactionsByUserID mocks base method.
+func (m *Mockstorage) GetUnrecordedTransactionsByUserID(ctx context.Context, userID user.ID) ([]*transaction.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnrecordedTransactionsByUserID", ctx, userID)
+	ret0, _ := ret[0].([]*transaction.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnrecordedTransactionsByUserID indicates an expected call of GetUnrecordedTransactionsByUserID.
+func (mr *MockstorageMockRecorder) GetUnrecordedTransactionsByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnrecordedTransactionsByUserID", reflect.TypeOf((*Mockstorage)(nil).GetUnrecordedTransactionsByUserID), ctx, userID)
+}
+
+// GetUserIDs mocks base method.
+func (m *Mockstorage) GetUserIDs(ctx context.Context) ([]user.ID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserIDs", ctx)
+	ret0, _ := ret[0].([]user.ID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserIDs indicates an expected call of GetUserIDs.
+func (mr *MockstorageMockRecorder) GetUserIDs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIDs", reflect.TypeOf((*Mockstorage)(nil).GetUserIDs), ctx)
+}
+
+// ReplaceTransactions mocks base method.
+func (m *Mockstorage) ReplaceTransactions(ctx context.Context, userID user.ID, lastSeq uint64, transactions []*transaction.Transaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceTransactions", ctx, userID, lastSeq, transactions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceTransactions indicates an expected call of ReplaceTransactions.
+func (mr *MockstorageMockRecorder) ReplaceTransactions(ctx, userID, lastSeq, transactions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceTransactions", reflect.TypeOf((*Mockstorage)(nil).ReplaceTransactions), ctx, userID, lastSeq, transactions)
+}
+
+// Mocklogger is a mock of logger interface.
+type Mocklogger struct {
+	ctrl     *gomock.Controller
+	recorder *MockloggerMockRecorder
+}
+
+// MockloggerMockRecorder is the mock recorder for Mocklogger.
+type MockloggerMockRecorder struct {
+	mock *Mocklogger
+}
+
+// NewMocklogger creates a new mock instance.
+func NewMocklogger(ctrl *gomock.Controller) *Mocklogger {
+	mock := &Mocklogger{ctrl: ctrl}
+	mock.recorder = &MockloggerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mocklogger) EXPECT() *MockloggerMockRecorder {
+	return m.recorder
+}
+
+// Debugf mocks base method.
+func (m *Mocklogger) Debugf(template string, args ...interface{}) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{template}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Debugf", varargs...)
+}
+
+// Debugf indicates an expected call of Debugf.
+func (mr *MockloggerMockRecorder) Debugf(template interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{template}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debugf", reflect.TypeOf((*Mocklogger)(nil).Debugf), varargs...)
+}
+
+// Errorf mocks base method.
+func (m *Mocklogger) Errorf(template string, args ...interface{}) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{template}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Errorf", varargs...)
+}
+
+// Errorf indicates an expected call of Errorf.
+func (mr *MockloggerMockRecorder) Errorf(template interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{template}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Errorf", reflect.TypeOf((*Mocklogger)(nil).Errorf), varargs...)
+}
@@ -0,0 +1,17 @@
+package rules
+
+import (
+	"context"
+
+	"github.com/k1nky/gophermart/internal/entity/reward"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+)
+
+//go:generate mockgen -source=contract.go -destination=mock/storage.go -package=mock storage
+type storage interface {
+	GetRewardRules(ctx context.Context, tenantID tenant.ID) ([]*reward.Rule, error)
+}
+
+type logger interface {
+	Debugf(template string, args ...interface{})
+}
@@ -0,0 +1,46 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/reward"
+)
+
+// Engine собственная система расчёта начислений: рассчитывает вознаграждение за заказ по правилам
+// расчёта вознаграждений и сведениям о покупке, загруженным вместе с заказом. Реализует интерфейс
+// системы расчёта начислений сервиса accrual и может использоваться наравне с внешними системами.
+type Engine struct {
+	store storage
+	log   logger
+}
+
+func New(store storage, log logger) *Engine {
+	return &Engine{
+		store: store,
+		log:   log,
+	}
+}
+
+// Рассчитывает начисление по заказу o по сведениям о покупке и времени загрузки заказа. Заказ без сведений
+// о покупке не может быть рассчитан и получает статус INVALID, заказ со сведениями о покупке - статус PROCESSED
+// и сумму вознаграждений по правилам программы лояльности заказа, действовавшим в момент загрузки заказа.
+func (e *Engine) FetchOrder(ctx context.Context, o order.Order) (*order.Order, error) {
+	if o.Purchase == nil {
+		e.log.Debugf("rules: order #%s has no purchase details", o.Number)
+		return &order.Order{Number: o.Number, Status: order.StatusInvalid}, nil
+	}
+	rules, err := e.store.GetRewardRules(ctx, o.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("rules: fetch order #%s: %w", o.Number, err)
+	}
+	accrual := reward.Evaluate(rules, *o.Purchase, o.UploadedAt)
+	e.log.Debugf("rules: order #%s accrual %v", o.Number, accrual)
+	return &order.Order{Number: o.Number, Status: order.StatusProcessed, Accrual: &accrual}, nil
+}
+
+// Собственная система расчёта начислений всегда доступна
+func (e *Engine) Available() bool {
+	return true
+}
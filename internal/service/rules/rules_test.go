@@ -0,0 +1,73 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/k1nky/gophermart/internal/entity/order"
+	"github.com/k1nky/gophermart/internal/entity/reward"
+	"github.com/k1nky/gophermart/internal/entity/tenant"
+	log "github.com/k1nky/gophermart/internal/logger"
+	"github.com/k1nky/gophermart/internal/service/rules/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type rulesEngineTestSuite struct {
+	suite.Suite
+	store  *mock.Mockstorage
+	engine *Engine
+}
+
+func TestRulesEngine(t *testing.T) {
+	suite.Run(t, new(rulesEngineTestSuite))
+}
+
+func (suite *rulesEngineTestSuite) SetupTest() {
+	ctrl := gomock.NewController(suite.T())
+	suite.store = mock.NewMockstorage(ctrl)
+	suite.engine = New(suite.store, &log.Blackhole{})
+}
+
+func (suite *rulesEngineTestSuite) TestFetchOrder() {
+	uploadedAt := time.Date(2020, 12, 10, 15, 0, 0, 0, time.UTC)
+	expired := uploadedAt.Add(-time.Hour)
+	suite.store.EXPECT().GetRewardRules(gomock.Any(), tenant.ID("bork")).Return([]*reward.Rule{
+		{Match: "чайник", Reward: 10, RewardType: reward.TypePercent},
+		{Reward: 100, RewardType: reward.TypePoints, ValidUntil: &expired},
+	}, nil)
+	o, err := suite.engine.FetchOrder(context.TODO(), order.Order{
+		Number:     "12345678903",
+		Status:     order.StatusNew,
+		UploadedAt: uploadedAt,
+		TenantID:   "bork",
+		Purchase: &order.Purchase{
+			Total:    7000,
+			Currency: "RUB",
+			Items:    []order.Item{{Description: "Чайник Bork", Price: 7000}},
+		},
+	})
+	suite.NoError(err)
+	suite.Equal(order.StatusProcessed, o.Status)
+	if suite.NotNil(o.Accrual) {
+		suite.Equal(float32(700), *o.Accrual)
+	}
+}
+
+func (suite *rulesEngineTestSuite) TestFetchOrderWithoutPurchase() {
+	o, err := suite.engine.FetchOrder(context.TODO(), order.Order{Number: "12345678903", Status: order.StatusNew})
+	suite.NoError(err)
+	suite.Equal(order.StatusInvalid, o.Status)
+	suite.Nil(o.Accrual)
+}
+
+func (suite *rulesEngineTestSuite) TestFetchOrderStorageError() {
+	suite.store.EXPECT().GetRewardRules(gomock.Any(), tenant.Default).Return(nil, errors.New("connection refused"))
+	o, err := suite.engine.FetchOrder(context.TODO(), order.Order{Number: "12345678903", TenantID: tenant.Default, Purchase: &order.Purchase{Total: 100, Currency: "RUB"}})
+	suite.Error(err)
+	suite.Nil(o)
+	// ошибка хранилища не считается некорректным ответом системы начислений
+	suite.NotErrorIs(err, order.ErrInvalidAccrualResponse)
+}